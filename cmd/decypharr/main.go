@@ -5,8 +5,14 @@ import (
 	"fmt"
 	"github.com/sirrobot01/decypharr/internal/config"
 	"github.com/sirrobot01/decypharr/internal/logger"
+	"github.com/sirrobot01/decypharr/internal/storage"
+	"github.com/sirrobot01/decypharr/pkg/blackhole"
+	"github.com/sirrobot01/decypharr/pkg/deluge"
 	"github.com/sirrobot01/decypharr/pkg/qbit"
+	"github.com/sirrobot01/decypharr/pkg/sabnzbd"
 	"github.com/sirrobot01/decypharr/pkg/server"
+	"github.com/sirrobot01/decypharr/pkg/torznab"
+	"github.com/sirrobot01/decypharr/pkg/transmission"
 	"github.com/sirrobot01/decypharr/pkg/version"
 	"github.com/sirrobot01/decypharr/pkg/web"
 	"github.com/sirrobot01/decypharr/pkg/webdav"
@@ -60,17 +66,28 @@ func Start(ctx context.Context) error {
 
 		// Initialize services
 		qb := qbit.New()
+		sab := sabnzbd.New()
 		wd := webdav.New()
 
 		ui := web.New().Routes()
 		webdavRoutes := wd.Routes()
+		streamRoutes := wd.StreamRoutes()
 		qbitRoutes := qb.Routes()
+		sabRoutes := sab.Routes()
+		indexerRoutes := torznab.New().Routes()
+		transmissionRoutes := transmission.New().Routes()
+		delugeRoutes := deluge.New().Routes()
 
 		// Register routes
 		handlers := map[string]http.Handler{
-			"/":       ui,
-			"/api/v2": qbitRoutes,
-			"/webdav": webdavRoutes,
+			"/":             ui,
+			"/api/v2":       qbitRoutes,
+			"/sabnzbd":      sabRoutes,
+			"/webdav":       webdavRoutes,
+			"/stream":       streamRoutes,
+			"/indexers":     indexerRoutes,
+			"/transmission": transmissionRoutes,
+			"/deluge":       delugeRoutes,
 		}
 		srv := server.New(handlers)
 
@@ -98,6 +115,9 @@ func Start(ctx context.Context) error {
 			<-done      // wait for them to finish
 			_log.Info().Msg("Decypharr has been stopped gracefully.")
 			reset() // reset store and services
+			if err := storage.Close(); err != nil {
+				_log.Error().Err(err).Msg("Failed to close database")
+			}
 			return nil
 
 		case <-restartCh:
@@ -170,6 +190,12 @@ func startServices(ctx context.Context, cancelSvc context.CancelFunc, wd *webdav
 		})
 	}
 
+	if cfg := config.Get(); len(cfg.Blackholes) > 0 {
+		safeGo(func() error {
+			return blackhole.New().Start(ctx)
+		})
+	}
+
 	safeGo(func() error {
 		wire.Get().StartWorkers(ctx)
 		return nil
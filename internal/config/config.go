@@ -12,6 +12,7 @@ import (
 	"runtime"
 	"strings"
 	"sync"
+	"time"
 )
 
 type RepairStrategy string
@@ -33,6 +34,7 @@ type Debrid struct {
 	DownloadAPIKeys   []string `json:"download_api_keys,omitempty"`
 	Folder            string   `json:"folder,omitempty"`
 	RcloneMountPath   string   `json:"rclone_mount_path,omitempty"` // Custom rclone mount path for this debrid service
+	FuseMountPath     string   `json:"fuse_mount_path,omitempty"`   // Custom FUSE mount path for this debrid service
 	DownloadUncached  bool     `json:"download_uncached,omitempty"`
 	CheckCached       bool     `json:"check_cached,omitempty"`
 	RateLimit         string   `json:"rate_limit,omitempty"` // 200/minute or 10/second
@@ -42,6 +44,7 @@ type Debrid struct {
 	UnpackRar         bool     `json:"unpack_rar,omitempty"`
 	AddSamples        bool     `json:"add_samples,omitempty"`
 	MinimumFreeSlot   int      `json:"minimum_free_slot,omitempty"` // Minimum active pots to use this debrid
+	Priority          int      `json:"priority,omitempty"`          // Lower is tried first by the priority policy
 	Limit             int      `json:"limit,omitempty"`             // Maximum number of total torrents
 
 	UseWebDav bool `json:"use_webdav,omitempty"`
@@ -67,7 +70,38 @@ type Arr struct {
 	SkipRepair       bool   `json:"skip_repair,omitempty"`
 	DownloadUncached *bool  `json:"download_uncached,omitempty"`
 	SelectedDebrid   string `json:"selected_debrid,omitempty"`
-	Source           string `json:"source,omitempty"` // The source of the arr, e.g. "auto", "config", "". Auto means it was automatically detected from the arr
+	Source           string `json:"source,omitempty"`        // The source of the arr, e.g. "auto", "config", "". Auto means it was automatically detected from the arr
+	DecypharrURL     string `json:"decypharr_url,omitempty"` // Where the arr reaches Decypharr. Set when Decypharr registered itself as the arr's download client, which is then kept in sync
+	Action           string `json:"action,omitempty"`        // symlink, download, strm, none. Defaults to symlink
+}
+
+type Notification struct {
+	Name     string   `json:"name,omitempty"`
+	Type     string   `json:"type,omitempty"` // discord, webhook, apprise, gotify, ntfy
+	URL      string   `json:"url,omitempty"`
+	Token    string   `json:"token,omitempty"`    // Gotify app token or ntfy access token
+	Secret   string   `json:"secret,omitempty"`   // HMAC-SHA256 secret used to sign webhook bodies
+	Events   []string `json:"events,omitempty"`   // Events to send, empty means all
+	Template string   `json:"template,omitempty"` // Go template for the message body
+}
+
+// Indexer is an upstream Torznab/Newznab feed proxied under /indexers/{name}/api
+type Indexer struct {
+	Name          string   `json:"name,omitempty"`
+	URL           string   `json:"url,omitempty"` // Upstream API url, e.g http://prowlarr:9696/1/api
+	APIKey        string   `json:"api_key,omitempty"`
+	Debrids       []string `json:"debrids,omitempty"`        // Debrids to check availability on, empty means all
+	CachedOnly    bool     `json:"cached_only,omitempty"`    // Drop results that aren't cached on any debrid
+	MarkFreeleech bool     `json:"mark_freeleech,omitempty"` // Flag cached results as freeleech so the arrs can score them
+}
+
+// Blackhole is a folder watched for .torrent and .magnet files, dropped files are added for the arr
+type Blackhole struct {
+	Path             string `json:"path,omitempty"`
+	Arr              string `json:"arr,omitempty"`
+	Action           string `json:"action,omitempty"` // symlink, download, strm, none. Defaults to symlink
+	Debrid           string `json:"debrid,omitempty"` // Debrid to add to, empty uses the arr's selected debrid
+	DownloadUncached bool   `json:"download_uncached,omitempty"`
 }
 
 type Repair struct {
@@ -79,6 +113,12 @@ type Repair struct {
 	Workers     int            `json:"workers,omitempty"`
 	ReInsert    bool           `json:"reinsert,omitempty"`
 	Strategy    RepairStrategy `json:"strategy,omitempty"`
+	// DeepVerifyInterval schedules jobs that also sample the files on the WebDAV and check their container headers, empty disables them
+	DeepVerifyInterval string `json:"deep_verify_interval,omitempty"`
+	// WebhookSecret is required on inbound webhooks if set, as the X-Webhook-Secret header or ?secret=
+	WebhookSecret string `json:"webhook_secret,omitempty"`
+	// WebhookOnPlayback also starts repairs on media server plays and library additions, not only on playback errors
+	WebhookOnPlayback bool `json:"webhook_on_playback,omitempty"`
 }
 
 type Auth struct {
@@ -131,29 +171,54 @@ type Rclone struct {
 	LogLevel string `json:"log_level,omitempty"`
 }
 
+// Fuse is the built-in FUSE mount, an alternative to rclone that serves the WebDAV cache in-process
+type Fuse struct {
+	Enabled   bool   `json:"enabled,omitempty"`
+	MountPath string `json:"mount_path,omitempty"` // Global mount folder, each debrid is mounted as a subfolder
+
+	ReadAhead string `json:"read_ahead,omitempty"` // How much is fetched ahead of sequential reads (default 32MB)
+
+	DirCacheTime string `json:"dir_cache_time,omitempty"` // How long the kernel caches entries and attributes (default 5m)
+	AllowOther   bool   `json:"allow_other,omitempty"`
+	UID          uint32 `json:"uid,omitempty"`
+	GID          uint32 `json:"gid,omitempty"`
+}
+
+// ChunkCache keeps the chunks streamed from the debrids on disk. It's one cache for all the debrids
+type ChunkCache struct {
+	Size string `json:"size,omitempty"` // Disk space used, e.g 20GB. Empty disables it
+	Pin  string `json:"pin,omitempty"`  // How much of the start and end of each file is kept over other chunks (default 16MB)
+}
+
 type Config struct {
 	// server
 	BindAddress string `json:"bind_address,omitempty"`
 	URLBase     string `json:"url_base,omitempty"`
 	Port        string `json:"port,omitempty"`
 
-	LogLevel           string      `json:"log_level,omitempty"`
-	Debrids            []Debrid    `json:"debrids,omitempty"`
-	QBitTorrent        QBitTorrent `json:"qbittorrent,omitempty"`
-	Arrs               []Arr       `json:"arrs,omitempty"`
-	Repair             Repair      `json:"repair,omitempty"`
-	WebDav             WebDav      `json:"webdav,omitempty"`
-	Rclone             Rclone      `json:"rclone,omitempty"`
-	AllowedExt         []string    `json:"allowed_file_types,omitempty"`
-	MinFileSize        string      `json:"min_file_size,omitempty"` // Minimum file size to download, 10MB, 1GB, etc
-	MaxFileSize        string      `json:"max_file_size,omitempty"` // Maximum file size to download (0 means no limit)
-	Path               string      `json:"-"`                       // Path to save the config file
-	UseAuth            bool        `json:"use_auth,omitempty"`
-	Auth               *Auth       `json:"-"`
-	DiscordWebhook     string      `json:"discord_webhook_url,omitempty"`
-	RemoveStalledAfter string      `json:"remove_stalled_after,omitzero"`
-	CallbackURL        string      `json:"callback_url,omitempty"`
-	EnableWebdavAuth   bool        `json:"enable_webdav_auth,omitempty"`
+	LogLevel           string         `json:"log_level,omitempty"`
+	Debrids            []Debrid       `json:"debrids,omitempty"`
+	QBitTorrent        QBitTorrent    `json:"qbittorrent,omitempty"`
+	Arrs               []Arr          `json:"arrs,omitempty"`
+	Repair             Repair         `json:"repair,omitempty"`
+	WebDav             WebDav         `json:"webdav,omitempty"`
+	Rclone             Rclone         `json:"rclone,omitempty"`
+	Fuse               Fuse           `json:"fuse,omitempty"`
+	ChunkCache         ChunkCache     `json:"chunk_cache,omitempty"`
+	AllowedExt         []string       `json:"allowed_file_types,omitempty"`
+	MinFileSize        string         `json:"min_file_size,omitempty"` // Minimum file size to download, 10MB, 1GB, etc
+	MaxFileSize        string         `json:"max_file_size,omitempty"` // Maximum file size to download (0 means no limit)
+	Path               string         `json:"-"`                       // Path to save the config file
+	UseAuth            bool           `json:"use_auth,omitempty"`
+	Auth               *Auth          `json:"-"`
+	DiscordWebhook     string         `json:"discord_webhook_url,omitempty"`
+	RemoveStalledAfter string         `json:"remove_stalled_after,omitzero"`
+	CallbackURL        string         `json:"callback_url,omitempty"`
+	EnableWebdavAuth   bool           `json:"enable_webdav_auth,omitempty"`
+	DebridSelection    string         `json:"debrid_selection,omitempty"` // priority, cached, round_robin, slots
+	Notifications      []Notification `json:"notifications,omitempty"`
+	Indexers           []Indexer      `json:"indexers,omitempty"`
+	Blackholes         []Blackhole    `json:"blackholes,omitempty"`
 }
 
 func (c *Config) JsonFile() string {
@@ -167,6 +232,10 @@ func (c *Config) TorrentsFile() string {
 	return filepath.Join(c.Path, "torrents.json")
 }
 
+func (c *Config) DatabaseFile() string {
+	return filepath.Join(c.Path, "decypharr.db")
+}
+
 func (c *Config) loadConfig() error {
 	// Load the config file
 	if configPath == "" {
@@ -231,6 +300,91 @@ func validateRepair(config *Repair) error {
 	return nil
 }
 
+func validateIndexers(indexers []Indexer) error {
+	seen := make(map[string]bool, len(indexers))
+	for _, indexer := range indexers {
+		if indexer.Name == "" || indexer.URL == "" {
+			return errors.New("indexer name and url are required")
+		}
+		if seen[indexer.Name] {
+			return fmt.Errorf("duplicate indexer name: %s", indexer.Name)
+		}
+		seen[indexer.Name] = true
+	}
+	return nil
+}
+
+func validateArrs(arrs []Arr) error {
+	for _, a := range arrs {
+		switch a.Action {
+		case "", "symlink", "download", "strm", "none":
+		default:
+			return fmt.Errorf("invalid action for arr %s: %s", a.Name, a.Action)
+		}
+	}
+	return nil
+}
+
+func validateBlackholes(blackholes []Blackhole) error {
+	seen := make(map[string]bool, len(blackholes))
+	for _, blackhole := range blackholes {
+		if blackhole.Path == "" || blackhole.Arr == "" {
+			return errors.New("blackhole path and arr are required")
+		}
+		switch blackhole.Action {
+		case "", "symlink", "download", "strm", "none":
+		default:
+			return fmt.Errorf("invalid blackhole action for %s: %s", blackhole.Path, blackhole.Action)
+		}
+		path := filepath.Clean(blackhole.Path)
+		if seen[path] {
+			return fmt.Errorf("duplicate blackhole path: %s", blackhole.Path)
+		}
+		seen[path] = true
+	}
+	return nil
+}
+
+func validateFuse(config *Config) error {
+	if !config.Fuse.Enabled {
+		return nil
+	}
+	if runtime.GOOS != "linux" {
+		return errors.New("fuse mounts are only supported on linux")
+	}
+	if config.Rclone.Enabled {
+		return errors.New("rclone and fuse mounts cannot both be enabled")
+	}
+	if config.Fuse.MountPath == "" {
+		return errors.New("fuse mount path is required")
+	}
+	if config.Fuse.ReadAhead != "" {
+		if _, err := ParseSize(config.Fuse.ReadAhead); err != nil {
+			return fmt.Errorf("invalid fuse read_ahead %s: %w", config.Fuse.ReadAhead, err)
+		}
+	}
+	if config.Fuse.DirCacheTime != "" {
+		if _, err := time.ParseDuration(config.Fuse.DirCacheTime); err != nil {
+			return fmt.Errorf("invalid fuse dir_cache_time: %w", err)
+		}
+	}
+	return nil
+}
+
+func validateChunkCache(config *ChunkCache) error {
+	if config.Size != "" {
+		if size, err := ParseSize(config.Size); err != nil || size <= 0 {
+			return fmt.Errorf("invalid chunk_cache size %s", config.Size)
+		}
+	}
+	if config.Pin != "" {
+		if _, err := ParseSize(config.Pin); err != nil {
+			return fmt.Errorf("invalid chunk_cache pin %s: %w", config.Pin, err)
+		}
+	}
+	return nil
+}
+
 func ValidateConfig(config *Config) error {
 	// Run validations concurrently
 
@@ -246,6 +400,26 @@ func ValidateConfig(config *Config) error {
 		return err
 	}
 
+	if err := validateIndexers(config.Indexers); err != nil {
+		return err
+	}
+
+	if err := validateArrs(config.Arrs); err != nil {
+		return err
+	}
+
+	if err := validateBlackholes(config.Blackholes); err != nil {
+		return err
+	}
+
+	if err := validateChunkCache(&config.ChunkCache); err != nil {
+		return err
+	}
+
+	if err := validateFuse(config); err != nil {
+		return err
+	}
+
 	return nil
 }
 
@@ -273,6 +447,16 @@ func Get() *Config {
 	return instance
 }
 
+// GetIndexer returns the proxied indexer with the name, or nil
+func (c *Config) GetIndexer(name string) *Indexer {
+	for i := range c.Indexers {
+		if c.Indexers[i].Name == name {
+			return &c.Indexers[i]
+		}
+	}
+	return nil
+}
+
 func (c *Config) GetMinFileSize() int64 {
 	// 0 means no limit
 	if c.MinFileSize == "" {
@@ -396,6 +580,9 @@ func (c *Config) updateDebrid(d Debrid) Debrid {
 	d.RcUrl = cmp.Or(d.RcUrl, c.WebDav.RcUrl)
 	d.RcUser = cmp.Or(d.RcUser, c.WebDav.RcUser)
 	d.RcPass = cmp.Or(d.RcPass, c.WebDav.RcPass)
+	d.StrmBaseURL = cmp.Or(d.StrmBaseURL, c.WebDav.StrmBaseURL)
+	d.StrmUseStream = d.StrmUseStream || c.WebDav.StrmUseStream
+	d.StreamTokenTTL = cmp.Or(d.StreamTokenTTL, c.WebDav.StreamTokenTTL, "168h") // 7 days
 
 	return d
 }
@@ -455,6 +642,24 @@ func (c *Config) setDefaults() {
 		c.Rclone.DirCacheTime = cmp.Or(c.Rclone.DirCacheTime, "5m")
 		c.Rclone.LogLevel = cmp.Or(c.Rclone.LogLevel, "INFO")
 	}
+
+	// Fuse defaults
+	if c.Fuse.Enabled {
+		// The FUSE mount reads through the chunk cache, it can't be disabled
+		c.ChunkCache.Size = cmp.Or(c.ChunkCache.Size, "10GB")
+		c.Fuse.ReadAhead = cmp.Or(c.Fuse.ReadAhead, "32MB")
+		c.Fuse.DirCacheTime = cmp.Or(c.Fuse.DirCacheTime, "5m")
+		if c.Fuse.UID == 0 {
+			c.Fuse.UID = uint32(os.Getuid())
+		}
+		if c.Fuse.GID == 0 {
+			c.Fuse.GID = uint32(os.Getgid())
+		}
+	}
+	if c.ChunkCache.Size != "" {
+		c.ChunkCache.Pin = cmp.Or(c.ChunkCache.Pin, "16MB")
+	}
+
 	// Load the auth file
 	c.Auth = c.GetAuth()
 
@@ -2,7 +2,6 @@ package config
 
 type WebdavDirectories struct {
 	Filters map[string]string `json:"filters,omitempty"`
-	//SaveStrms bool              `json:"save_streams,omitempty"`
 }
 
 type WebDav struct {
@@ -23,4 +22,11 @@ type WebDav struct {
 
 	// Directories
 	Directories map[string]WebdavDirectories `json:"directories,omitempty"`
+
+	// StrmBaseURL is the address players reach decypharr at, written in .strm files. e.g http://decypharr:8282
+	StrmBaseURL string `json:"strm_base_url,omitempty"`
+	// StrmUseStream writes signed /stream urls in .strm files instead of webdav urls
+	StrmUseStream bool `json:"strm_use_stream,omitempty"`
+	// StreamTokenTTL is how long a signed /stream url is valid, e.g 168h
+	StreamTokenTTL string `json:"stream_token_ttl,omitempty"`
 }
@@ -0,0 +1,99 @@
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "decypharr"
+
+var registry = prometheus.NewRegistry()
+
+var (
+	debridRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "debrid",
+		Name:      "requests_total",
+		Help:      "Total HTTP requests made to debrid providers, by status code. Code is \"error\" for transport failures.",
+	}, []string{"debrid", "method", "code"})
+
+	debridRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "debrid",
+		Name:      "request_duration_seconds",
+		Help:      "Latency of HTTP requests made to debrid providers.",
+		Buckets:   []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30},
+	}, []string{"debrid", "method"})
+
+	invalidLinks = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "debrid",
+		Name:      "invalid_links_total",
+		Help:      "Download links marked as invalid, by reason.",
+	}, []string{"debrid", "reason"})
+
+	webdavBytes = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "webdav",
+		Name:      "streamed_bytes_total",
+		Help:      "Bytes streamed to WebDAV clients.",
+	}, []string{"debrid"})
+
+	repairJobs = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "repair",
+		Name:      "jobs_total",
+		Help:      "Repair jobs that reached a final state, by status.",
+	}, []string{"status"})
+)
+
+func init() {
+	registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		debridRequests,
+		debridRequestDuration,
+		invalidLinks,
+		webdavBytes,
+		repairJobs,
+	)
+}
+
+// Register adds a collector to the decypharr registry.
+// Used for gauges that are read from live state at scrape time
+func Register(c prometheus.Collector) error {
+	return registry.Register(c)
+}
+
+// Handler returns the http handler serving the Prometheus text format
+func Handler() http.Handler {
+	return promhttp.HandlerFor(registry, promhttp.HandlerOpts{})
+}
+
+// ObserveDebridRequest records a single request attempt to a debrid provider.
+// statusCode is 0 when the request failed before a response was received
+func ObserveDebridRequest(debrid, method string, statusCode int, duration time.Duration) {
+	code := "error"
+	if statusCode > 0 {
+		code = strconv.Itoa(statusCode)
+	}
+	debridRequests.WithLabelValues(debrid, method, code).Inc()
+	debridRequestDuration.WithLabelValues(debrid, method).Observe(duration.Seconds())
+}
+
+func IncInvalidLink(debrid, reason string) {
+	invalidLinks.WithLabelValues(debrid, reason).Inc()
+}
+
+func AddWebDavBytes(debrid string, n int) {
+	webdavBytes.WithLabelValues(debrid).Add(float64(n))
+}
+
+func IncRepairJob(status string) {
+	repairJobs.WithLabelValues(status).Inc()
+}
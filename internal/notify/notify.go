@@ -0,0 +1,214 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/sirrobot01/decypharr/internal/config"
+	"github.com/sirrobot01/decypharr/internal/logger"
+	"github.com/sirrobot01/decypharr/internal/request"
+)
+
+// Events fired by decypharr
+const (
+	EventDownloadComplete = "download_complete"
+	EventDownloadFailed   = "download_failed"
+	EventRepairPending    = "repair_pending"
+	EventRepairComplete   = "repair_complete"
+	EventRepairFailed     = "repair_failed"
+	EventRepairCancelled  = "repair_cancelled"
+	EventTest             = "test"
+)
+
+var Events = []string{
+	EventDownloadComplete,
+	EventDownloadFailed,
+	EventRepairPending,
+	EventRepairComplete,
+	EventRepairFailed,
+	EventRepairCancelled,
+}
+
+// Event is what every target receives, and the data passed to message templates
+type Event struct {
+	Event   string         `json:"event"`
+	Status  string         `json:"status"` // success, error, warning, pending
+	Title   string         `json:"title"`
+	Message string         `json:"message"`
+	Data    map[string]any `json:"data,omitempty"`
+	Time    time.Time      `json:"time"`
+}
+
+var (
+	once    sync.Once
+	client  *request.Client
+	_logger zerolog.Logger
+)
+
+func setup() {
+	once.Do(func() {
+		_logger = logger.New("notify")
+		// request.Client retries network errors and 429/5xx with exponential backoff
+		client = request.New(
+			request.WithLogger(_logger),
+			request.WithTimeout(30*time.Second),
+			request.WithMaxRetries(4),
+			request.WithSkipTLSVerify(false),
+		)
+	})
+}
+
+// Send fires the event to every subscribed target in the background
+func Send(event, status, message string, data map[string]any) {
+	targets := configured()
+	if len(targets) == 0 {
+		return
+	}
+	setup()
+	e := newEvent(event, status, message, data)
+	for _, t := range targets {
+		if !subscribed(t, event) {
+			continue
+		}
+		go func(t config.Notification) {
+			if err := send(context.Background(), t, e); err != nil {
+				_logger.Error().Err(err).Str("target", targetName(t)).Str("event", event).Msg("Failed to send notification")
+			}
+		}(t)
+	}
+}
+
+// Test sends a test event to a single target, and waits for the result
+func Test(ctx context.Context, t config.Notification) error {
+	setup()
+	e := newEvent(EventTest, "success", "This is a test notification from Decypharr", map[string]any{"target": targetName(t)})
+	return send(ctx, t, e)
+}
+
+func newEvent(event, status, message string, data map[string]any) Event {
+	return Event{
+		Event:   event,
+		Status:  status,
+		Title:   eventTitle(event),
+		Message: strings.TrimSpace(message),
+		Data:    data,
+		Time:    time.Now(),
+	}
+}
+
+// configured returns the notification targets, including the legacy discord webhook
+func configured() []config.Notification {
+	cfg := config.Get()
+	targets := slices.Clone(cfg.Notifications)
+	if cfg.DiscordWebhook != "" {
+		targets = append(targets, config.Notification{
+			Name: "discord",
+			Type: "discord",
+			URL:  cfg.DiscordWebhook,
+		})
+	}
+	return targets
+}
+
+func subscribed(t config.Notification, event string) bool {
+	if t.URL == "" {
+		return false
+	}
+	return len(t.Events) == 0 || slices.Contains(t.Events, event)
+}
+
+func targetName(t config.Notification) string {
+	if t.Name != "" {
+		return t.Name
+	}
+	return t.Type
+}
+
+func eventTitle(event string) string {
+	switch event {
+	case EventDownloadComplete:
+		return "[Decypharr] Download Completed"
+	case EventDownloadFailed:
+		return "[Decypharr] Download Failed"
+	case EventRepairPending:
+		return "[Decypharr] Repair Completed, Awaiting action"
+	case EventRepairComplete:
+		return "[Decypharr] Repair Complete"
+	case EventRepairFailed:
+		return "[Decypharr] Repair Failed"
+	case EventRepairCancelled:
+		return "[Decypharr] Repair Cancelled"
+	case EventTest:
+		return "[Decypharr] Test Notification"
+	default:
+		// split the event string and capitalize the first letter of each word
+		evs := strings.Split(event, "_")
+		for i, ev := range evs {
+			if ev != "" {
+				evs[i] = strings.ToUpper(ev[:1]) + ev[1:]
+			}
+		}
+		return "[Decypharr] " + strings.Join(evs, " ")
+	}
+}
+
+// render executes the target template with the event, falling back to the event message
+func render(t config.Notification, e Event) (string, error) {
+	if t.Template == "" {
+		return e.Message, nil
+	}
+	tmpl, err := template.New(targetName(t)).Option("missingkey=zero").Parse(t.Template)
+	if err != nil {
+		return "", fmt.Errorf("invalid template: %w", err)
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, e); err != nil {
+		return "", fmt.Errorf("failed to execute template: %w", err)
+	}
+	return buf.String(), nil
+}
+
+func send(ctx context.Context, t config.Notification, e Event) error {
+	body, err := render(t, e)
+	if err != nil {
+		return err
+	}
+	var req *http.Request
+	switch t.Type {
+	case "discord", "":
+		req, err = discordRequest(ctx, t, e, body)
+	case "webhook":
+		req, err = webhookRequest(ctx, t, e, body)
+	case "apprise":
+		req, err = appriseRequest(ctx, t, e, body)
+	case "gotify":
+		req, err = gotifyRequest(ctx, t, e, body)
+	case "ntfy":
+		req, err = ntfyRequest(ctx, t, e, body)
+	default:
+		return fmt.Errorf("unknown notification type: %s", t.Type)
+	}
+	if err != nil {
+		return err
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send %s notification: %w", t.Type, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		bodyBytes, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("%s returned error status code: %s, body: %s", t.Type, resp.Status, string(bodyBytes))
+	}
+	return nil
+}
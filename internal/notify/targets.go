@@ -0,0 +1,157 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/sirrobot01/decypharr/internal/config"
+)
+
+type discordEmbed struct {
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	Color       int    `json:"color"`
+}
+
+type discordWebhook struct {
+	Embeds []discordEmbed `json:"embeds"`
+}
+
+func discordColor(status string) int {
+	switch status {
+	case "success":
+		return 3066993
+	case "error":
+		return 15158332
+	case "warning":
+		return 15844367
+	case "pending":
+		return 3447003
+	default:
+		return 0
+	}
+}
+
+func jsonRequest(ctx context.Context, method, u string, payload any) (*http.Request, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal payload: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u, bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	return req, nil
+}
+
+func discordRequest(ctx context.Context, t config.Notification, e Event, body string) (*http.Request, error) {
+	return jsonRequest(ctx, http.MethodPost, t.URL, discordWebhook{
+		Embeds: []discordEmbed{
+			{
+				Title:       e.Title,
+				Description: body,
+				Color:       discordColor(e.Status),
+			},
+		},
+	})
+}
+
+// webhookRequest posts the event as JSON, or the rendered template as is if the target has one.
+// With a secret, the body is signed with HMAC-SHA256 in the X-Decypharr-Signature header
+func webhookRequest(ctx context.Context, t config.Notification, e Event, body string) (*http.Request, error) {
+	var data []byte
+	if t.Template != "" {
+		data = []byte(body)
+	} else {
+		var err error
+		if data, err = json.Marshal(e); err != nil {
+			return nil, fmt.Errorf("failed to marshal payload: %w", err)
+		}
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, t.URL, bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Decypharr-Event", e.Event)
+	if t.Secret != "" {
+		req.Header.Set("X-Decypharr-Signature", "sha256="+Sign(t.Secret, data))
+	}
+	return req, nil
+}
+
+// Sign returns the hex HMAC-SHA256 of body, as sent in the X-Decypharr-Signature header
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// appriseRequest targets the Apprise API notify endpoint, e.g http://apprise:8000/notify/{key}
+func appriseRequest(ctx context.Context, t config.Notification, e Event, body string) (*http.Request, error) {
+	notifyType := "info"
+	switch e.Status {
+	case "success":
+		notifyType = "success"
+	case "error":
+		notifyType = "failure"
+	case "warning":
+		notifyType = "warning"
+	}
+	return jsonRequest(ctx, http.MethodPost, t.URL, map[string]any{
+		"title":  e.Title,
+		"body":   body,
+		"type":   notifyType,
+		"format": "markdown",
+	})
+}
+
+func gotifyRequest(ctx context.Context, t config.Notification, e Event, body string) (*http.Request, error) {
+	u := strings.TrimRight(t.URL, "/")
+	if !strings.HasSuffix(u, "/message") {
+		u += "/message"
+	}
+	priority := 5
+	if e.Status == "error" {
+		priority = 8
+	}
+	req, err := jsonRequest(ctx, http.MethodPost, u, map[string]any{
+		"title":    e.Title,
+		"message":  body,
+		"priority": priority,
+		"extras": map[string]any{
+			"client::display": map[string]string{"contentType": "text/markdown"},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("X-Gotify-Key", t.Token)
+	return req, nil
+}
+
+// ntfyRequest publishes to the topic in the URL, e.g https://ntfy.sh/decypharr
+func ntfyRequest(ctx context.Context, t config.Notification, e Event, body string) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, t.URL, strings.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Title", e.Title)
+	req.Header.Set("Markdown", "yes")
+	req.Header.Set("Tags", e.Event)
+	if e.Status == "error" {
+		req.Header.Set("Priority", "high")
+	}
+	if t.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+t.Token)
+	}
+	return req, nil
+}
@@ -19,6 +19,7 @@ import (
 
 	"github.com/rs/zerolog"
 	"github.com/sirrobot01/decypharr/internal/logger"
+	"github.com/sirrobot01/decypharr/internal/metrics"
 	"go.uber.org/ratelimit"
 	"golang.org/x/net/proxy"
 )
@@ -61,6 +62,7 @@ type Client struct {
 	retryableStatus map[int]struct{}
 	logger          zerolog.Logger
 	proxy           string
+	metricsName     string // debrid name used as the metrics label, empty disables metrics
 }
 
 // WithMaxRetries sets the maximum number of retry attempts
@@ -127,12 +129,26 @@ func WithRetryableStatus(statusCodes ...int) ClientOption {
 	}
 }
 
+// WithSkipTLSVerify sets whether the server certificates are verified, they're not by default
+func WithSkipTLSVerify(skip bool) ClientOption {
+	return func(c *Client) {
+		c.skipTLSVerify = skip
+	}
+}
+
 func WithProxy(proxyURL string) ClientOption {
 	return func(c *Client) {
 		c.proxy = proxyURL
 	}
 }
 
+// WithMetrics records call counts and latencies of every request under the given debrid name
+func WithMetrics(name string) ClientOption {
+	return func(c *Client) {
+		c.metricsName = name
+	}
+}
+
 // doRequest performs a single HTTP request with rate limiting
 func (c *Client) doRequest(req *http.Request) (*http.Response, error) {
 	if c.rateLimiter != nil {
@@ -144,7 +160,17 @@ func (c *Client) doRequest(req *http.Request) (*http.Response, error) {
 		}
 	}
 
-	return c.client.Do(req)
+	if c.metricsName == "" {
+		return c.client.Do(req)
+	}
+	start := time.Now()
+	resp, err := c.client.Do(req)
+	statusCode := 0
+	if err == nil {
+		statusCode = resp.StatusCode
+	}
+	metrics.ObserveDebridRequest(c.metricsName, req.Method, statusCode, time.Since(start))
+	return resp, err
 }
 
 // Do performs an HTTP request with retries for certain status codes
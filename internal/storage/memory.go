@@ -0,0 +1,73 @@
+package storage
+
+import "sync"
+
+// Memory is a non-persistent Store, used when the database can't be opened
+type Memory struct {
+	buckets map[string]map[string][]byte
+	mu      sync.RWMutex
+}
+
+func NewMemory() *Memory {
+	return &Memory{
+		buckets: make(map[string]map[string][]byte),
+	}
+}
+
+func (m *Memory) Get(bucket, key string) ([]byte, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	value, ok := m.buckets[bucket][key]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return value, nil
+}
+
+func (m *Memory) Put(bucket, key string, value []byte) error {
+	return m.PutMany(bucket, map[string][]byte{key: value})
+}
+
+func (m *Memory) PutMany(bucket string, items map[string][]byte) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	bk, ok := m.buckets[bucket]
+	if !ok {
+		bk = make(map[string][]byte)
+		m.buckets[bucket] = bk
+	}
+	for key, value := range items {
+		bk[key] = append([]byte(nil), value...)
+	}
+	return nil
+}
+
+func (m *Memory) Delete(bucket string, keys ...string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, key := range keys {
+		delete(m.buckets[bucket], key)
+	}
+	return nil
+}
+
+func (m *Memory) ForEach(bucket string, fn func(key string, value []byte) error) error {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	for key, value := range m.buckets[bucket] {
+		if err := fn(key, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *Memory) Count(bucket string) (int, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return len(m.buckets[bucket]), nil
+}
+
+func (m *Memory) Close() error {
+	return nil
+}
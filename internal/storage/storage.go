@@ -0,0 +1,57 @@
+package storage
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/sirrobot01/decypharr/internal/config"
+	"github.com/sirrobot01/decypharr/internal/logger"
+)
+
+var ErrNotFound = errors.New("key not found")
+
+// Store is a transactional key/value store. Keys are grouped in buckets, each bucket is created on first write
+type Store interface {
+	Get(bucket, key string) ([]byte, error)
+	Put(bucket, key string, value []byte) error
+	// PutMany writes all the items in a single transaction
+	PutMany(bucket string, items map[string][]byte) error
+	Delete(bucket string, keys ...string) error
+	// ForEach calls fn for every key in the bucket. The value is only valid during the call
+	ForEach(bucket string, fn func(key string, value []byte) error) error
+	Count(bucket string) (int, error)
+	Close() error
+}
+
+var (
+	instance Store
+	once     sync.Once
+)
+
+// Get returns the store shared by the whole app, opening it on first use
+func Get() Store {
+	once.Do(func() {
+		cfg := config.Get()
+		db, err := NewBolt(cfg.DatabaseFile())
+		if err != nil {
+			// Keep running, without persistence
+			_log := logger.Default()
+			_log.Error().Err(err).Msgf("Failed to open database %s, falling back to memory", cfg.DatabaseFile())
+			instance = NewMemory()
+			return
+		}
+		instance = db
+	})
+	return instance
+}
+
+// Close closes the shared store. The next call to Get will reopen it
+func Close() error {
+	if instance == nil {
+		return nil
+	}
+	err := instance.Close()
+	instance = nil
+	once = sync.Once{}
+	return err
+}
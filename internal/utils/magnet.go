@@ -30,12 +30,17 @@ type Magnet struct {
 	Size     int64  `json:"size"`
 	Link     string `json:"link"`
 	File     []byte `json:"-"`
+	NZB      []byte `json:"-"`
 }
 
 func (m *Magnet) IsTorrent() bool {
 	return m.File != nil
 }
 
+func (m *Magnet) IsNZB() bool {
+	return m.NZB != nil
+}
+
 func GetMagnetFromFile(file io.Reader, filePath string) (*Magnet, error) {
 	var (
 		m   *Magnet
@@ -189,6 +194,11 @@ func ExtractInfoHash(magnetDesc string) string {
 	return hash
 }
 
+// ParseInfoHash returns the lowercase hex form of a hex or base32 infohash
+func ParseInfoHash(input string) (string, error) {
+	return processInfoHash(strings.TrimSpace(input))
+}
+
 func processInfoHash(input string) (string, error) {
 	// Regular expression for a valid 40-character hex infohash
 
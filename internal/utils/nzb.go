@@ -0,0 +1,109 @@
+package utils
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+type nzbFile struct {
+	XMLName xml.Name `xml:"nzb"`
+	Meta    []struct {
+		Type  string `xml:"type,attr"`
+		Value string `xml:",chardata"`
+	} `xml:"head>meta"`
+	Files []struct {
+		Subject  string `xml:"subject,attr"`
+		Segments []struct {
+			Bytes int64 `xml:"bytes,attr"`
+		} `xml:"segments>segment"`
+	} `xml:"file"`
+}
+
+// GetNZBFromBytes parses an NZB document and returns it wrapped in a Magnet.
+// NZBs have no infohash, the SHA1 of the document is used in its place so the rest of the pipeline can key on it
+func GetNZBFromBytes(data []byte) (*Magnet, error) {
+	var nzb nzbFile
+	if err := xml.Unmarshal(data, &nzb); err != nil {
+		return nil, fmt.Errorf("invalid nzb: %w", err)
+	}
+	if len(nzb.Files) == 0 {
+		return nil, fmt.Errorf("invalid nzb: no files")
+	}
+
+	var size int64
+	for _, f := range nzb.Files {
+		for _, s := range f.Segments {
+			size += s.Bytes
+		}
+	}
+
+	name := ""
+	for _, m := range nzb.Meta {
+		if m.Type == "name" || m.Type == "title" {
+			name = strings.TrimSpace(m.Value)
+			break
+		}
+	}
+
+	hash := sha1.Sum(data)
+	return &Magnet{
+		Name:     name,
+		InfoHash: hex.EncodeToString(hash[:]),
+		Size:     size,
+		NZB:      data,
+	}, nil
+}
+
+func GetNZBFromFile(file io.Reader, filePath string) (*Magnet, error) {
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return nil, err
+	}
+	m, err := GetNZBFromBytes(data)
+	if err != nil {
+		return nil, err
+	}
+	if name := strings.TrimSuffix(filepath.Base(filePath), filepath.Ext(filePath)); name != "" {
+		m.Name = name
+	}
+	return m, nil
+}
+
+func GetNZBFromUrl(url string) (*Magnet, error) {
+	if !strings.HasPrefix(url, "http") {
+		return nil, fmt.Errorf("invalid url")
+	}
+	resp, err := http.Get(url)
+	if err != nil {
+		return nil, fmt.Errorf("error making GET request: %v", err)
+	}
+	defer func(resp *http.Response) {
+		err := resp.Body.Close()
+		if err != nil {
+			return
+		}
+	}(resp)
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("error fetching nzb: %s", resp.Status)
+	}
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("error reading response body: %v", err)
+	}
+	m, err := GetNZBFromBytes(data)
+	if err != nil {
+		return nil, err
+	}
+	m.Link = url
+	if m.Name == "" {
+		m.Name = strings.TrimSuffix(path.Base(strings.Split(url, "?")[0]), ".nzb")
+	}
+	return m, nil
+}
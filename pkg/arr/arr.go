@@ -48,6 +48,8 @@ type Arr struct {
 	DownloadUncached *bool  `json:"download_uncached"`
 	SelectedDebrid   string `json:"selected_debrid,omitempty"` // The debrid service selected for this arr
 	Source           string `json:"source,omitempty"`          // The source of the arr, e.g. "auto", "manual". Auto means it was automatically detected from the arr
+	DecypharrURL     string `json:"decypharr_url,omitempty"`   // Where the arr reaches Decypharr, set when Decypharr registered itself as its download client
+	Action           string `json:"action,omitempty"`          // What's done with its downloads: symlink, download, strm or none. Empty is symlink
 }
 
 func New(name, host, token string, cleanup, skipRepair bool, downloadUncached *bool, selectedDebrid, source string) *Arr {
@@ -132,9 +134,10 @@ func (a *Arr) Validate() error {
 }
 
 type Storage struct {
-	Arrs   map[string]*Arr // name -> arr
-	mu     sync.Mutex
-	logger zerolog.Logger
+	Arrs           map[string]*Arr // name -> arr
+	mu             sync.Mutex
+	logger         zerolog.Logger
+	clientStatuses map[string]*DownloadClientStatus // arr name -> last download client sync
 }
 
 func (s *Storage) Cleanup() {
@@ -166,6 +169,7 @@ func NewStorage() *Storage {
 		}
 		name := a.Name
 		as := New(name, a.Host, a.Token, a.Cleanup, a.SkipRepair, a.DownloadUncached, a.SelectedDebrid, a.Source)
+		as.DecypharrURL = a.DecypharrURL
 		if request.ValidateURL(as.Host) != nil {
 			continue
 		}
@@ -232,6 +236,8 @@ func (s *Storage) SyncToConfig() []config.Arr {
 			exists.SkipRepair = arr.SkipRepair
 			exists.DownloadUncached = arr.DownloadUncached
 			exists.SelectedDebrid = arr.SelectedDebrid
+			exists.DecypharrURL = arr.DecypharrURL
+			exists.Action = arr.Action
 			arrConfigs[name] = exists
 		} else {
 			// Add new arr config
@@ -244,6 +250,8 @@ func (s *Storage) SyncToConfig() []config.Arr {
 				DownloadUncached: arr.DownloadUncached,
 				SelectedDebrid:   arr.SelectedDebrid,
 				Source:           arr.Source,
+				DecypharrURL:     arr.DecypharrURL,
+				Action:           arr.Action,
 			}
 		}
 	}
@@ -261,6 +269,8 @@ func (s *Storage) SyncFromConfig(arrs []config.Arr) {
 	arrConfigs := make(map[string]*Arr)
 	for _, a := range arrs {
 		arrConfigs[a.Name] = New(a.Name, a.Host, a.Token, a.Cleanup, a.SkipRepair, a.DownloadUncached, a.SelectedDebrid, a.Source)
+		arrConfigs[a.Name].DecypharrURL = a.DecypharrURL
+		arrConfigs[a.Name].Action = a.Action
 	}
 
 	// Add or update arrs from config
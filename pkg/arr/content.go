@@ -6,6 +6,8 @@ import (
 	"fmt"
 	"golang.org/x/sync/errgroup"
 	"net/http"
+	"net/url"
+	"slices"
 	"strconv"
 	"strings"
 )
@@ -13,6 +15,7 @@ import (
 type episode struct {
 	Id            int `json:"id"`
 	EpisodeFileID int `json:"episodeFileId"`
+	EpisodeNumber int `json:"episodeNumber"`
 }
 
 type sonarrSearch struct {
@@ -26,11 +29,34 @@ type radarrSearch struct {
 	MovieIds []int  `json:"movieIds"`
 }
 
+type lidarrSearch struct {
+	Name     string `json:"name"`
+	AlbumIds []int  `json:"albumIds"`
+}
+
+type readarrSearch struct {
+	Name    string `json:"name"`
+	BookIds []int  `json:"bookIds"`
+}
+
+type rescanCommand struct {
+	Name     string `json:"name"`
+	SeriesId int    `json:"seriesId,omitempty"`
+	MovieId  int    `json:"movieId,omitempty"`
+	ArtistId int    `json:"artistId,omitempty"`
+	AuthorId int    `json:"authorId,omitempty"`
+}
+
 func (a *Arr) GetMedia(mediaId string) ([]Content, error) {
-	// Get series
-	if a.Type == Radarr {
+	switch a.Type {
+	case Radarr:
 		return GetMovies(a, mediaId)
+	case Lidarr:
+		return GetArtists(a, mediaId)
+	case Readarr:
+		return GetAuthors(a, mediaId)
 	}
+	// Get series
 	// This is likely Sonarr
 	resp, err := a.Request(http.MethodGet, fmt.Sprintf("api/v3/series?tvdbId=%s", mediaId), nil)
 	if err != nil {
@@ -63,7 +89,7 @@ func (a *Arr) GetMedia(mediaId string) ([]Content, error) {
 		}
 		var ct Content
 		var seriesFiles []seriesFile
-		episodeFileIDMap := make(map[int]int)
+		episodeFileIDMap := make(map[int]episode)
 		func() {
 			defer resp.Body.Close()
 			if err = json.NewDecoder(resp.Body).Decode(&seriesFiles); err != nil {
@@ -86,26 +112,24 @@ func (a *Arr) GetMedia(mediaId string) ([]Content, error) {
 			}
 
 			for _, e := range episodes {
-				episodeFileIDMap[e.EpisodeFileID] = e.Id
+				episodeFileIDMap[e.EpisodeFileID] = e
 			}
 		}()
 		files := make([]ContentFile, 0)
 		for _, file := range seriesFiles {
-			eId, ok := episodeFileIDMap[file.Id]
-			if !ok {
-				eId = 0
-			}
+			e := episodeFileIDMap[file.Id]
 			if file.Id == 0 || file.Path == "" {
 				// Skip files without path
 				continue
 			}
 			files = append(files, ContentFile{
-				FileId:       file.Id,
-				Path:         file.Path,
-				Id:           d.Id,
-				EpisodeId:    eId,
-				SeasonNumber: file.SeasonNumber,
-				Size:         file.Size,
+				FileId:        file.Id,
+				Path:          file.Path,
+				Id:            d.Id,
+				EpisodeId:     e.Id,
+				EpisodeNumber: e.EpisodeNumber,
+				SeasonNumber:  file.SeasonNumber,
+				Size:          file.Size,
 			})
 		}
 		if len(files) == 0 {
@@ -118,17 +142,94 @@ func (a *Arr) GetMedia(mediaId string) ([]Content, error) {
 	return contents, nil
 }
 
+// FindSeries returns the tvdb id of the series of an episode, for media servers that only know the episode ids.
+// Sonarr's parser matches the title against its library, newer versions also match the file path if given
+func (a *Arr) FindSeries(title, path string, season, episode int) (string, error) {
+	release := title
+	if season > 0 {
+		release = fmt.Sprintf("%s S%02d", title, season)
+		if episode > 0 {
+			release += fmt.Sprintf("E%02d", episode)
+		}
+	}
+	query := url.Values{"title": {release}}
+	if path != "" {
+		query.Set("path", path)
+	}
+	resp, err := a.Request(http.MethodGet, "api/v3/parse?"+query.Encode(), nil)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("failed to parse %s: %s", release, resp.Status)
+	}
+	var data struct {
+		Series *struct {
+			TvdbId int `json:"tvdbId"`
+		} `json:"series"`
+	}
+	if err = json.NewDecoder(resp.Body).Decode(&data); err != nil {
+		return "", fmt.Errorf("failed to decode parse result: %v", err)
+	}
+	if data.Series == nil || data.Series.TvdbId == 0 {
+		return "", fmt.Errorf("series %s not found in %s", title, a.Name)
+	}
+	return strconv.Itoa(data.Series.TvdbId), nil
+}
+
+// HasSeries reports whether the series with the given tvdb id is in the arr
+func (a *Arr) HasSeries(tvdbId string) (bool, error) {
+	resp, err := a.Request(http.MethodGet, "api/v3/series?tvdbId="+url.QueryEscape(tvdbId), nil)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return false, fmt.Errorf("failed to get series: %s", resp.Status)
+	}
+	var data []struct {
+		Id int `json:"id"`
+	}
+	if err = json.NewDecoder(resp.Body).Decode(&data); err != nil {
+		return false, fmt.Errorf("failed to decode series: %v", err)
+	}
+	return len(data) > 0, nil
+}
+
+// HasMovie reports whether the movie with the given tmdb id is in the arr
+func (a *Arr) HasMovie(tmdbId string) (bool, error) {
+	resp, err := a.Request(http.MethodGet, "api/v3/movie?tmdbId="+url.QueryEscape(tmdbId), nil)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return false, fmt.Errorf("failed to get movie: %s", resp.Status)
+	}
+	var data []struct {
+		Id int `json:"id"`
+	}
+	if err = json.NewDecoder(resp.Body).Decode(&data); err != nil {
+		return false, fmt.Errorf("failed to decode movie: %v", err)
+	}
+	return len(data) > 0, nil
+}
+
 func GetMovies(a *Arr, tvId string) ([]Content, error) {
 	resp, err := a.Request(http.MethodGet, fmt.Sprintf("api/v3/movie?tmdbId=%s", tvId), nil)
 	if err != nil {
 		return nil, err
 	}
+	defer resp.Body.Close()
 	if resp.StatusCode == http.StatusNotFound {
 		// This is likely Lidarr or Readarr
+		return GetArtists(a, tvId)
+	}
+	if resp.StatusCode != http.StatusOK {
 		return nil, fmt.Errorf("failed to get movies: %s", resp.Status)
 	}
 	a.Type = Radarr
-	defer resp.Body.Close()
 	var movies []Movie
 	if err = json.NewDecoder(resp.Body).Decode(&movies); err != nil {
 		return nil, fmt.Errorf("failed to decode movies: %v", err)
@@ -157,6 +258,127 @@ func GetMovies(a *Arr, tvId string) ([]Content, error) {
 	return contents, nil
 }
 
+// GetArtists returns the artists with their track files. mbId is the MusicBrainz id of the artist, empty for all artists
+func GetArtists(a *Arr, mbId string) ([]Content, error) {
+	endpoint := "api/v1/artist"
+	if mbId != "" {
+		endpoint += "?mbId=" + url.QueryEscape(mbId)
+	}
+	resp, err := a.Request(http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		// This is likely Readarr
+		return GetAuthors(a, mbId)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to get artists: %s", resp.Status)
+	}
+	a.Type = Lidarr
+
+	var artists []struct {
+		Id         int    `json:"id"`
+		ArtistName string `json:"artistName"`
+	}
+	if err = json.NewDecoder(resp.Body).Decode(&artists); err != nil {
+		return nil, fmt.Errorf("failed to decode artists: %v", err)
+	}
+	contents := make([]Content, 0)
+	for _, artist := range artists {
+		var trackFiles []trackFile
+		if err := a.getJSON(fmt.Sprintf("api/v1/trackfile?artistId=%d", artist.Id), &trackFiles); err != nil {
+			continue
+		}
+		files := make([]ContentFile, 0, len(trackFiles))
+		for _, file := range trackFiles {
+			if file.Id == 0 || file.Path == "" {
+				// Skip files without path
+				continue
+			}
+			files = append(files, ContentFile{
+				FileId:  file.Id,
+				Path:    file.Path,
+				Id:      artist.Id,
+				AlbumId: file.AlbumId,
+				Size:    file.Size,
+			})
+		}
+		if len(files) == 0 {
+			// Skip artists without files
+			continue
+		}
+		contents = append(contents, Content{Title: artist.ArtistName, Id: artist.Id, Files: files})
+	}
+	return contents, nil
+}
+
+// GetAuthors returns the authors with their book files. foreignId is the Goodreads id of the author, empty for all authors
+func GetAuthors(a *Arr, foreignId string) ([]Content, error) {
+	resp, err := a.Request(http.MethodGet, "api/v1/author", nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to get authors: %s", resp.Status)
+	}
+	a.Type = Readarr
+
+	var authors []struct {
+		Id              int    `json:"id"`
+		AuthorName      string `json:"authorName"`
+		ForeignAuthorId string `json:"foreignAuthorId"`
+	}
+	if err = json.NewDecoder(resp.Body).Decode(&authors); err != nil {
+		return nil, fmt.Errorf("failed to decode authors: %v", err)
+	}
+	contents := make([]Content, 0)
+	for _, author := range authors {
+		// Readarr can't filter authors by their foreign id
+		if foreignId != "" && author.ForeignAuthorId != foreignId {
+			continue
+		}
+		var bookFiles []bookFile
+		if err := a.getJSON(fmt.Sprintf("api/v1/bookfile?authorId=%d", author.Id), &bookFiles); err != nil {
+			continue
+		}
+		files := make([]ContentFile, 0, len(bookFiles))
+		for _, file := range bookFiles {
+			if file.Id == 0 || file.Path == "" {
+				// Skip files without path
+				continue
+			}
+			files = append(files, ContentFile{
+				FileId: file.Id,
+				Path:   file.Path,
+				Id:     author.Id,
+				BookId: file.BookId,
+				Size:   file.Size,
+			})
+		}
+		if len(files) == 0 {
+			// Skip authors without files
+			continue
+		}
+		contents = append(contents, Content{Title: author.AuthorName, Id: author.Id, Files: files})
+	}
+	return contents, nil
+}
+
+func (a *Arr) getJSON(endpoint string, v any) error {
+	resp, err := a.Request(http.MethodGet, endpoint, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to get %s: %s", endpoint, resp.Status)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
 // searchSonarr searches for missing files in the arr
 // map ids are series id and season number
 func (a *Arr) searchSonarr(files []ContentFile) error {
@@ -233,6 +455,94 @@ func (a *Arr) searchRadarr(files []ContentFile) error {
 	return nil
 }
 
+// searchLidarr searches the albums of the files, like a season search in Sonarr
+func (a *Arr) searchLidarr(files []ContentFile) error {
+	ids := make([]int, 0)
+	for _, f := range files {
+		if f.AlbumId != 0 && !slices.Contains(ids, f.AlbumId) {
+			ids = append(ids, f.AlbumId)
+		}
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+	return a.searchCommand(lidarrSearch{
+		Name:     "AlbumSearch",
+		AlbumIds: ids,
+	})
+}
+
+// searchReadarr searches the books of the files
+func (a *Arr) searchReadarr(files []ContentFile) error {
+	ids := make([]int, 0)
+	for _, f := range files {
+		if f.BookId != 0 && !slices.Contains(ids, f.BookId) {
+			ids = append(ids, f.BookId)
+		}
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+	return a.searchCommand(readarrSearch{
+		Name:    "BookSearch",
+		BookIds: ids,
+	})
+}
+
+func (a *Arr) searchCommand(payload any) error {
+	resp, err := a.Request(http.MethodPost, a.apiPath("command"), payload)
+	if err != nil {
+		return fmt.Errorf("failed to automatic search: %v", err)
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode >= 300 || resp.StatusCode < 200 {
+		return fmt.Errorf("failed to automatic search. Status Code: %s", resp.Status)
+	}
+	return nil
+}
+
+// apiPath returns the endpoint under the arr's api version, Lidarr and Readarr are on v1
+func (a *Arr) apiPath(endpoint string) string {
+	switch a.Type {
+	case Lidarr, Readarr:
+		return "api/v1/" + endpoint
+	default:
+		return "api/v3/" + endpoint
+	}
+}
+
+// Rescan asks the arr to rescan the series/movies/artists/authors of the files, importing whatever is back on disk
+func (a *Arr) Rescan(files []ContentFile) error {
+	ids := make(map[int]struct{})
+	for _, f := range files {
+		ids[f.Id] = struct{}{}
+	}
+	for id := range ids {
+		var payload rescanCommand
+		switch a.Type {
+		case Sonarr:
+			payload = rescanCommand{Name: "RescanSeries", SeriesId: id}
+		case Radarr:
+			payload = rescanCommand{Name: "RescanMovie", MovieId: id}
+		case Lidarr:
+			payload = rescanCommand{Name: "RefreshArtist", ArtistId: id}
+		case Readarr:
+			payload = rescanCommand{Name: "RefreshAuthor", AuthorId: id}
+		default:
+			return fmt.Errorf("unknown arr type: %s", a.Type)
+		}
+		resp, err := a.Request(http.MethodPost, a.apiPath("command"), payload)
+		if err != nil {
+			return fmt.Errorf("failed to rescan: %v", err)
+		}
+		_ = resp.Body.Close()
+		if resp.StatusCode >= 300 || resp.StatusCode < 200 {
+			return fmt.Errorf("failed to rescan. Status Code: %s", resp.Status)
+		}
+	}
+	return nil
+}
+
 func (a *Arr) SearchMissing(files []ContentFile) error {
 	if len(files) == 0 {
 		return nil
@@ -268,6 +578,10 @@ func (a *Arr) searchMissing(files []ContentFile) error {
 		return a.searchSonarr(files)
 	case Radarr:
 		return a.searchRadarr(files)
+	case Lidarr:
+		return a.searchLidarr(files)
+	case Readarr:
+		return a.searchReadarr(files)
 	default:
 		return fmt.Errorf("unknown arr type: %s", a.Type)
 	}
@@ -328,6 +642,26 @@ func (a *Arr) batchDeleteFiles(files []ContentFile) error {
 		if err != nil {
 			return err
 		}
+	case Lidarr:
+		payload = struct {
+			TrackFileIds []int `json:"trackFileIds"`
+		}{
+			TrackFileIds: ids,
+		}
+		_, err := a.Request(http.MethodDelete, "api/v1/trackfile/bulk", payload)
+		if err != nil {
+			return err
+		}
+	case Readarr:
+		payload = struct {
+			BookFileIds []int `json:"bookFileIds"`
+		}{
+			BookFileIds: ids,
+		}
+		_, err := a.Request(http.MethodDelete, "api/v1/bookfile/bulk", payload)
+		if err != nil {
+			return err
+		}
 	default:
 		return fmt.Errorf("unknown arr type: %s", a.Type)
 	}
@@ -0,0 +1,409 @@
+package arr
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sirrobot01/decypharr/internal/config"
+)
+
+// Name of the download client Decypharr registers in the arrs
+const downloadClientName = "Decypharr"
+
+// DownloadClientStatus is the state of Decypharr's download client in an arr, as of the last sync
+type DownloadClientStatus struct {
+	Arr       string    `json:"arr"`
+	ClientId  int       `json:"client_id,omitempty"`
+	Created   bool      `json:"created,omitempty"` // The client was missing and added
+	Drift     []string  `json:"drift,omitempty"`   // Settings that were changed in the arr, they're set back
+	PathError string    `json:"path_error,omitempty"`
+	Error     string    `json:"error,omitempty"`
+	CheckedAt time.Time `json:"checked_at"`
+}
+
+type downloadClientField struct {
+	Name  string `json:"name"`
+	Value any    `json:"value"`
+}
+
+type downloadClient struct {
+	Id                       int                   `json:"id,omitempty"`
+	Name                     string                `json:"name"`
+	Enable                   bool                  `json:"enable"`
+	Protocol                 string                `json:"protocol"`
+	Priority                 int                   `json:"priority"`
+	RemoveCompletedDownloads bool                  `json:"removeCompletedDownloads"`
+	RemoveFailedDownloads    bool                  `json:"removeFailedDownloads"`
+	Implementation           string                `json:"implementation"`
+	ConfigContract           string                `json:"configContract"`
+	Fields                   []downloadClientField `json:"fields"`
+	Tags                     []int                 `json:"tags"`
+}
+
+func (c *downloadClient) field(name string) any {
+	for _, f := range c.Fields {
+		if f.Name == name {
+			return f.Value
+		}
+	}
+	return nil
+}
+
+// categoryField is the name of the category setting of the qBittorrent client, it's different in every arr.
+// It's looked up in the fields the arr returned, falling back to the arr's type
+func (a *Arr) categoryField(fields []downloadClientField) string {
+	for _, f := range fields {
+		if strings.HasSuffix(f.Name, "Category") && !strings.Contains(f.Name, "Imported") {
+			return f.Name
+		}
+	}
+	switch a.Type {
+	case Radarr:
+		return "movieCategory"
+	case Lidarr:
+		return "musicCategory"
+	case Readarr:
+		return "bookCategory"
+	default:
+		return "tvCategory"
+	}
+}
+
+// DetectType asks the arr what it is, for arrs whose name and host don't tell
+func (a *Arr) DetectType() error {
+	for _, endpoint := range []string{"api/v3/system/status", "api/v1/system/status"} {
+		resp, err := a.Request(http.MethodGet, endpoint, nil)
+		if err != nil {
+			return err
+		}
+		var status struct {
+			AppName string `json:"appName"`
+		}
+		err = json.NewDecoder(resp.Body).Decode(&status)
+		_ = resp.Body.Close()
+		if resp.StatusCode != http.StatusOK || err != nil {
+			continue
+		}
+		if t := InferType("", strings.ToLower(status.AppName)); t != Others {
+			a.Type = t
+			return nil
+		}
+	}
+	return fmt.Errorf("failed to detect the type of %s", a.Host)
+}
+
+// desiredDownloadClient is the qBittorrent client pointing at Decypharr, with the arr's credentials and its name as the category.
+// fields are the current fields of the client, or the arr's defaults
+func (a *Arr) desiredDownloadClient(decypharrURL string, fields []downloadClientField) (*downloadClient, *url.URL, error) {
+	u, err := url.Parse(decypharrURL)
+	if err != nil || u.Hostname() == "" {
+		return nil, nil, fmt.Errorf("invalid decypharr url %s", decypharrURL)
+	}
+	port, _ := strconv.Atoi(u.Port())
+	if port == 0 {
+		port = 80
+		if u.Scheme == "https" {
+			port = 443
+		}
+	}
+	urlBase := strings.Trim(u.Path, "/")
+	if urlBase == "" {
+		urlBase = strings.Trim(config.Get().URLBase, "/")
+	}
+	return &downloadClient{
+		Name:                     downloadClientName,
+		Enable:                   true,
+		Protocol:                 "torrent",
+		Priority:                 1,
+		RemoveCompletedDownloads: true,
+		RemoveFailedDownloads:    true,
+		Implementation:           "QBittorrent",
+		ConfigContract:           "QBittorrentSettings",
+		Fields: []downloadClientField{
+			{Name: "host", Value: u.Hostname()},
+			{Name: "port", Value: port},
+			{Name: "useSsl", Value: u.Scheme == "https"},
+			{Name: "urlBase", Value: urlBase},
+			{Name: "username", Value: a.Host},
+			{Name: "password", Value: a.Token},
+			{Name: a.categoryField(fields), Value: a.Name},
+		},
+		Tags: []int{},
+	}, u, nil
+}
+
+// drift lists the settings of the client that aren't what Decypharr needs. The password isn't compared, arrs don't return it
+func (c *downloadClient) drift(desired *downloadClient) []string {
+	drift := make([]string, 0)
+	if !c.Enable {
+		drift = append(drift, "client is disabled")
+	}
+	if c.Implementation != desired.Implementation {
+		drift = append(drift, fmt.Sprintf("implementation is %s", c.Implementation))
+	}
+	for _, f := range desired.Fields {
+		if f.Name == "password" {
+			continue
+		}
+		current, want := fieldString(c.field(f.Name)), fieldString(f.Value)
+		if current != want {
+			drift = append(drift, fmt.Sprintf("%s is %q instead of %q", f.Name, current, want))
+		}
+	}
+	return drift
+}
+
+func fieldString(v any) string {
+	if v == nil {
+		return ""
+	}
+	return fmt.Sprint(v)
+}
+
+// mergeFields sets the fields Decypharr needs over the current ones, keeping the others as they are
+func mergeFields(current, desired []downloadClientField) []downloadClientField {
+	fields := make([]downloadClientField, 0, len(current)+len(desired))
+	for _, f := range current {
+		if !slices.ContainsFunc(desired, func(d downloadClientField) bool { return d.Name == f.Name }) {
+			fields = append(fields, f)
+		}
+	}
+	return append(fields, desired...)
+}
+
+func (a *Arr) getDownloadClients() ([]downloadClient, error) {
+	var clients []downloadClient
+	if err := a.getJSON(a.apiPath("downloadclient"), &clients); err != nil {
+		return nil, err
+	}
+	return clients, nil
+}
+
+// DecypharrClients returns the names of the arr's download clients that point at Decypharr: the one auto-configure
+// registers, and the qBittorrent clients set up by hand with the arr's host as the username
+func (a *Arr) DecypharrClients() ([]string, error) {
+	clients, err := a.getDownloadClients()
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0)
+	for _, c := range clients {
+		username := strings.TrimRight(fieldString(c.field("username")), "/")
+		if c.Name == downloadClientName || (c.Implementation == "QBittorrent" && username != "" && username == strings.TrimRight(a.Host, "/")) {
+			names = append(names, c.Name)
+		}
+	}
+	return names, nil
+}
+
+// downloadClientDefaults returns the default fields of the arr's qBittorrent client, nil if they can't be fetched
+func (a *Arr) downloadClientDefaults() []downloadClientField {
+	var schema []downloadClient
+	if err := a.getJSON(a.apiPath("downloadclient/schema"), &schema); err != nil {
+		return nil
+	}
+	for _, c := range schema {
+		if c.Implementation == "QBittorrent" {
+			return c.Fields
+		}
+	}
+	return nil
+}
+
+func (a *Arr) saveDownloadClient(client *downloadClient) error {
+	method, endpoint := http.MethodPost, a.apiPath("downloadclient")
+	if client.Id != 0 {
+		method, endpoint = http.MethodPut, a.apiPath(fmt.Sprintf("downloadclient/%d", client.Id))
+	}
+	resp, err := a.Request(method, endpoint, client)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 || resp.StatusCode < 200 {
+		var errs []struct {
+			ErrorMessage string `json:"errorMessage"`
+		}
+		if json.NewDecoder(resp.Body).Decode(&errs) == nil && len(errs) > 0 {
+			return fmt.Errorf("failed to save download client: %s", errs[0].ErrorMessage)
+		}
+		return fmt.Errorf("failed to save download client: %s", resp.Status)
+	}
+	var saved downloadClient
+	if err := json.NewDecoder(resp.Body).Decode(&saved); err == nil && saved.Id != 0 {
+		client.Id = saved.Id
+	}
+	return nil
+}
+
+// SyncDownloadClient registers Decypharr as a qBittorrent download client in the arr, reachable at decypharrURL.
+// If it's already registered, the settings that drifted are set back. The remote path mapping is then checked
+func (a *Arr) SyncDownloadClient(decypharrURL string) *DownloadClientStatus {
+	status := &DownloadClientStatus{Arr: a.Name, CheckedAt: time.Now()}
+	if a.Type == Others {
+		if err := a.DetectType(); err != nil {
+			status.Error = err.Error()
+			return status
+		}
+	}
+	clients, err := a.getDownloadClients()
+	if err != nil {
+		status.Error = err.Error()
+		return status
+	}
+	var current *downloadClient
+	for i := range clients {
+		if strings.EqualFold(clients[i].Name, downloadClientName) {
+			current = &clients[i]
+			break
+		}
+	}
+
+	var fields []downloadClientField
+	if current != nil {
+		fields = current.Fields
+	} else {
+		status.Created = true
+		fields = a.downloadClientDefaults()
+	}
+	desired, u, err := a.desiredDownloadClient(decypharrURL, fields)
+	if err != nil {
+		status.Error = err.Error()
+		return status
+	}
+	desired.Fields = mergeFields(fields, desired.Fields)
+	if current != nil {
+		status.Drift = current.drift(desired)
+		desired.Id = current.Id
+		desired.Priority = current.Priority
+		desired.Tags = current.Tags
+	}
+	if status.Created || len(status.Drift) > 0 {
+		if err := a.saveDownloadClient(desired); err != nil {
+			status.Error = err.Error()
+			return status
+		}
+	}
+	status.ClientId = desired.Id
+
+	if err := a.checkRemotePath(u.Hostname()); err != nil {
+		status.PathError = err.Error()
+	}
+	return status
+}
+
+type remotePathMapping struct {
+	Host       string `json:"host"`
+	RemotePath string `json:"remotePath"`
+	LocalPath  string `json:"localPath"`
+}
+
+// checkRemotePath makes sure the arr sees Decypharr's download folder, through its remote path mappings for host
+func (a *Arr) checkRemotePath(host string) error {
+	folder := config.Get().QBitTorrent.DownloadFolder
+	if folder == "" {
+		return nil
+	}
+	var mappings []remotePathMapping
+	if err := a.getJSON(a.apiPath("remotepathmapping"), &mappings); err != nil {
+		return err
+	}
+	local := withSlash(folder)
+	for _, m := range mappings {
+		if !strings.EqualFold(m.Host, host) {
+			continue
+		}
+		if remote := withSlash(m.RemotePath); strings.HasPrefix(local, remote) {
+			local = withSlash(m.LocalPath) + strings.TrimPrefix(local, remote)
+			break
+		}
+	}
+
+	var result struct {
+		Parent      string `json:"parent"`
+		Directories []any  `json:"directories"`
+	}
+	if err := a.getJSON(a.apiPath("filesystem?includeFiles=false&path="+url.QueryEscape(local)), &result); err != nil {
+		return err
+	}
+	// The arr returns an empty result for folders it can't see
+	if result.Parent == "" && len(result.Directories) == 0 {
+		if local == withSlash(folder) {
+			return fmt.Errorf("%s can't see the download folder %s, mount it at the same path or add a remote path mapping for %s", a.Name, folder, host)
+		}
+		return fmt.Errorf("%s can't see the download folder %s at %s, check the remote path mapping for %s", a.Name, folder, local, host)
+	}
+	return nil
+}
+
+func withSlash(p string) string {
+	return strings.TrimSuffix(path.Clean(p), "/") + "/"
+}
+
+// SyncDownloadClients syncs the download client of every arr Decypharr registered itself in
+func (s *Storage) SyncDownloadClients() {
+	for _, a := range s.GetAll() {
+		if a.DecypharrURL == "" {
+			continue
+		}
+		status := a.SyncDownloadClient(a.DecypharrURL)
+		switch {
+		case status.Error != "":
+			s.logger.Error().Msgf("Failed to sync the download client of %s: %s", a.Name, status.Error)
+		case status.Created:
+			s.logger.Info().Msgf("Download client was missing from %s, added it back", a.Name)
+		case len(status.Drift) > 0:
+			s.logger.Warn().Msgf("Download client of %s drifted, set it back: %s", a.Name, strings.Join(status.Drift, ", "))
+		}
+		if status.PathError != "" {
+			s.logger.Warn().Msg(status.PathError)
+		}
+		s.setDownloadClientStatus(status)
+	}
+}
+
+func (s *Storage) setDownloadClientStatus(status *DownloadClientStatus) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.clientStatuses == nil {
+		s.clientStatuses = make(map[string]*DownloadClientStatus)
+	}
+	s.clientStatuses[status.Arr] = status
+}
+
+// DownloadClientStatuses returns the last sync of the download client of every arr Decypharr registered itself in
+func (s *Storage) DownloadClientStatuses() []*DownloadClientStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	statuses := make([]*DownloadClientStatus, 0, len(s.clientStatuses))
+	for name, status := range s.clientStatuses {
+		if a, ok := s.Arrs[name]; ok && a.DecypharrURL != "" {
+			statuses = append(statuses, status)
+		}
+	}
+	return statuses
+}
+
+// AutoConfigure registers Decypharr as the download client of the arr and adds the arr, its download client
+// is then kept in sync with SyncDownloadClients
+func (s *Storage) AutoConfigure(a *Arr, decypharrURL string) (*DownloadClientStatus, error) {
+	if err := a.Validate(); err != nil {
+		return nil, err
+	}
+	status := a.SyncDownloadClient(decypharrURL)
+	if status.Error != "" {
+		return status, fmt.Errorf("%s", status.Error)
+	}
+	a.DecypharrURL = decypharrURL
+	a.Source = "auto"
+	s.AddOrUpdate(a)
+	s.setDownloadClientStatus(status)
+	return status, nil
+}
@@ -2,6 +2,7 @@ package arr
 
 import (
 	"encoding/json"
+	"fmt"
 	"io"
 	"net/http"
 	gourl "net/url"
@@ -18,6 +19,7 @@ type HistorySchema struct {
 	Records       []struct {
 		ID         int    `json:"id"`
 		DownloadID string `json:"downloadId"`
+		EventType  string `json:"eventType"`
 	} `json:"records"`
 }
 
@@ -32,6 +34,7 @@ type QueueResponseScheme struct {
 
 type QueueSchema struct {
 	SeriesId              int    `json:"seriesId"`
+	MovieId               int    `json:"movieId"`
 	EpisodeId             int    `json:"episodeId"`
 	SeasonNumber          int    `json:"seasonNumber"`
 	Title                 string `json:"title"`
@@ -57,36 +60,90 @@ func (a *Arr) GetHistory(downloadId, eventType string) *HistorySchema {
 	if downloadId != "" {
 		query.Add("downloadId", downloadId)
 	}
-	query.Add("eventType", eventType)
+	if eventType != "" {
+		query.Add("eventType", eventType)
+	}
 	query.Add("pageSize", "100")
-	url := "api/v3/history" + "?" + query.Encode()
-	resp, err := a.Request(http.MethodGet, url, nil)
+	data, err := a.fetchHistory(query)
 	if err != nil {
 		return nil
 	}
+	return data
+}
+
+func (a *Arr) fetchHistory(query gourl.Values) (*HistorySchema, error) {
+	url := a.apiPath("history") + "?" + query.Encode()
+	resp, err := a.Request(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
 	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to get history: %s", resp.Status)
+	}
 	var data *HistorySchema
-
 	if err = json.NewDecoder(resp.Body).Decode(&data); err != nil {
-		return nil
+		return nil, err
 	}
-	return data
+	if data == nil {
+		return nil, fmt.Errorf("failed to get history: empty response")
+	}
+	return data, nil
+}
 
+// DownloadHistory reports whether the arr grabbed and imported the download, from every page of its history.
+// It fails when the arr doesn't filter its history by download, rather than going through all of it
+func (a *Arr) DownloadHistory(downloadId string) (grabbed, imported bool, err error) {
+	query := gourl.Values{}
+	query.Add("downloadId", downloadId)
+	query.Add("page", "1")
+	query.Add("pageSize", "200")
+	seen := 0
+	for {
+		history, err := a.fetchHistory(query)
+		if err != nil {
+			return false, false, fmt.Errorf("failed to get the history of %s: %w", downloadId, err)
+		}
+		for _, r := range history.Records {
+			if !strings.EqualFold(r.DownloadID, downloadId) {
+				return false, false, fmt.Errorf("%s doesn't filter its history by download", a.Name)
+			}
+			switch {
+			case r.EventType == "grabbed":
+				grabbed = true
+			case strings.HasSuffix(r.EventType, "Imported"):
+				// downloadFolderImported, trackFileImported, bookFileImported
+				imported = true
+			}
+		}
+		seen += len(history.Records)
+		if imported || len(history.Records) == 0 || seen >= history.TotalRecords {
+			return grabbed, imported, nil
+		}
+		query.Set("page", strconv.Itoa(history.Page+1))
+	}
 }
 
 func (a *Arr) GetQueue() []QueueSchema {
+	results, _ := a.FetchQueue()
+	return results
+}
+
+// FetchQueue returns the whole queue of the arr, with the error that stopped it if it couldn't fetch every page
+func (a *Arr) FetchQueue() ([]QueueSchema, error) {
 	query := gourl.Values{}
 	query.Add("page", "1")
 	query.Add("pageSize", "200")
 	results := make([]QueueSchema, 0)
 
 	for {
-		url := "api/v3/queue" + "?" + query.Encode()
+		url := a.apiPath("queue") + "?" + query.Encode()
 		resp, err := a.Request(http.MethodGet, url, nil)
 		if err != nil {
-			break
+			return results, err
 		}
 
+		var data QueueResponseScheme
 		func() {
 			defer func(Body io.ReadCloser) {
 				err := Body.Close()
@@ -95,28 +152,24 @@ func (a *Arr) GetQueue() []QueueSchema {
 				}
 			}(resp.Body)
 
-			var data QueueResponseScheme
-			if err = json.NewDecoder(resp.Body).Decode(&data); err != nil {
+			if resp.StatusCode != http.StatusOK {
+				err = fmt.Errorf("failed to get queue: %s", resp.Status)
 				return
 			}
-
-			results = append(results, data.Records...)
-
-			if len(results) >= data.TotalRecords {
-				// We've fetched all records
-				err = io.EOF // Signal to exit the loop
-				return
-			}
-
-			query.Set("page", strconv.Itoa(data.Page+1))
+			err = json.NewDecoder(resp.Body).Decode(&data)
 		}()
-
 		if err != nil {
-			break
+			return results, err
 		}
-	}
 
-	return results
+		results = append(results, data.Records...)
+		if len(data.Records) == 0 || len(results) >= data.TotalRecords {
+			// We've fetched all records
+			return results, nil
+		}
+
+		query.Set("page", strconv.Itoa(data.Page+1))
+	}
 }
 
 func (a *Arr) CleanupQueue() error {
@@ -181,7 +234,7 @@ func (a *Arr) CleanupQueue() error {
 	query.Add("blocklist", "true")
 	query.Add("skipRedownload", "false")
 	query.Add("changeCategory", "false")
-	url := "api/v3/queue/bulk" + "?" + query.Encode()
+	url := a.apiPath("queue/bulk") + "?" + query.Encode()
 
 	_, err := a.Request(http.MethodDelete, url, payload)
 	if err != nil {
@@ -189,3 +242,27 @@ func (a *Arr) CleanupQueue() error {
 	}
 	return nil
 }
+
+// RemoveFromQueue removes the items from the queue without blocklisting them
+func (a *Arr) RemoveFromQueue(ids []int, removeFromClient bool) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	payload := struct {
+		Ids []int `json:"ids"`
+	}{
+		Ids: ids,
+	}
+	query := gourl.Values{}
+	query.Add("removeFromClient", strconv.FormatBool(removeFromClient))
+	query.Add("blocklist", "false")
+	resp, err := a.Request(http.MethodDelete, a.apiPath("queue/bulk")+"?"+query.Encode(), payload)
+	if err != nil {
+		return err
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode >= 300 || resp.StatusCode < 200 {
+		return fmt.Errorf("failed to remove queue items: %s", resp.Status)
+	}
+	return nil
+}
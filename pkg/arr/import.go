@@ -3,9 +3,9 @@ package arr
 import (
 	"encoding/json"
 	"fmt"
-	"io"
 	"net/http"
 	gourl "net/url"
+	"slices"
 	"strconv"
 	"time"
 )
@@ -16,7 +16,10 @@ type ImportResponseSchema struct {
 	FolderName   string `json:"folderName"`
 	Name         string `json:"name"`
 	Size         int    `json:"size"`
-	Series       struct {
+	Movie        struct {
+		Id int `json:"id"`
+	} `json:"movie"`
+	Series struct {
 		Title     string `json:"title"`
 		SortTitle string `json:"sortTitle"`
 		Status    string `json:"status"`
@@ -102,21 +105,24 @@ type ImportResponseSchema struct {
 		Id   int    `json:"id"`
 		Name string `json:"name"`
 	} `json:"languages"`
-	QualityWeight     int           `json:"qualityWeight"`
-	CustomFormats     []interface{} `json:"customFormats"`
-	CustomFormatScore int           `json:"customFormatScore"`
-	IndexerFlags      int           `json:"indexerFlags"`
-	ReleaseType       string        `json:"releaseType"`
-	Rejections        []struct {
-		Reason string `json:"reason"`
-		Type   string `json:"type"`
-	} `json:"rejections"`
-	Id int `json:"id"`
+	QualityWeight     int               `json:"qualityWeight"`
+	CustomFormats     []interface{}     `json:"customFormats"`
+	CustomFormatScore int               `json:"customFormatScore"`
+	IndexerFlags      int               `json:"indexerFlags"`
+	ReleaseType       string            `json:"releaseType"`
+	Rejections        []ImportRejection `json:"rejections"`
+	Id                int               `json:"id"`
+}
+
+type ImportRejection struct {
+	Reason string `json:"reason"`
+	Type   string `json:"type"`
 }
 
 type ManualImportRequestFile struct {
 	Path         string `json:"path"`
-	SeriesId     int    `json:"seriesId"`
+	SeriesId     int    `json:"seriesId,omitempty"`
+	MovieId      int    `json:"movieId,omitempty"`
 	SeasonNumber int    `json:"seasonNumber"`
 	EpisodeIds   []int  `json:"episodeIds"`
 	Quality      struct {
@@ -136,15 +142,13 @@ type ManualImportRequestFile struct {
 		Id   int    `json:"id"`
 		Name string `json:"name"`
 	} `json:"languages"`
-	ReleaseGroup      string        `json:"releaseGroup"`
-	CustomFormats     []interface{} `json:"customFormats"`
-	CustomFormatScore int           `json:"customFormatScore"`
-	IndexerFlags      int           `json:"indexerFlags"`
-	ReleaseType       string        `json:"releaseType"`
-	Rejections        []struct {
-		Reason string `json:"reason"`
-		Type   string `json:"type"`
-	} `json:"rejections"`
+	ReleaseGroup      string            `json:"releaseGroup"`
+	CustomFormats     []interface{}     `json:"customFormats"`
+	CustomFormatScore int               `json:"customFormatScore"`
+	IndexerFlags      int               `json:"indexerFlags"`
+	ReleaseType       string            `json:"releaseType"`
+	DownloadId        string            `json:"downloadId,omitempty"`
+	Rejections        []ImportRejection `json:"rejections"`
 }
 
 type ManualImportRequestSchema struct {
@@ -153,25 +157,43 @@ type ManualImportRequestSchema struct {
 	ImportMode string                    `json:"importMode"`
 }
 
-func (a *Arr) Import(path string, seriesId int, seasons []int) (io.ReadCloser, error) {
+// Import asks the arr to import the files of the folder for the download, the same way it imports completed downloads.
+// mediaId is the id of the series in Sonarr, the movie in Radarr. Files the arr rejects for good are left out
+func (a *Arr) Import(path, downloadId string, mediaId int) error {
+	if a.Type != Sonarr && a.Type != Radarr {
+		return fmt.Errorf("manual import isn't supported for %s", a.Type)
+	}
 	query := gourl.Values{}
 	query.Add("folder", path)
-	if seriesId != 0 {
-		query.Add("seriesId", strconv.Itoa(seriesId))
+	if downloadId != "" {
+		query.Add("downloadId", downloadId)
+	}
+	if mediaId != 0 {
+		if a.Type == Radarr {
+			query.Add("movieId", strconv.Itoa(mediaId))
+		} else {
+			query.Add("seriesId", strconv.Itoa(mediaId))
+		}
 	}
 	url := "api/v3/manualimport" + "?" + query.Encode()
 	resp, err := a.Request(http.MethodGet, url, nil)
 	if err != nil {
-		return nil, fmt.Errorf("failed to import, invalid file: %w", err)
+		return fmt.Errorf("failed to import, invalid file: %w", err)
 	}
 	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to list files to import: %s", resp.Status)
+	}
 	var data []ImportResponseSchema
 	if err = json.NewDecoder(resp.Body).Decode(&data); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %w", err)
+		return fmt.Errorf("failed to decode response: %w", err)
 	}
 
 	var files []ManualImportRequestFile
 	for _, d := range data {
+		if slices.ContainsFunc(d.Rejections, func(r ImportRejection) bool { return r.Type == "permanent" }) {
+			continue
+		}
 		episodesIds := []int{}
 		for _, e := range d.Episodes {
 			episodesIds = append(episodesIds, e.Id)
@@ -179,6 +201,7 @@ func (a *Arr) Import(path string, seriesId int, seasons []int) (io.ReadCloser, e
 		file := ManualImportRequestFile{
 			Path:              d.Path,
 			SeriesId:          d.Series.Id,
+			MovieId:           d.Movie.Id,
 			SeasonNumber:      d.SeasonNumber,
 			EpisodeIds:        episodesIds,
 			Quality:           d.Quality,
@@ -188,21 +211,28 @@ func (a *Arr) Import(path string, seriesId int, seasons []int) (io.ReadCloser, e
 			CustomFormatScore: d.CustomFormatScore,
 			IndexerFlags:      d.IndexerFlags,
 			ReleaseType:       d.ReleaseType,
+			DownloadId:        downloadId,
 			Rejections:        d.Rejections,
 		}
 		files = append(files, file)
 	}
+	if len(files) == 0 {
+		return fmt.Errorf("no files to import found in %s", path)
+	}
 	request := ManualImportRequestSchema{
 		Name:       "ManualImport",
 		Files:      files,
-		ImportMode: "copy",
+		ImportMode: "auto", // Whatever the arr does with its completed downloads
 	}
 
 	url = "api/v3/command"
 	resp, err = a.Request(http.MethodPost, url, request)
 	if err != nil {
-		return nil, fmt.Errorf("failed to import: %w", err)
+		return fmt.Errorf("failed to import: %w", err)
 	}
 	defer resp.Body.Close()
-	return resp.Body, nil
+	if resp.StatusCode >= 300 || resp.StatusCode < 200 {
+		return fmt.Errorf("failed to import: %s", resp.Status)
+	}
+	return nil
 }
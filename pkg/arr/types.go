@@ -17,17 +17,20 @@ type Movie struct {
 }
 
 type ContentFile struct {
-	Name         string `json:"name"`
-	Path         string `json:"path"`
-	Id           int    `json:"id"`
-	EpisodeId    int    `json:"showId"`
-	FileId       int    `json:"fileId"`
-	TargetPath   string `json:"targetPath"`
-	IsSymlink    bool   `json:"isSymlink"`
-	IsBroken     bool   `json:"isBroken"`
-	SeasonNumber int    `json:"seasonNumber"`
-	Processed    bool   `json:"processed"`
-	Size         int64  `json:"size"`
+	Name          string `json:"name"`
+	Path          string `json:"path"`
+	Id            int    `json:"id"`
+	EpisodeId     int    `json:"showId"`
+	FileId        int    `json:"fileId"`
+	TargetPath    string `json:"targetPath"`
+	IsSymlink     bool   `json:"isSymlink"`
+	IsBroken      bool   `json:"isBroken"`
+	SeasonNumber  int    `json:"seasonNumber"`
+	EpisodeNumber int    `json:"episodeNumber"`
+	AlbumId       int    `json:"albumId,omitempty"` // Lidarr album of the track
+	BookId        int    `json:"bookId,omitempty"`  // Readarr book of the file
+	Processed     bool   `json:"processed"`
+	Size          int64  `json:"size"`
 }
 
 func (file *ContentFile) Delete() {
@@ -49,3 +52,19 @@ type seriesFile struct {
 	Id           int    `json:"id"`
 	Size         int64  `json:"size"`
 }
+
+type trackFile struct {
+	ArtistId int    `json:"artistId"`
+	AlbumId  int    `json:"albumId"`
+	Path     string `json:"path"`
+	Id       int    `json:"id"`
+	Size     int64  `json:"size"`
+}
+
+type bookFile struct {
+	AuthorId int    `json:"authorId"`
+	BookId   int    `json:"bookId"`
+	Path     string `json:"path"`
+	Id       int    `json:"id"`
+	Size     int64  `json:"size"`
+}
@@ -0,0 +1,186 @@
+package blackhole
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/sirrobot01/decypharr/internal/config"
+	"github.com/sirrobot01/decypharr/internal/logger"
+	"github.com/sirrobot01/decypharr/internal/utils"
+	"github.com/sirrobot01/decypharr/pkg/arr"
+	"github.com/sirrobot01/decypharr/pkg/wire"
+)
+
+const (
+	// How often the folders are rescanned, this is all there is where they can't be watched
+	pollInterval = 10 * time.Second
+	// Files changed more recently than this may still be being written, the next scan picks them up
+	settleTime = 2 * time.Second
+
+	doneFolder   = "done"
+	failedFolder = "failed"
+)
+
+// Watcher adds the .torrent and .magnet files dropped in the blackhole folders
+type Watcher struct {
+	folders        []config.Blackhole
+	downloadFolder string
+	logger         zerolog.Logger
+}
+
+func New() *Watcher {
+	cfg := config.Get()
+	return &Watcher{
+		folders:        cfg.Blackholes,
+		downloadFolder: cfg.QBitTorrent.DownloadFolder,
+		logger:         logger.New("blackhole"),
+	}
+}
+
+// Start watches every folder until ctx is done
+func (w *Watcher) Start(ctx context.Context) error {
+	var wg sync.WaitGroup
+	for _, folder := range w.folders {
+		if err := setupFolder(folder.Path); err != nil {
+			w.logger.Error().Err(err).Str("path", folder.Path).Msg("Failed to set up blackhole folder")
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			w.watch(ctx, folder)
+		}()
+	}
+	wg.Wait()
+	return nil
+}
+
+func setupFolder(path string) error {
+	for _, dir := range []string{path, filepath.Join(path, doneFolder), filepath.Join(path, failedFolder)} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (w *Watcher) watch(ctx context.Context, folder config.Blackhole) {
+	events, err := watchDir(ctx, folder.Path)
+	if err != nil {
+		w.logger.Warn().Err(err).Str("path", folder.Path).Msgf("Cannot watch blackhole folder, polling every %s", pollInterval)
+	} else {
+		w.logger.Info().Str("path", folder.Path).Str("arr", folder.Arr).Msg("Watching blackhole folder")
+	}
+
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	w.scan(ctx, folder)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			w.scan(ctx, folder)
+		case name, ok := <-events:
+			if !ok {
+				// The watch is gone, e.g the folder was removed. Polling carries on
+				w.logger.Warn().Str("path", folder.Path).Msg("Lost watch on blackhole folder, polling instead")
+				events = nil
+				continue
+			}
+			if name == "" {
+				// Events were dropped
+				w.scan(ctx, folder)
+				continue
+			}
+			if isBlackholeFile(name) {
+				w.process(ctx, folder, name)
+			}
+		}
+	}
+}
+
+// scan processes the files that are in the folder and done being written
+func (w *Watcher) scan(ctx context.Context, folder config.Blackhole) {
+	entries, err := os.ReadDir(folder.Path)
+	if err != nil {
+		w.logger.Error().Err(err).Str("path", folder.Path).Msg("Failed to read blackhole folder")
+		return
+	}
+	for _, entry := range entries {
+		if ctx.Err() != nil {
+			return
+		}
+		if !entry.Type().IsRegular() || !isBlackholeFile(entry.Name()) {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil || time.Since(info.ModTime()) < settleTime {
+			continue
+		}
+		w.process(ctx, folder, entry.Name())
+	}
+}
+
+// process adds the file, then moves it to done or failed
+func (w *Watcher) process(ctx context.Context, folder config.Blackhole, name string) {
+	path := filepath.Join(folder.Path, name)
+	if info, err := os.Stat(path); err != nil || !info.Mode().IsRegular() {
+		// Already processed from a scan, or not a file
+		return
+	}
+
+	dest := doneFolder
+	if err := w.add(ctx, folder, path); err != nil {
+		w.logger.Error().Err(err).Str("file", name).Str("arr", folder.Arr).Msg("Failed to add blackhole file")
+		dest = failedFolder
+	} else {
+		w.logger.Info().Str("file", name).Str("arr", folder.Arr).Msg("Added blackhole file")
+	}
+	if err := os.Rename(path, filepath.Join(folder.Path, dest, name)); err != nil {
+		w.logger.Error().Err(err).Str("file", name).Msgf("Failed to move blackhole file to %s", dest)
+	}
+}
+
+func (w *Watcher) add(ctx context.Context, folder config.Blackhole, path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	magnet, err := utils.GetMagnetFromFile(file, filepath.Base(path))
+	if err != nil {
+		return fmt.Errorf("failed to read file: %w", err)
+	}
+
+	a := getArr(folder.Arr)
+	importReq := wire.NewImportRequest(folder.Debrid, w.downloadFolder, magnet, a, wire.ImportAction(a, folder.Action), folder.DownloadUncached, "", wire.ImportTypeBlackhole, false)
+	// The folder's debrid wins over the arr's
+	importReq.SelectedDebrid = cmp.Or(folder.Debrid, importReq.SelectedDebrid)
+	if err := wire.Get().AddTorrent(ctx, importReq); err != nil {
+		return fmt.Errorf("failed to process torrent: %w", err)
+	}
+	return nil
+}
+
+func getArr(name string) *arr.Arr {
+	if a := wire.Get().Arr().Get(name); a != nil {
+		return a
+	}
+	// Arr is not configured, create a new one
+	downloadUncached := false
+	return arr.New(name, "", "", false, false, &downloadUncached, "", "auto")
+}
+
+func isBlackholeFile(name string) bool {
+	ext := filepath.Ext(name)
+	return ext == ".torrent" || ext == ".magnet"
+}
@@ -0,0 +1,75 @@
+//go:build linux
+
+package blackhole
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"os"
+	"strings"
+	"syscall"
+)
+
+// watchDir sends the names of the files written or moved into dir, until ctx is done.
+// An empty name means events were dropped and dir should be rescanned
+func watchDir(ctx context.Context, dir string) (<-chan string, error) {
+	fd, err := syscall.InotifyInit1(syscall.IN_CLOEXEC | syscall.IN_NONBLOCK)
+	if err != nil {
+		return nil, fmt.Errorf("inotify init: %w", err)
+	}
+	if _, err := syscall.InotifyAddWatch(fd, dir, syscall.IN_CLOSE_WRITE|syscall.IN_MOVED_TO); err != nil {
+		_ = syscall.Close(fd)
+		return nil, fmt.Errorf("inotify watch: %w", err)
+	}
+	// Non-blocking, so closing it stops the pending read
+	file := os.NewFile(uintptr(fd), "inotify")
+
+	events := make(chan string)
+	go func() {
+		<-ctx.Done()
+		_ = file.Close()
+	}()
+	go func() {
+		defer close(events)
+		defer file.Close()
+		buf := make([]byte, 64*1024)
+		for {
+			n, err := file.Read(buf)
+			if err != nil {
+				return
+			}
+			for offset := 0; offset+syscall.SizeofInotifyEvent <= n; {
+				mask := binary.NativeEndian.Uint32(buf[offset+4:])
+				nameLen := int(binary.NativeEndian.Uint32(buf[offset+12:]))
+				start := offset + syscall.SizeofInotifyEvent
+				offset = start + nameLen
+				if offset > n {
+					break
+				}
+
+				var name string
+				switch {
+				case mask&syscall.IN_IGNORED != 0:
+					// The folder was removed or unmounted
+					return
+				case mask&syscall.IN_Q_OVERFLOW != 0:
+					// Sent as an empty name
+				case mask&syscall.IN_ISDIR != 0:
+					continue
+				default:
+					name = strings.TrimRight(string(buf[start:offset]), "\x00")
+					if name == "" {
+						continue
+					}
+				}
+				select {
+				case events <- name:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+	return events, nil
+}
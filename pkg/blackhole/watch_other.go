@@ -0,0 +1,13 @@
+//go:build !linux
+
+package blackhole
+
+import (
+	"context"
+	"errors"
+)
+
+// watchDir is only implemented with inotify, other platforms poll
+func watchDir(ctx context.Context, dir string) (<-chan string, error) {
+	return nil, errors.New("watching folders is not supported on this platform")
+}
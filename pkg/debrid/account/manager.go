@@ -52,6 +52,7 @@ func NewManager(debridConf config.Debrid, downloadRL ratelimit.Limiter, logger z
 				request.WithMaxRetries(3),
 				request.WithRetryableStatus(429, 447, 502),
 				request.WithProxy(debridConf.Proxy),
+				request.WithMetrics(debridConf.Name),
 			),
 		}
 		m.accounts.Store(token, account)
@@ -0,0 +1,100 @@
+package debrid
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirrobot01/decypharr/pkg/debrid/common"
+)
+
+// How long a cache check is trusted for
+const availabilityTTL = 30 * time.Minute
+
+type availabilityEntry struct {
+	cached  bool
+	checked time.Time
+}
+
+// Availability checks which of the clients have the hashes cached, returning infohash(lowercase) => debrid => cached.
+// Results are kept for availabilityTTL, so indexer pre-checks don't hammer the debrids. Hashes a failed check left out
+// are reported as not cached but aren't kept, they're checked again next time.
+// Each client batches the hashes and goes through its own rate limiter
+func (d *Storage) Availability(infohashes []string, clients map[string]common.Client) map[string]map[string]bool {
+	hashes := make([]string, 0, len(infohashes))
+	result := make(map[string]map[string]bool, len(infohashes))
+	for _, h := range infohashes {
+		h = strings.ToLower(strings.TrimSpace(h))
+		if h == "" {
+			continue
+		}
+		if _, ok := result[h]; !ok {
+			hashes = append(hashes, h)
+			result[h] = make(map[string]bool, len(clients))
+		}
+	}
+
+	var (
+		wg sync.WaitGroup
+		mu sync.Mutex
+	)
+	for name, client := range clients {
+		wg.Add(1)
+		go func(name string, client common.Client) {
+			defer wg.Done()
+			cached := make(map[string]bool, len(hashes))
+			missing := make([]string, 0, len(hashes))
+			for _, h := range hashes {
+				if e, ok := d.availability.Load(name + ":" + h); ok && time.Since(e.checked) < availabilityTTL {
+					cached[h] = e.cached
+				} else {
+					missing = append(missing, h)
+				}
+			}
+			if len(missing) > 0 {
+				// Providers don't agree on the case of the keys
+				available := make(map[string]bool)
+				for h, ok := range client.IsAvailable(missing) {
+					available[strings.ToLower(h)] = ok
+				}
+				now := time.Now()
+				for _, h := range missing {
+					ok, checked := available[h]
+					cached[h] = ok
+					if checked {
+						d.availability.Store(name+":"+h, availabilityEntry{cached: ok, checked: now})
+					}
+				}
+			}
+			mu.Lock()
+			for h, ok := range cached {
+				result[h][name] = ok
+			}
+			mu.Unlock()
+		}(name, client)
+	}
+	wg.Wait()
+	return result
+}
+
+// sweepAvailabilityWorker drops the expired cache checks, most hashes an indexer returns are never asked about again
+func (d *Storage) sweepAvailabilityWorker(ctx context.Context) {
+	ticker := time.NewTicker(availabilityTTL)
+	go func() {
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				d.availability.Range(func(key string, e availabilityEntry) bool {
+					if time.Since(e.checked) >= availabilityTTL {
+						d.availability.Delete(key)
+					}
+					return true
+				})
+			}
+		}
+	}()
+}
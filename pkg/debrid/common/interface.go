@@ -12,6 +12,7 @@ type Client interface {
 	GetFileDownloadLinks(tr *types.Torrent) error
 	GetDownloadLink(tr *types.Torrent, file *types.File) (types.DownloadLink, error)
 	DeleteTorrent(torrentId string) error
+	// IsAvailable returns whether the hashes are cached. Hashes it couldn't check are left out
 	IsAvailable(infohashes []string) map[string]bool
 	GetDownloadUncached() bool
 	UpdateTorrent(torrent *types.Torrent) error
@@ -29,3 +30,16 @@ type Client interface {
 	SyncAccounts() error // Updates each accounts details(like traffic, username, etc.)
 	DeleteDownloadLink(account *account.Account, downloadLink types.DownloadLink) error
 }
+
+// FileSelector is implemented by debrid services that can change which files of a torrent are downloaded
+type FileSelector interface {
+	Client
+	// SelectFiles selects the files with the paths, the other files of the torrent are deselected
+	SelectFiles(torrentId string, paths []string) error
+}
+
+// UsenetClient is implemented by debrid services that can also download NZBs
+type UsenetClient interface {
+	Client
+	SubmitNZB(tr *types.Torrent) (*types.Torrent, error)
+}
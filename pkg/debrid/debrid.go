@@ -5,9 +5,11 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strings"
 	"sync"
 	"time"
 
+	"github.com/puzpuzpuz/xsync/v4"
 	"github.com/sirrobot01/decypharr/internal/config"
 	"github.com/sirrobot01/decypharr/internal/logger"
 	"github.com/sirrobot01/decypharr/internal/request"
@@ -16,23 +18,33 @@ import (
 	"github.com/sirrobot01/decypharr/pkg/debrid/common"
 	"github.com/sirrobot01/decypharr/pkg/debrid/providers/alldebrid"
 	"github.com/sirrobot01/decypharr/pkg/debrid/providers/debridlink"
+	"github.com/sirrobot01/decypharr/pkg/debrid/providers/premiumize"
 	"github.com/sirrobot01/decypharr/pkg/debrid/providers/realdebrid"
 	"github.com/sirrobot01/decypharr/pkg/debrid/providers/torbox"
 	debridStore "github.com/sirrobot01/decypharr/pkg/debrid/store"
 	"github.com/sirrobot01/decypharr/pkg/debrid/types"
+	"github.com/sirrobot01/decypharr/pkg/fuse"
 	"github.com/sirrobot01/decypharr/pkg/rclone"
 	"go.uber.org/ratelimit"
 )
 
 type Debrid struct {
-	cache  *debridStore.Cache // Could be nil if not using WebDAV
-	client common.Client      // HTTP client for making requests to the debrid service
+	cache         *debridStore.Cache // Could be nil if not using WebDAV
+	client        common.Client      // HTTP client for making requests to the debrid service
+	repairLimiter ratelimit.Limiter  // Shared with the client's repair requests, nil means no limit
+	priority      int
+	order         int // position in the config
 }
 
 func (de *Debrid) Client() common.Client {
 	return de.client
 }
 
+// RepairLimiter is the repair_rate_limit of the debrid, anything checking files for repair should take from it
+func (de *Debrid) RepairLimiter() ratelimit.Limiter {
+	return de.repairLimiter
+}
+
 func (de *Debrid) Cache() *debridStore.Cache {
 	return de.cache
 }
@@ -44,9 +56,11 @@ func (de *Debrid) Reset() {
 }
 
 type Storage struct {
-	debrids  map[string]*Debrid
-	mu       sync.RWMutex
-	lastUsed string
+	debrids      map[string]*Debrid
+	mu           sync.RWMutex
+	lastUsed     string
+	policy       SelectionPolicy
+	availability *xsync.Map[string, availabilityEntry] // debrid:infohash => last cache check
 }
 
 func NewStorage(rcManager *rclone.Manager) *Storage {
@@ -62,15 +76,16 @@ func NewStorage(rcManager *rclone.Manager) *Storage {
 	}
 	webdavUrl := fmt.Sprintf("http://%s:%s%s/webdav", bindAddress, cfg.Port, cfg.URLBase)
 
-	for _, dc := range cfg.Debrids {
-		client, err := createDebridClient(dc)
+	for i, dc := range cfg.Debrids {
+		rateLimits := parseRateLimits(dc)
+		client, err := createDebridClient(dc, rateLimits)
 		if err != nil {
 			_logger.Error().Err(err).Str("Debrid", dc.Name).Msg("failed to connect to debrid client")
 			continue
 		}
 		var (
 			cache   *debridStore.Cache
-			mounter *rclone.Mount
+			mounter debridStore.Mounter
 		)
 		_log := client.Logger()
 		if dc.UseWebDav {
@@ -78,19 +93,27 @@ func NewStorage(rcManager *rclone.Manager) *Storage {
 				mounter = rclone.NewMount(dc.Name, dc.RcloneMountPath, webdavUrl, rcManager)
 			}
 			cache = debridStore.NewDebridCache(dc, client, mounter)
+			if cfg.Fuse.Enabled {
+				cache.SetMounter(fuse.NewMount(dc.Name, dc.FuseMountPath, cache))
+			}
 			_log.Info().Msg("Debrid Service started with WebDAV")
 		} else {
 			_log.Info().Msg("Debrid Service started")
 		}
 		debrids[dc.Name] = &Debrid{
-			cache:  cache,
-			client: client,
+			cache:         cache,
+			client:        client,
+			repairLimiter: rateLimits["repair"],
+			priority:      dc.Priority,
+			order:         i,
 		}
 	}
 
 	d := &Storage{
-		debrids:  debrids,
-		lastUsed: "",
+		debrids:      debrids,
+		lastUsed:     "",
+		policy:       parseSelectionPolicy(cfg.DebridSelection),
+		availability: xsync.NewMap[string, availabilityEntry](),
 	}
 	return d
 }
@@ -115,6 +138,9 @@ func (d *Storage) StartWorker(ctx context.Context) error {
 	// Start bandwidth reset worker
 	go d.checkBandwidthWorker(ctx)
 
+	// Start availability sweep worker
+	go d.sweepAvailabilityWorker(ctx)
+
 	return nil
 }
 
@@ -223,6 +249,7 @@ func (d *Storage) Reset() {
 	// Reinitialize the debrids map
 	d.debrids = make(map[string]*Debrid)
 	d.lastUsed = ""
+	d.availability.Clear()
 }
 
 func (d *Storage) Clients() map[string]common.Client {
@@ -261,7 +288,7 @@ func (d *Storage) FilterClients(filter func(common.Client) bool) map[string]comm
 	return filteredClients
 }
 
-func createDebridClient(dc config.Debrid) (common.Client, error) {
+func parseRateLimits(dc config.Debrid) map[string]ratelimit.Limiter {
 	rateLimits := map[string]ratelimit.Limiter{}
 
 	mainRL := request.ParseRateLimit(dc.RateLimit)
@@ -271,7 +298,10 @@ func createDebridClient(dc config.Debrid) (common.Client, error) {
 	rateLimits["main"] = mainRL
 	rateLimits["repair"] = repairRL
 	rateLimits["download"] = downloadRL
+	return rateLimits
+}
 
+func createDebridClient(dc config.Debrid, rateLimits map[string]ratelimit.Limiter) (common.Client, error) {
 	switch dc.Name {
 	case "realdebrid":
 		return realdebrid.New(dc, rateLimits)
@@ -281,6 +311,8 @@ func createDebridClient(dc config.Debrid) (common.Client, error) {
 		return debridlink.New(dc, rateLimits)
 	case "alldebrid":
 		return alldebrid.New(dc, rateLimits)
+	case "premiumize":
+		return premiumize.New(dc, rateLimits)
 	default:
 		return realdebrid.New(dc, rateLimits)
 	}
@@ -301,6 +333,12 @@ func Process(ctx context.Context, store *Storage, selectedDebrid string, magnet
 		if selectedDebrid != "" && c.Name() != selectedDebrid {
 			return false
 		}
+		if magnet.IsNZB() {
+			// Only usenet capable debrids can take NZBs
+			if _, ok := c.(common.UsenetClient); !ok {
+				return false
+			}
+		}
 		return true
 	})
 
@@ -308,7 +346,26 @@ func Process(ctx context.Context, store *Storage, selectedDebrid string, magnet
 		return nil, fmt.Errorf("no debrid clients available")
 	}
 
+	policy := store.policy
+	if selectedDebrid != "" {
+		policy = SelectionSelected
+	}
+	var candidates []candidate
+	if policy == SelectionSelected {
+		candidates = store.byPriority(clients)
+		for i := range candidates {
+			candidates[i].reason = "selected by request"
+		}
+	} else {
+		infohash := ""
+		if !magnet.IsNZB() {
+			infohash = magnet.InfoHash
+		}
+		candidates = store.orderClients(policy, clients, infohash)
+	}
+
 	errs := make([]error, 0, len(clients))
+	failed := make([]string, 0, len(clients))
 
 	// Override first, arr second, debrid third
 
@@ -317,7 +374,13 @@ func Process(ctx context.Context, store *Storage, selectedDebrid string, magnet
 		overrideDownloadUncached = *a.DownloadUncached
 	}
 
-	for _, db := range clients {
+	if magnet.IsNZB() {
+		// There's no cache for usenet, NZBs are always downloaded by the debrid
+		overrideDownloadUncached = true
+	}
+
+	for _, c := range candidates {
+		db := c.client
 		_logger := db.Logger()
 		_logger.Info().
 			Str("Debrid", db.Name()).
@@ -332,14 +395,23 @@ func Process(ctx context.Context, store *Storage, selectedDebrid string, magnet
 			debridTorrent.DownloadUncached = true
 		}
 
-		dbt, err := db.SubmitMagnet(debridTorrent)
+		var (
+			dbt *types.Torrent
+			err error
+		)
+		if uc, ok := db.(common.UsenetClient); ok && magnet.IsNZB() {
+			dbt, err = uc.SubmitNZB(debridTorrent)
+		} else {
+			dbt, err = db.SubmitMagnet(debridTorrent)
+		}
 		if err != nil || dbt == nil || dbt.Id == "" {
 			errs = append(errs, err)
+			failed = append(failed, db.Name())
 			continue
 		}
 		dbt.Arr = a
-		_logger.Info().Str("id", dbt.Id).Msgf("Torrent: %s submitted to %s", dbt.Name, db.Name())
-		store.lastUsed = db.Name()
+		_logger.Info().Str("id", dbt.Id).Str("policy", string(policy)).Str("reason", c.reason).Msgf("Torrent: %s submitted to %s", dbt.Name, db.Name())
+		store.setLastUsed(db.Name())
 
 		torrent, err := db.CheckStatus(dbt)
 		if err != nil && torrent != nil && torrent.Id != "" {
@@ -350,12 +422,19 @@ func Process(ctx context.Context, store *Storage, selectedDebrid string, magnet
 		}
 		if err != nil {
 			errs = append(errs, err)
+			failed = append(failed, db.Name())
 			continue
 		}
 		if torrent == nil {
 			errs = append(errs, fmt.Errorf("torrent %s returned nil after checking status", dbt.Name))
+			failed = append(failed, db.Name())
 			continue
 		}
+		torrent.SelectionPolicy = string(policy)
+		torrent.SelectionReason = c.reason
+		if len(failed) > 0 {
+			torrent.SelectionReason += fmt.Sprintf(" (failed over from %s)", strings.Join(failed, ", "))
+		}
 		return torrent, nil
 	}
 	if len(errs) == 0 {
@@ -48,6 +48,7 @@ func New(dc config.Debrid, ratelimits map[string]ratelimit.Limiter) (*AllDebrid,
 		request.WithLogger(_log),
 		request.WithRateLimiter(ratelimits["main"]),
 		request.WithProxy(dc.Proxy),
+		request.WithMetrics(dc.Name),
 	)
 
 	autoExpiresLinksAfter, err := time.ParseDuration(dc.AutoExpireLinksAfter)
@@ -48,6 +48,7 @@ func New(dc config.Debrid, ratelimits map[string]ratelimit.Limiter) (*DebridLink
 		request.WithLogger(_log),
 		request.WithRateLimiter(ratelimits["main"]),
 		request.WithProxy(dc.Proxy),
+		request.WithMetrics(dc.Name),
 	)
 
 	autoExpiresLinksAfter, err := time.ParseDuration(dc.AutoExpireLinksAfter)
@@ -119,11 +120,9 @@ func (dl *DebridLink) IsAvailable(hashes []string) map[string]bool {
 			return result
 		}
 		value := *data.Value
-		for _, h := range hashes[i:end] {
+		for _, h := range validHashes {
 			_, exists := value[h]
-			if exists {
-				result[h] = true
-			}
+			result[h] = exists
 		}
 	}
 	return result
@@ -0,0 +1,256 @@
+package premiumize
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/sirrobot01/decypharr/internal/config"
+	"github.com/sirrobot01/decypharr/internal/utils"
+)
+
+const fakeAPIKey = "fake-api-key"
+
+// fakeAPI is an in-memory Premiumize API. Cached hashes become finished transfers as soon as they're created,
+// the others stay queued
+type fakeAPI struct {
+	t      *testing.T
+	server *httptest.Server
+
+	mu         sync.Mutex
+	cached     map[string][]folderItem // Files of the cached hashes, by upper case hash
+	transfers  []transfer
+	folders    map[string][]folderItem
+	items      map[string]folderItem
+	deleted    []string // Ids of the deleted transfers, folders and items
+	limitUsed  float64
+	nextId     int
+	linkSerial int
+}
+
+func newFakeAPI(t *testing.T) *fakeAPI {
+	f := &fakeAPI{
+		t:       t,
+		cached:  make(map[string][]folderItem),
+		folders: make(map[string][]folderItem),
+		items:   make(map[string]folderItem),
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/cache/check", f.cacheCheck)
+	mux.HandleFunc("/transfer/create", f.transferCreate)
+	mux.HandleFunc("/transfer/list", f.transferList)
+	mux.HandleFunc("/transfer/delete", f.transferDelete)
+	mux.HandleFunc("/folder/list", f.folderList)
+	mux.HandleFunc("/folder/delete", f.deleteById)
+	mux.HandleFunc("/item/details", f.itemDetails)
+	mux.HandleFunc("/item/delete", f.deleteById)
+	mux.HandleFunc("/account/info", f.accountInfo)
+	f.server = httptest.NewServer(f.authenticated(mux))
+	t.Cleanup(f.server.Close)
+	return f
+}
+
+// newClient returns a Premiumize client talking to the fake
+func (f *fakeAPI) newClient() *Premiumize {
+	f.t.Helper()
+	config.SetConfigPath(f.t.TempDir())
+	pm, err := New(config.Debrid{
+		Name:            "premiumize",
+		APIKey:          fakeAPIKey,
+		DownloadAPIKeys: []string{fakeAPIKey},
+		Folder:          "/mnt/premiumize",
+	}, nil)
+	if err != nil {
+		f.t.Fatal(err)
+	}
+	pm.Host = f.server.URL
+	return pm
+}
+
+// addCached makes the hash cached, with the files given by their path in the transfer
+func (f *fakeAPI) addCached(hash string, files map[string]int64) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	items := make([]folderItem, 0, len(files))
+	for path, size := range files {
+		items = append(items, folderItem{Name: path, Type: "file", Size: size})
+	}
+	f.cached[strings.ToUpper(hash)] = items
+}
+
+func (f *fakeAPI) authenticated(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("apikey") != fakeAPIKey {
+			f.writeJSON(w, APIResponse{Status: "error", Message: "Not logged in."})
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (f *fakeAPI) writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		f.t.Errorf("failed to encode response: %v", err)
+	}
+}
+
+func (f *fakeAPI) id(prefix string) string {
+	f.nextId++
+	return fmt.Sprintf("%s%d", prefix, f.nextId)
+}
+
+func (f *fakeAPI) cacheCheck(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	res := CacheCheckResponse{APIResponse: APIResponse{Status: "success"}}
+	for _, hash := range r.URL.Query()["items[]"] {
+		_, ok := f.cached[strings.ToUpper(hash)]
+		res.Response = append(res.Response, ok)
+		res.Filename = append(res.Filename, "")
+		res.Filesize = append(res.Filesize, "")
+	}
+	f.writeJSON(w, res)
+}
+
+func (f *fakeAPI) transferCreate(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	src := r.FormValue("src")
+	magnet, err := utils.GetMagnetInfo(src)
+	if err != nil {
+		f.writeJSON(w, APIResponse{Status: "error", Message: "Invalid magnet"})
+		return
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	tr := transfer{Id: f.id("transfer"), Name: magnet.Name, Src: src, Status: "queued"}
+	if files, ok := f.cached[strings.ToUpper(magnet.InfoHash)]; ok {
+		tr.Status = "finished"
+		tr.Progress = 1
+		tr.FolderId = f.addFolder(files)
+	}
+	f.transfers = append(f.transfers, tr)
+	f.writeJSON(w, CreateTransferResponse{APIResponse: APIResponse{Status: "success"}, Id: tr.Id, Name: tr.Name, Type: "torrent"})
+}
+
+// addFolder stores the files in a folder tree, one sub folder per path segment, and returns the root folder id.
+// Must be called with mu held
+func (f *fakeAPI) addFolder(files []folderItem) string {
+	root := f.id("folder")
+	folderIds := map[string]string{"": root}
+	var folderFor func(dir string) string
+	folderFor = func(dir string) string {
+		if id, ok := folderIds[dir]; ok {
+			return id
+		}
+		parent, name := "", dir
+		if i := strings.LastIndex(dir, "/"); i >= 0 {
+			parent, name = dir[:i], dir[i+1:]
+		}
+		id := f.id("folder")
+		folderIds[dir] = id
+		parentId := folderFor(parent)
+		f.folders[parentId] = append(f.folders[parentId], folderItem{Id: id, Name: name, Type: "folder"})
+		return id
+	}
+	for _, file := range files {
+		dir, name := "", file.Name
+		if i := strings.LastIndex(file.Name, "/"); i >= 0 {
+			dir, name = file.Name[:i], file.Name[i+1:]
+		}
+		item := file
+		item.Id = f.id("item")
+		item.Name = name
+		item.CreatedAt = 1700000000
+		item.Link = f.link(item.Id)
+		f.items[item.Id] = item
+		folderId := folderFor(dir)
+		f.folders[folderId] = append(f.folders[folderId], item)
+	}
+	return root
+}
+
+// link returns a new download link, links change every time they're asked for. Must be called with mu held
+func (f *fakeAPI) link(itemId string) string {
+	f.linkSerial++
+	return fmt.Sprintf("%s/dl/%s/%d", f.server.URL, itemId, f.linkSerial)
+}
+
+func (f *fakeAPI) transferList(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.writeJSON(w, TransferListResponse{APIResponse: APIResponse{Status: "success"}, Transfers: f.transfers})
+}
+
+func (f *fakeAPI) transferDelete(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	id := r.FormValue("id")
+	for i, tr := range f.transfers {
+		if tr.Id == id {
+			f.transfers = append(f.transfers[:i], f.transfers[i+1:]...)
+			f.deleted = append(f.deleted, id)
+			f.writeJSON(w, APIResponse{Status: "success"})
+			return
+		}
+	}
+	f.writeJSON(w, APIResponse{Status: "error", Message: "Transfer not found"})
+}
+
+func (f *fakeAPI) folderList(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	content, ok := f.folders[r.URL.Query().Get("id")]
+	if !ok {
+		f.writeJSON(w, APIResponse{Status: "error", Message: "Folder not found"})
+		return
+	}
+	f.writeJSON(w, FolderListResponse{APIResponse: APIResponse{Status: "success"}, Content: content})
+}
+
+func (f *fakeAPI) itemDetails(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	item, ok := f.items[r.URL.Query().Get("id")]
+	if !ok {
+		http.Error(w, `{"status":"error","message":"Item not found"}`, http.StatusNotFound)
+		return
+	}
+	f.writeJSON(w, ItemDetailsResponse{
+		Id:        item.Id,
+		Name:      item.Name,
+		Type:      item.Type,
+		Size:      item.Size,
+		CreatedAt: item.CreatedAt,
+		Link:      f.link(item.Id),
+	})
+}
+
+func (f *fakeAPI) deleteById(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	id := r.FormValue("id")
+	delete(f.folders, id)
+	delete(f.items, id)
+	f.deleted = append(f.deleted, id)
+	f.writeJSON(w, APIResponse{Status: "success"})
+}
+
+func (f *fakeAPI) accountInfo(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.writeJSON(w, AccountInfoResponse{
+		APIResponse:  APIResponse{Status: "success"},
+		CustomerId:   "1234",
+		PremiumUntil: 4102444800,
+		LimitUsed:    f.limitUsed,
+	})
+}
@@ -0,0 +1,690 @@
+package premiumize
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	gourl "net/url"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/sirrobot01/decypharr/internal/config"
+	"github.com/sirrobot01/decypharr/internal/logger"
+	"github.com/sirrobot01/decypharr/internal/request"
+	"github.com/sirrobot01/decypharr/internal/utils"
+	"github.com/sirrobot01/decypharr/pkg/debrid/account"
+	"github.com/sirrobot01/decypharr/pkg/debrid/types"
+	"go.uber.org/ratelimit"
+)
+
+const (
+	// transferListTTL is how long a /transfer/list response is reused.
+	// Premiumize has no endpoint for a single transfer, so this keeps UpdateTorrent from listing everything per torrent
+	transferListTTL = 2 * time.Second
+
+	// cachedTransferTimeout is how long we wait for a cached transfer to move to finished
+	cachedTransferTimeout = 30 * time.Second
+
+	// transferSlots is what GetAvailableSlots reports. Premiumize has no transfer slot limit,
+	// this is only large enough that slot-aware callers never hold torrents back
+	transferSlots = 100
+
+	// fairUsePointBytes is the traffic a fair use point stands for, one point per GB downloaded
+	fairUsePointBytes = 1 << 30
+)
+
+type Premiumize struct {
+	name                  string
+	Host                  string `json:"host"`
+	APIKey                string
+	accountsManager       *account.Manager
+	autoExpiresLinksAfter time.Duration
+	DownloadUncached      bool
+	client                *request.Client
+	Profile               *types.Profile `json:"profile"`
+
+	MountPath       string
+	logger          zerolog.Logger
+	checkCached     bool
+	addSamples      bool
+	minimumFreeSlot int
+
+	transfersMu        sync.Mutex
+	transfers          []transfer
+	transfersFetchedAt time.Time
+}
+
+func New(dc config.Debrid, ratelimits map[string]ratelimit.Limiter) (*Premiumize, error) {
+	headers := map[string]string{
+		"Accept": "application/json",
+	}
+	_log := logger.New(dc.Name)
+	client := request.New(
+		request.WithHeaders(headers),
+		request.WithLogger(_log),
+		request.WithRateLimiter(ratelimits["main"]),
+		request.WithProxy(dc.Proxy),
+		request.WithMetrics(dc.Name),
+	)
+
+	autoExpiresLinksAfter, err := time.ParseDuration(dc.AutoExpireLinksAfter)
+	if autoExpiresLinksAfter == 0 || err != nil {
+		autoExpiresLinksAfter = 48 * time.Hour
+	}
+	return &Premiumize{
+		name:                  "premiumize",
+		Host:                  "https://www.premiumize.me/api",
+		APIKey:                dc.APIKey,
+		accountsManager:       account.NewManager(dc, ratelimits["download"], _log),
+		DownloadUncached:      dc.DownloadUncached,
+		autoExpiresLinksAfter: autoExpiresLinksAfter,
+		client:                client,
+		MountPath:             dc.Folder,
+		logger:                _log,
+		checkCached:           dc.CheckCached,
+		addSamples:            dc.AddSamples,
+		minimumFreeSlot:       dc.MinimumFreeSlot,
+	}, nil
+}
+
+func (pm *Premiumize) Name() string {
+	return pm.name
+}
+
+func (pm *Premiumize) Logger() zerolog.Logger {
+	return pm.logger
+}
+
+// endpoint builds an API url. Premiumize authenticates API keys through the apikey query parameter
+func (pm *Premiumize) endpoint(path string, query gourl.Values, apiKey string) string {
+	if query == nil {
+		query = gourl.Values{}
+	}
+	query.Set("apikey", apiKey)
+	return fmt.Sprintf("%s%s?%s", pm.Host, path, query.Encode())
+}
+
+func (pm *Premiumize) get(path string, query gourl.Values, v interface{}) error {
+	req, err := http.NewRequest(http.MethodGet, pm.endpoint(path, query, pm.APIKey), nil)
+	if err != nil {
+		return err
+	}
+	resp, err := pm.client.MakeRequest(req)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(resp, v)
+}
+
+func (pm *Premiumize) post(path string, form gourl.Values, v interface{}) error {
+	req, err := http.NewRequest(http.MethodPost, pm.endpoint(path, nil, pm.APIKey), strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp, err := pm.client.MakeRequest(req)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(resp, v)
+}
+
+func (pm *Premiumize) IsAvailable(hashes []string) map[string]bool {
+	// Check if the infohashes are available in the local cache
+	result := make(map[string]bool)
+
+	// Divide hashes into groups of 100
+	for i := 0; i < len(hashes); i += 100 {
+		end := i + 100
+		if end > len(hashes) {
+			end = len(hashes)
+		}
+
+		// Filter out empty strings
+		validHashes := make([]string, 0, end-i)
+		for _, hash := range hashes[i:end] {
+			if hash != "" {
+				validHashes = append(validHashes, hash)
+			}
+		}
+
+		// If no valid hashes in this batch, continue to the next batch
+		if len(validHashes) == 0 {
+			continue
+		}
+
+		query := gourl.Values{}
+		for _, hash := range validHashes {
+			query.Add("items[]", hash)
+		}
+		var res CacheCheckResponse
+		if err := pm.get("/cache/check", query, &res); err != nil {
+			pm.logger.Error().Err(err).Msgf("Error checking availability")
+			return result
+		}
+		if err := res.Err(); err != nil {
+			pm.logger.Error().Err(err).Msgf("Error checking availability")
+			return result
+		}
+		// The response is positional, one entry per item sent
+		for idx, cached := range res.Response {
+			if idx >= len(validHashes) {
+				break
+			}
+			result[strings.ToUpper(validHashes[idx])] = cached
+		}
+	}
+	return result
+}
+
+func (pm *Premiumize) SubmitMagnet(torrent *types.Torrent) (*types.Torrent, error) {
+	if !torrent.DownloadUncached {
+		// Premiumize happily queues uncached magnets, so refuse them before they are created
+		available := pm.IsAvailable([]string{torrent.InfoHash})
+		if !available[strings.ToUpper(torrent.InfoHash)] {
+			return nil, fmt.Errorf("torrent: %s not cached", torrent.Name)
+		}
+	}
+	form := gourl.Values{}
+	form.Set("src", torrent.Magnet.Link)
+	var data CreateTransferResponse
+	if err := pm.post("/transfer/create", form, &data); err != nil {
+		return nil, err
+	}
+	if err := data.Err(); err != nil {
+		return nil, err
+	}
+	if data.Id == "" {
+		return nil, fmt.Errorf("error adding torrent. No transfer id returned")
+	}
+	torrent.Id = data.Id
+	torrent.Debrid = pm.name
+	torrent.MountPath = pm.MountPath
+	torrent.Added = time.Now().Format(time.RFC3339)
+	pm.invalidateTransfers()
+	return torrent, nil
+}
+
+func getPremiumizeStatus(status string) string {
+	switch status {
+	case "finished", "seeding":
+		return "downloaded"
+	case "waiting", "queued", "running":
+		return "downloading"
+	default:
+		return "error"
+	}
+}
+
+func (pm *Premiumize) invalidateTransfers() {
+	pm.transfersMu.Lock()
+	pm.transfersFetchedAt = time.Time{}
+	pm.transfersMu.Unlock()
+}
+
+func (pm *Premiumize) listTransfers() ([]transfer, error) {
+	pm.transfersMu.Lock()
+	defer pm.transfersMu.Unlock()
+	if pm.transfers != nil && time.Since(pm.transfersFetchedAt) < transferListTTL {
+		return pm.transfers, nil
+	}
+	var res TransferListResponse
+	if err := pm.get("/transfer/list", nil, &res); err != nil {
+		return nil, err
+	}
+	if err := res.Err(); err != nil {
+		return nil, err
+	}
+	pm.transfers = res.Transfers
+	pm.transfersFetchedAt = time.Now()
+	return pm.transfers, nil
+}
+
+func (pm *Premiumize) getTransfer(torrentId string) (*transfer, error) {
+	transfers, err := pm.listTransfers()
+	if err != nil {
+		return nil, err
+	}
+	for _, tr := range transfers {
+		if tr.Id == torrentId {
+			return &tr, nil
+		}
+	}
+	return nil, utils.TorrentNotFoundError
+}
+
+// listFolder recursively collects the files in a cloud folder.
+// parentPath is the path of the folder relative to the transfer root
+func (pm *Premiumize) listFolder(folderId, parentPath string) ([]folderItem, []string, error) {
+	query := gourl.Values{}
+	query.Set("id", folderId)
+	var res FolderListResponse
+	if err := pm.get("/folder/list", query, &res); err != nil {
+		return nil, nil, err
+	}
+	if err := res.Err(); err != nil {
+		return nil, nil, err
+	}
+	files := make([]folderItem, 0, len(res.Content))
+	paths := make([]string, 0, len(res.Content))
+	for _, item := range res.Content {
+		currentPath := item.Name
+		if parentPath != "" {
+			currentPath = filepath.Join(parentPath, item.Name)
+		}
+		if item.Type == "folder" {
+			subFiles, subPaths, err := pm.listFolder(item.Id, currentPath)
+			if err != nil {
+				return nil, nil, err
+			}
+			files = append(files, subFiles...)
+			paths = append(paths, subPaths...)
+			continue
+		}
+		files = append(files, item)
+		paths = append(paths, currentPath)
+	}
+	return files, paths, nil
+}
+
+func (pm *Premiumize) getTransferFiles(t *types.Torrent, tr *transfer) (map[string]types.File, int64, time.Time, error) {
+	var (
+		items []folderItem
+		paths []string
+	)
+	switch {
+	case tr.FolderId != "":
+		var err error
+		items, paths, err = pm.listFolder(tr.FolderId, "")
+		if err != nil {
+			return nil, 0, time.Time{}, err
+		}
+	case tr.FileId != "":
+		query := gourl.Values{}
+		query.Set("id", tr.FileId)
+		var item ItemDetailsResponse
+		if err := pm.get("/item/details", query, &item); err != nil {
+			return nil, 0, time.Time{}, err
+		}
+		items = append(items, folderItem{
+			Id:         item.Id,
+			Name:       item.Name,
+			Type:       item.Type,
+			Size:       item.Size,
+			CreatedAt:  item.CreatedAt,
+			Link:       item.Link,
+			StreamLink: item.StreamLink,
+		})
+		paths = append(paths, item.Name)
+	default:
+		return nil, 0, time.Time{}, fmt.Errorf("transfer %s has no files", tr.Id)
+	}
+
+	cfg := config.Get()
+	files := make(map[string]types.File)
+	var (
+		totalSize int64
+		addedOn   time.Time
+	)
+	for idx, item := range items {
+		totalSize += item.Size
+		if created := time.Unix(item.CreatedAt, 0); item.CreatedAt > 0 && (addedOn.IsZero() || created.Before(addedOn)) {
+			addedOn = created
+		}
+		fileName := filepath.Base(item.Name)
+
+		// Skip sample files
+		if !pm.addSamples && utils.IsSampleFile(item.Name) {
+			continue
+		}
+		if !cfg.IsAllowedFile(fileName) {
+			continue
+		}
+		if !cfg.IsSizeAllowed(item.Size) {
+			continue
+		}
+		file := types.File{
+			TorrentId: t.Id,
+			Id:        item.Id,
+			Name:      fileName,
+			Size:      item.Size,
+			Path:      paths[idx],
+			Link:      item.Link,
+		}
+		if _, ok := files[file.Name]; ok {
+			// File already exists, use path as key
+			files[file.Path] = file
+		} else {
+			files[file.Name] = file
+		}
+	}
+	return files, totalSize, addedOn, nil
+}
+
+func (pm *Premiumize) GetTorrent(torrentId string) (*types.Torrent, error) {
+	t := &types.Torrent{
+		Id:    torrentId,
+		Files: make(map[string]types.File),
+	}
+	if err := pm.UpdateTorrent(t); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+func (pm *Premiumize) UpdateTorrent(t *types.Torrent) error {
+	tr, err := pm.getTransfer(t.Id)
+	if err != nil {
+		return err
+	}
+	status := getPremiumizeStatus(tr.Status)
+	name := tr.Name
+	t.Name = name
+	t.Status = status
+	t.Filename = name
+	t.OriginalFilename = name
+	t.Folder = name
+	t.MountPath = pm.MountPath
+	t.Debrid = pm.name
+	if t.InfoHash == "" {
+		t.InfoHash = utils.ExtractInfoHash(tr.Src)
+	}
+	if status == "downloaded" {
+		t.Progress = 100
+		files, size, addedOn, err := pm.getTransferFiles(t, tr)
+		if err != nil {
+			return err
+		}
+		t.Files = files
+		t.Bytes = size
+		if !addedOn.IsZero() {
+			t.Added = addedOn.Format(time.RFC3339)
+		}
+	} else {
+		// Premiumize reports progress as a fraction
+		t.Progress = tr.Progress * 100
+	}
+	return nil
+}
+
+func (pm *Premiumize) CheckStatus(torrent *types.Torrent) (*types.Torrent, error) {
+	deadline := time.Now().Add(cachedTransferTimeout)
+	for {
+		err := pm.UpdateTorrent(torrent)
+
+		if err != nil || torrent == nil {
+			return torrent, err
+		}
+		status := torrent.Status
+		if status == "downloaded" {
+			pm.logger.Info().Msgf("Torrent: %s downloaded", torrent.Name)
+			return torrent, nil
+		} else if utils.Contains(pm.GetDownloadingStatus(), status) {
+			if torrent.DownloadUncached {
+				// Break out of the loop if the torrent is downloading.
+				// This is necessary to prevent infinite loop since we moved to sync downloading and async processing
+				return torrent, nil
+			}
+			// SubmitMagnet only accepts cached magnets here, but Premiumize still takes a few seconds to move them to finished
+			if time.Now().After(deadline) {
+				return torrent, fmt.Errorf("torrent: %s not cached", torrent.Name)
+			}
+			time.Sleep(transferListTTL)
+		} else {
+			return torrent, fmt.Errorf("torrent: %s has error", torrent.Name)
+		}
+	}
+}
+
+func (pm *Premiumize) DeleteTorrent(torrentId string) error {
+	tr, err := pm.getTransfer(torrentId)
+	if err != nil {
+		return err
+	}
+	form := gourl.Values{}
+	form.Set("id", torrentId)
+	var res APIResponse
+	if err := pm.post("/transfer/delete", form, &res); err != nil {
+		return err
+	}
+	if err := res.Err(); err != nil {
+		return err
+	}
+	// Deleting a transfer leaves its files in the cloud, remove them too
+	switch {
+	case tr.FolderId != "":
+		form = gourl.Values{}
+		form.Set("id", tr.FolderId)
+		if err := pm.post("/folder/delete", form, &res); err != nil {
+			return err
+		}
+	case tr.FileId != "":
+		form = gourl.Values{}
+		form.Set("id", tr.FileId)
+		if err := pm.post("/item/delete", form, &res); err != nil {
+			return err
+		}
+	}
+	pm.invalidateTransfers()
+	pm.logger.Info().Msgf("Torrent %s deleted from Premiumize", torrentId)
+	return res.Err()
+}
+
+func (pm *Premiumize) GetFileDownloadLinks(t *types.Torrent) error {
+	// Files keep their key, files with the same name in different folders are keyed by path
+	type keyedFile struct {
+		key  string
+		file types.File
+	}
+	filesCh := make(chan keyedFile, len(t.Files))
+	errCh := make(chan error, len(t.Files))
+
+	var wg sync.WaitGroup
+	wg.Add(len(t.Files))
+	for key, file := range t.Files {
+		go func(key string, file types.File) {
+			defer wg.Done()
+			link, err := pm.GetDownloadLink(t, &file)
+			if err != nil {
+				errCh <- err
+				return
+			}
+			file.DownloadLink = link
+			filesCh <- keyedFile{key: key, file: file}
+		}(key, file)
+	}
+	go func() {
+		wg.Wait()
+		close(filesCh)
+		close(errCh)
+	}()
+	files := make(map[string]types.File, len(t.Files))
+	for f := range filesCh {
+		files[f.key] = f.file
+	}
+
+	// Check for errors
+	for err := range errCh {
+		if err != nil {
+			return err
+		}
+	}
+
+	t.Files = files
+	return nil
+}
+
+func (pm *Premiumize) GetDownloadLink(t *types.Torrent, file *types.File) (types.DownloadLink, error) {
+	// Cloud item links are direct links already, ask for the item again so we never hand out a stale one
+	link := file.Link
+	if file.Id != "" {
+		query := gourl.Values{}
+		query.Set("id", file.Id)
+		var item ItemDetailsResponse
+		if err := pm.get("/item/details", query, &item); err != nil {
+			return types.DownloadLink{}, err
+		}
+		if item.Link != "" {
+			link = item.Link
+		}
+	}
+	if link == "" {
+		return types.DownloadLink{}, fmt.Errorf("download link is empty")
+	}
+	now := time.Now()
+	dl := types.DownloadLink{
+		Debrid:       pm.name,
+		Token:        pm.APIKey,
+		Link:         file.Link,
+		DownloadLink: link,
+		Id:           file.Id,
+		Size:         file.Size,
+		Filename:     file.Name,
+		Generated:    now,
+		ExpiresAt:    now.Add(pm.autoExpiresLinksAfter),
+	}
+	// Set the download link in the account
+	pm.accountsManager.StoreDownloadLink(dl)
+	return dl, nil
+}
+
+func (pm *Premiumize) GetTorrents() ([]*types.Torrent, error) {
+	torrents := make([]*types.Torrent, 0)
+	pm.invalidateTransfers()
+	transfers, err := pm.listTransfers()
+	if err != nil {
+		return torrents, err
+	}
+	for _, tr := range transfers {
+		status := getPremiumizeStatus(tr.Status)
+		if status != "downloaded" {
+			continue
+		}
+		torrents = append(torrents, &types.Torrent{
+			Id:               tr.Id,
+			Name:             tr.Name,
+			Status:           status,
+			Filename:         tr.Name,
+			OriginalFilename: tr.Name,
+			Files:            make(map[string]types.File),
+			InfoHash:         utils.ExtractInfoHash(tr.Src),
+			Debrid:           pm.name,
+			MountPath:        pm.MountPath,
+		})
+	}
+	return torrents, nil
+}
+
+func (pm *Premiumize) RefreshDownloadLinks() error {
+	return nil
+}
+
+func (pm *Premiumize) GetDownloadingStatus() []string {
+	return []string{"downloading"}
+}
+
+func (pm *Premiumize) GetDownloadUncached() bool {
+	return pm.DownloadUncached
+}
+
+func (pm *Premiumize) CheckLink(link string) error {
+	return nil
+}
+
+func (pm *Premiumize) GetMountPath() string {
+	return pm.MountPath
+}
+
+func (pm *Premiumize) GetAvailableSlots() (int, error) {
+	// Premiumize does not expose a transfer slot limit
+	return max(transferSlots-pm.minimumFreeSlot, 0), nil
+}
+
+func (pm *Premiumize) getAccountInfo(apiKey string, client *request.Client) (*AccountInfoResponse, error) {
+	req, err := http.NewRequest(http.MethodGet, pm.endpoint("/account/info", nil, apiKey), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.MakeRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	var res AccountInfoResponse
+	if err := json.Unmarshal(resp, &res); err != nil {
+		return nil, err
+	}
+	if err := res.Err(); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+func (pm *Premiumize) GetProfile() (*types.Profile, error) {
+	if pm.Profile != nil {
+		return pm.Profile, nil
+	}
+	res, err := pm.getAccountInfo(pm.APIKey, pm.client)
+	if err != nil {
+		pm.logger.Error().Err(err).Msgf("Error getting user profile")
+		return nil, err
+	}
+	customerId, _ := strconv.ParseInt(string(res.CustomerId), 10, 64)
+	expiration := time.Unix(res.PremiumUntil, 0)
+	profile := &types.Profile{
+		Id:         customerId,
+		Name:       pm.name,
+		Username:   string(res.CustomerId),
+		Premium:    res.PremiumUntil,
+		Expiration: expiration,
+		// Premiumize counts usage in fair use points, limit_used is the fraction of those spent
+		Points: int(res.LimitUsed * 1000),
+	}
+	if res.PremiumUntil > time.Now().Unix() {
+		profile.Type = "premium"
+	} else {
+		profile.Type = "free"
+	}
+	pm.Profile = profile
+	return profile, nil
+}
+
+func (pm *Premiumize) AccountManager() *account.Manager {
+	return pm.accountsManager
+}
+
+func (pm *Premiumize) SyncAccounts() error {
+	// Sync accounts with the current configuration
+	if len(pm.accountsManager.Active()) == 0 {
+		return nil
+	}
+	for _, _account := range pm.accountsManager.All() {
+		if err := pm.syncAccount(_account); err != nil {
+			pm.logger.Error().Err(err).Msgf("Error syncing account %s", _account.Username)
+			continue // Skip this account and continue with the next
+		}
+	}
+	return nil
+}
+
+func (pm *Premiumize) syncAccount(account *account.Account) error {
+	if account.Token == "" {
+		return fmt.Errorf("account %s has no token", account.Username)
+	}
+	res, err := pm.getAccountInfo(account.Token, account.Client())
+	if err != nil {
+		return fmt.Errorf("error checking account %s: %w", account.Username, err)
+	}
+	account.Username = string(res.CustomerId)
+	// Premiumize does not report traffic in bytes, only the fraction of the 1000 fair use points spent
+	account.TrafficUsed.Store(int64(res.LimitUsed * 1000 * fairUsePointBytes))
+	return nil
+}
+
+func (pm *Premiumize) DeleteDownloadLink(account *account.Account, downloadLink types.DownloadLink) error {
+	account.DeleteDownloadLink(downloadLink.Link)
+	return nil
+}
@@ -0,0 +1,263 @@
+package premiumize
+
+import (
+	"maps"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/sirrobot01/decypharr/internal/utils"
+	"github.com/sirrobot01/decypharr/pkg/debrid/types"
+)
+
+const (
+	cachedHash   = "0123456789abcdef0123456789abcdef01234567"
+	uncachedHash = "89abcdef0123456789abcdef0123456789abcdef"
+)
+
+func newTorrent(hash, name string, downloadUncached bool) *types.Torrent {
+	magnet := utils.ConstructMagnet(hash, name)
+	return &types.Torrent{
+		InfoHash:         hash,
+		Name:             name,
+		Magnet:           magnet,
+		DownloadUncached: downloadUncached,
+		Files:            make(map[string]types.File),
+	}
+}
+
+func TestIsAvailable(t *testing.T) {
+	api := newFakeAPI(t)
+	api.addCached(cachedHash, map[string]int64{"Show.S01E01.mkv": 100})
+	pm := api.newClient()
+
+	available := pm.IsAvailable([]string{cachedHash, "", uncachedHash})
+	if !available[strings.ToUpper(cachedHash)] {
+		t.Errorf("expected %s to be cached", cachedHash)
+	}
+	if available[strings.ToUpper(uncachedHash)] {
+		t.Errorf("expected %s not to be cached", uncachedHash)
+	}
+}
+
+func TestSubmitMagnetRefusesUncached(t *testing.T) {
+	api := newFakeAPI(t)
+	pm := api.newClient()
+
+	if _, err := pm.SubmitMagnet(newTorrent(uncachedHash, "Uncached", false)); err == nil {
+		t.Fatal("expected an uncached magnet to be refused")
+	}
+	if len(api.transfers) != 0 {
+		t.Errorf("expected no transfer to be created, got %d", len(api.transfers))
+	}
+
+	torrent, err := pm.SubmitMagnet(newTorrent(uncachedHash, "Uncached", true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	torrent, err = pm.CheckStatus(torrent)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if torrent.Status != "downloading" {
+		t.Errorf("expected an uncached transfer to be downloading, got %s", torrent.Status)
+	}
+}
+
+func TestSubmitMagnetCached(t *testing.T) {
+	api := newFakeAPI(t)
+	api.addCached(cachedHash, map[string]int64{
+		"Show.S01E01.mkv":          100,
+		"Extras/Show.S01E01.srt":   10,
+		"Season 1/Show.S01E02.mkv": 200,
+		"Sample/sample.mkv":        5,
+	})
+	pm := api.newClient()
+
+	torrent, err := pm.SubmitMagnet(newTorrent(cachedHash, "Show.S01", false))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if torrent.Id == "" || torrent.Debrid != "premiumize" {
+		t.Fatalf("unexpected torrent: %+v", torrent)
+	}
+	torrent, err = pm.CheckStatus(torrent)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if torrent.Status != "downloaded" {
+		t.Fatalf("expected the torrent to be downloaded, got %s", torrent.Status)
+	}
+
+	// Subtitles aren't allowed by default, samples are skipped
+	names := make([]string, 0, len(torrent.Files))
+	for _, f := range torrent.Files {
+		names = append(names, f.Path)
+	}
+	slices.Sort(names)
+	want := []string{"Season 1/Show.S01E02.mkv", "Show.S01E01.mkv"}
+	if !slices.Equal(names, want) {
+		t.Errorf("expected files %v, got %v", want, names)
+	}
+	if torrent.Bytes != 315 {
+		t.Errorf("expected the size of every file, got %d", torrent.Bytes)
+	}
+}
+
+func TestGetTorrents(t *testing.T) {
+	api := newFakeAPI(t)
+	api.addCached(cachedHash, map[string]int64{"Movie.mkv": 100})
+	pm := api.newClient()
+
+	if _, err := pm.SubmitMagnet(newTorrent(cachedHash, "Movie", false)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := pm.SubmitMagnet(newTorrent(uncachedHash, "Uncached", true)); err != nil {
+		t.Fatal(err)
+	}
+	torrents, err := pm.GetTorrents()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(torrents) != 1 {
+		t.Fatalf("expected only the finished transfer, got %d", len(torrents))
+	}
+	if !strings.EqualFold(torrents[0].InfoHash, cachedHash) {
+		t.Errorf("expected the infohash to be read from the source, got %s", torrents[0].InfoHash)
+	}
+}
+
+func TestGetDownloadLink(t *testing.T) {
+	api := newFakeAPI(t)
+	api.addCached(cachedHash, map[string]int64{"Movie.mkv": 100})
+	pm := api.newClient()
+
+	torrent, err := pm.SubmitMagnet(newTorrent(cachedHash, "Movie", false))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if torrent, err = pm.CheckStatus(torrent); err != nil {
+		t.Fatal(err)
+	}
+	file := torrent.Files["Movie.mkv"]
+	dl, err := pm.GetDownloadLink(torrent, &file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dl.DownloadLink == "" || dl.DownloadLink == file.Link {
+		t.Errorf("expected a fresh link from the item details, got %q", dl.DownloadLink)
+	}
+	if dl.Link != file.Link {
+		t.Errorf("expected the link to stay keyed by the file link, got %q", dl.Link)
+	}
+}
+
+func TestGetFileDownloadLinksKeepsDuplicateNames(t *testing.T) {
+	api := newFakeAPI(t)
+	api.addCached(cachedHash, map[string]int64{
+		"Season 1/Episode.mkv": 100,
+		"Season 2/Episode.mkv": 200,
+	})
+	pm := api.newClient()
+
+	torrent, err := pm.SubmitMagnet(newTorrent(cachedHash, "Show", false))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if torrent, err = pm.CheckStatus(torrent); err != nil {
+		t.Fatal(err)
+	}
+	keys := slices.Sorted(maps.Keys(torrent.Files))
+	if err := pm.GetFileDownloadLinks(torrent); err != nil {
+		t.Fatal(err)
+	}
+	if got := slices.Sorted(maps.Keys(torrent.Files)); !slices.Equal(got, keys) {
+		t.Fatalf("expected the files to keep their keys %v, got %v", keys, got)
+	}
+	for key, f := range torrent.Files {
+		if f.DownloadLink.DownloadLink == "" {
+			t.Errorf("expected a download link for %s", key)
+		}
+	}
+}
+
+func TestDeleteTorrent(t *testing.T) {
+	api := newFakeAPI(t)
+	api.addCached(cachedHash, map[string]int64{"Movie.mkv": 100})
+	pm := api.newClient()
+
+	torrent, err := pm.SubmitMagnet(newTorrent(cachedHash, "Movie", false))
+	if err != nil {
+		t.Fatal(err)
+	}
+	folderId := api.transfers[0].FolderId
+	if err := pm.DeleteTorrent(torrent.Id); err != nil {
+		t.Fatal(err)
+	}
+	if len(api.transfers) != 0 {
+		t.Error("expected the transfer to be deleted")
+	}
+	if !slices.Contains(api.deleted, folderId) {
+		t.Error("expected the transfer's folder to be deleted from the cloud")
+	}
+	if _, err := pm.GetTorrent(torrent.Id); err == nil {
+		t.Error("expected a deleted torrent not to be found")
+	}
+}
+
+func TestGetAvailableSlots(t *testing.T) {
+	api := newFakeAPI(t)
+	pm := api.newClient()
+	pm.minimumFreeSlot = 10
+
+	slots, err := pm.GetAvailableSlots()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if slots != transferSlots-10 {
+		t.Errorf("expected %d slots, got %d", transferSlots-10, slots)
+	}
+}
+
+func TestSyncAccounts(t *testing.T) {
+	api := newFakeAPI(t)
+	api.limitUsed = 0.25
+	pm := api.newClient()
+
+	if err := pm.SyncAccounts(); err != nil {
+		t.Fatal(err)
+	}
+	accounts := pm.AccountManager().All()
+	if len(accounts) != 1 {
+		t.Fatalf("expected one account, got %d", len(accounts))
+	}
+	if accounts[0].Username != "1234" {
+		t.Errorf("expected the customer id as the username, got %s", accounts[0].Username)
+	}
+	if want := int64(250 * fairUsePointBytes); accounts[0].TrafficUsed.Load() != want {
+		t.Errorf("expected %d bytes of traffic, got %d", want, accounts[0].TrafficUsed.Load())
+	}
+}
+
+func TestGetProfile(t *testing.T) {
+	api := newFakeAPI(t)
+	pm := api.newClient()
+
+	profile, err := pm.GetProfile()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if profile.Id != 1234 || profile.Type != "premium" {
+		t.Errorf("unexpected profile: %+v", profile)
+	}
+}
+
+func TestAPIError(t *testing.T) {
+	api := newFakeAPI(t)
+	pm := api.newClient()
+	pm.APIKey = "wrong"
+
+	if _, err := pm.GetTorrents(); err == nil || !strings.Contains(err.Error(), "Not logged in") {
+		t.Errorf("expected the API error message, got %v", err)
+	}
+}
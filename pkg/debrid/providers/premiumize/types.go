@@ -0,0 +1,114 @@
+package premiumize
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+type APIResponse struct {
+	Status  string `json:"status"`
+	Message string `json:"message,omitempty"`
+}
+
+func (r APIResponse) Err() error {
+	if r.Status == "success" {
+		return nil
+	}
+	if r.Message == "" {
+		return fmt.Errorf("premiumize API error: status %s", r.Status)
+	}
+	return fmt.Errorf("premiumize API error: %s", r.Message)
+}
+
+type transfer struct {
+	Id       string  `json:"id"`
+	Name     string  `json:"name"`
+	Message  string  `json:"message"`
+	Status   string  `json:"status"`
+	Progress float64 `json:"progress"`
+	Src      string  `json:"src"`
+	FolderId string  `json:"folder_id"`
+	FileId   string  `json:"file_id"`
+}
+
+type CreateTransferResponse struct {
+	APIResponse
+	Id   string `json:"id"`
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+type TransferListResponse struct {
+	APIResponse
+	Transfers []transfer `json:"transfers"`
+}
+
+type folderItem struct {
+	Id         string `json:"id"`
+	Name       string `json:"name"`
+	Type       string `json:"type"`
+	Size       int64  `json:"size"`
+	CreatedAt  int64  `json:"created_at"`
+	MimeType   string `json:"mime_type"`
+	Link       string `json:"link"`
+	StreamLink string `json:"stream_link"`
+}
+
+type FolderListResponse struct {
+	APIResponse
+	Content  []folderItem `json:"content"`
+	Name     string       `json:"name"`
+	ParentId string       `json:"parent_id"`
+	FolderId string       `json:"folder_id"`
+}
+
+type ItemDetailsResponse struct {
+	Id         string `json:"id"`
+	Name       string `json:"name"`
+	Type       string `json:"type"`
+	Size       int64  `json:"size"`
+	CreatedAt  int64  `json:"created_at"`
+	FolderId   string `json:"folder_id"`
+	Link       string `json:"link"`
+	StreamLink string `json:"stream_link"`
+}
+
+type CacheCheckResponse struct {
+	APIResponse
+	Response []bool       `json:"response"`
+	Filename []string     `json:"filename"`
+	Filesize []flexString `json:"filesize"`
+}
+
+type AccountInfoResponse struct {
+	APIResponse
+	CustomerId   flexString `json:"customer_id"`
+	PremiumUntil int64      `json:"premium_until"`
+	LimitUsed    float64    `json:"limit_used"`
+	SpaceUsed    float64    `json:"space_used"`
+}
+
+// flexString accepts both JSON strings and numbers.
+// Premiumize is not consistent about the type of some numeric fields(customer_id, filesize)
+type flexString string
+
+func (f *flexString) UnmarshalJSON(data []byte) error {
+	if len(data) == 0 || string(data) == "null" {
+		*f = ""
+		return nil
+	}
+	if data[0] == '"' {
+		var s string
+		if err := json.Unmarshal(data, &s); err != nil {
+			return err
+		}
+		*f = flexString(s)
+		return nil
+	}
+	var n json.Number
+	if err := json.Unmarshal(data, &n); err != nil {
+		return fmt.Errorf("flexString: unsupported JSON value %s", string(data))
+	}
+	*f = flexString(n.String())
+	return nil
+}
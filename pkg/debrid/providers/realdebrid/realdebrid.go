@@ -9,11 +9,13 @@ import (
 	"net/http"
 	gourl "net/url"
 	"path/filepath"
+	"slices"
 	"strconv"
 	"strings"
 	"sync"
 	"time"
 
+	"github.com/puzpuzpuz/xsync/v4"
 	"github.com/sirrobot01/decypharr/pkg/debrid/account"
 	"github.com/sirrobot01/decypharr/pkg/debrid/types"
 	"go.uber.org/ratelimit"
@@ -43,6 +45,7 @@ type RealDebrid struct {
 	UnpackRar bool
 
 	rarSemaphore    chan struct{}
+	splitRars       *xsync.Map[string, splitRar] // torrent id/archive => files inside the split RAR, dropped with the torrent
 	checkCached     bool
 	addSamples      bool
 	Profile         *types.Profile
@@ -77,6 +80,7 @@ func New(dc config.Debrid, ratelimits map[string]ratelimit.Limiter) (*RealDebrid
 			request.WithMaxRetries(10),
 			request.WithRetryableStatus(429, 502),
 			request.WithProxy(dc.Proxy),
+			request.WithMetrics(dc.Name),
 		),
 		repairClient: request.New(
 			request.WithRateLimiter(ratelimits["repair"]),
@@ -85,10 +89,12 @@ func New(dc config.Debrid, ratelimits map[string]ratelimit.Limiter) (*RealDebrid
 			request.WithMaxRetries(4),
 			request.WithRetryableStatus(429, 502),
 			request.WithProxy(dc.Proxy),
+			request.WithMetrics(dc.Name),
 		),
 		MountPath:       dc.Folder,
 		logger:          logger.New(dc.Name),
 		rarSemaphore:    make(chan struct{}, 2),
+		splitRars:       xsync.NewMap[string, splitRar](),
 		checkCached:     dc.CheckCached,
 		addSamples:      dc.AddSamples,
 		minimumFreeSlot: dc.MinimumFreeSlot,
@@ -149,6 +155,10 @@ func (r *RealDebrid) getSelectedFiles(t *types.Torrent, data torrentInfo) (map[s
 		}
 	}
 
+	if r.UnpackRar {
+		r.handleRarVolumes(t, files)
+	}
+
 	return files, nil
 }
 
@@ -221,6 +231,10 @@ func (r *RealDebrid) handleRarArchive(t *types.Torrent, data torrentInfo, select
 
 	for _, rarFile := range rarFiles {
 		if file, exists := fileMap[rarFile.Name()]; exists {
+			if !rarFile.IsStored() {
+				r.logger.Warn().Msgf("RAR file %s is compressed or encrypted, can't serve it in place", rarFile.Name())
+				continue
+			}
 			file.IsRar = true
 			file.ByteRange = rarFile.ByteRange()
 			file.Link = data.Links[0]
@@ -238,6 +252,129 @@ func (r *RealDebrid) handleRarArchive(t *types.Torrent, data torrentInfo, select
 	return files, nil
 }
 
+// splitRar is the unpacked content of a split RAR, with the link of its first volume it was read from
+type splitRar struct {
+	link  string
+	files map[string]types.File
+}
+
+// handleRarVolumes replaces the volumes of split RARs with the stored files inside them, each one
+// stitched together from its parts in every volume. Volumes that can't be read are dropped, like any other
+// file that isn't allowed
+func (r *RealDebrid) handleRarVolumes(t *types.Torrent, files map[string]types.File) {
+	cfg := config.Get()
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	unpacked := make(map[string]types.File)
+	for archive, volumes := range rar.GroupVolumes(names) {
+		// Links only change with the torrent, no need to read the volumes on every refresh
+		key := t.Id + "/" + archive
+		link := files[volumes[0]].Link
+		cached, ok := r.splitRars.Load(key)
+		if !ok || cached.link != link {
+			archiveFiles, err := r.unpackRarVolumes(t, files, volumes)
+			if err != nil {
+				r.logger.Debug().Err(err).Msgf("Error reading split RAR %s of %s, skipping its volumes", archive, t.Name)
+				continue
+			}
+			cached = splitRar{link: link, files: archiveFiles}
+			r.splitRars.Store(key, cached)
+			r.logger.Info().Msgf("Unpacked split RAR %s of %s: %d volumes, %d files", archive, t.Name, len(volumes), len(archiveFiles))
+		}
+		for name, file := range cached.files {
+			unpacked[name] = file
+		}
+	}
+	// Volumes are only selected to be unpacked, the ones left are unreadable or single RARs
+	for _, name := range names {
+		if rar.IsVolume(name) && !cfg.IsAllowedFile(name) {
+			delete(files, name)
+		}
+	}
+	for name, file := range unpacked {
+		files[name] = file
+	}
+}
+
+// forgetSplitRars drops the unpacked split RARs of the torrents gone reports true for
+func (r *RealDebrid) forgetSplitRars(gone func(torrentId string) bool) {
+	r.splitRars.Range(func(key string, _ splitRar) bool {
+		if id, _, _ := strings.Cut(key, "/"); gone(id) {
+			r.splitRars.Delete(key)
+		}
+		return true
+	})
+}
+
+// unpackRarVolumes returns the playable files inside the volumes of a split RAR
+func (r *RealDebrid) unpackRarVolumes(t *types.Torrent, files map[string]types.File, volumes []string) (map[string]types.File, error) {
+	rarFiles, err := r.readRarVolumes(t, files, volumes)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := config.Get()
+	now := time.Now()
+	unpacked := make(map[string]types.File)
+	for _, rarFile := range rarFiles {
+		name := rarFile.Name()
+		if rarFile.IsDirectory || !cfg.IsAllowedFile(name) || (!r.addSamples && utils.IsSampleFile(rarFile.Path)) {
+			continue
+		}
+		if !rarFile.IsStored() || rarFile.CompressedSize != rarFile.Size {
+			r.logger.Warn().Msgf("RAR file %s is compressed or encrypted, can't serve it in place", name)
+			continue
+		}
+		first := files[volumes[rarFile.Parts[0].Volume]]
+		file := types.File{
+			TorrentId: t.Id,
+			Id:        first.Id,
+			Name:      name,
+			Path:      name,
+			Size:      rarFile.Size,
+			IsRar:     true,
+			Link:      first.Link,
+			Generated: now,
+			Parts:     make([]types.FilePart, 0, len(rarFile.Parts)),
+		}
+		for _, p := range rarFile.Parts {
+			volume := files[volumes[p.Volume]]
+			file.Parts = append(file.Parts, types.FilePart{
+				Name:      volume.Name,
+				Link:      volume.Link,
+				ByteRange: [2]int64{p.DataOffset, p.DataOffset + p.Size - 1},
+			})
+		}
+		unpacked[name] = file
+	}
+	if len(unpacked) == 0 {
+		return nil, fmt.Errorf("no playable files found")
+	}
+	return unpacked, nil
+}
+
+// readRarVolumes reads the files inside the volumes of a split RAR, in order
+func (r *RealDebrid) readRarVolumes(t *types.Torrent, files map[string]types.File, volumes []string) ([]*rar.File, error) {
+	// This will block if 2 RAR operations are already in progress
+	r.rarSemaphore <- struct{}{}
+	defer func() {
+		<-r.rarSemaphore
+	}()
+
+	urls := make([]string, 0, len(volumes))
+	for _, name := range volumes {
+		volume := files[name]
+		downloadLink, err := r.GetDownloadLink(t, &volume)
+		if err != nil {
+			return nil, fmt.Errorf("error getting download link for %s: %w", name, err)
+		}
+		urls = append(urls, downloadLink.DownloadLink)
+	}
+	return rar.ReadVolumes(urls)
+}
+
 // getTorrentFiles returns a list of torrent files from the torrent info
 // validate is used to determine if the files should be validated
 // if validate is false, selected files will be returned
@@ -253,7 +390,7 @@ func (r *RealDebrid) getTorrentFiles(t *types.Torrent, data torrentInfo) map[str
 			continue
 		}
 
-		if !cfg.IsAllowedFile(name) {
+		if !cfg.IsAllowedFile(name) && !(r.UnpackRar && rar.IsVolume(name)) {
 			continue
 		}
 		if !cfg.IsSizeAllowed(f.Bytes) {
@@ -311,11 +448,9 @@ func (r *RealDebrid) IsAvailable(hashes []string) map[string]bool {
 			r.logger.Error().Err(err).Msgf("Error marshalling availability")
 			return result
 		}
-		for _, h := range hashes[i:end] {
+		for _, h := range validHashes {
 			hosters, exists := data[strings.ToLower(h)]
-			if exists && len(hosters.Rd) > 0 {
-				result[h] = true
-			}
+			result[h] = exists && len(hosters.Rd) > 0
 		}
 	}
 	return result
@@ -432,6 +567,44 @@ func (r *RealDebrid) GetTorrent(torrentId string) (*types.Torrent, error) {
 	return t, nil
 }
 
+// SelectFiles selects the files with the paths and deselects the others. Real Debrid only downloads the selected
+// files, it refuses a new selection once the torrent is downloaded
+func (r *RealDebrid) SelectFiles(torrentId string, paths []string) error {
+	url := fmt.Sprintf("%s/torrents/info/%s", r.Host, torrentId)
+	req, _ := http.NewRequest(http.MethodGet, url, nil)
+	resp, err := r.client.MakeRequest(req)
+	if err != nil {
+		return err
+	}
+	var data torrentInfo
+	if err = json.Unmarshal(resp, &data); err != nil {
+		return err
+	}
+	ids := make([]string, 0, len(paths))
+	changed := false
+	for _, f := range data.Files {
+		selected := slices.Contains(paths, filepath.Base(f.Path))
+		if selected {
+			ids = append(ids, strconv.Itoa(f.ID))
+		}
+		changed = changed || selected != (f.Selected == 1)
+	}
+	if len(ids) == 0 {
+		return fmt.Errorf("none of the files to select are in torrent %s", torrentId)
+	}
+	if !changed {
+		return nil
+	}
+	p := gourl.Values{
+		"files": {strings.Join(ids, ",")},
+	}
+	req, _ = http.NewRequest(http.MethodPost, fmt.Sprintf("%s/torrents/selectFiles/%s", r.Host, torrentId), strings.NewReader(p.Encode()))
+	if _, err = r.client.MakeRequest(req); err != nil {
+		return fmt.Errorf("failed to select files: %w", err)
+	}
+	return nil
+}
+
 func (r *RealDebrid) UpdateTorrent(t *types.Torrent) error {
 	url := fmt.Sprintf("%s/torrents/info/%s", r.Host, t.Id)
 	req, _ := http.NewRequest(http.MethodGet, url, nil)
@@ -545,6 +718,7 @@ func (r *RealDebrid) DeleteTorrent(torrentId string) error {
 	if _, err := r.client.MakeRequest(req); err != nil {
 		return err
 	}
+	r.forgetSplitRars(func(id string) bool { return id == torrentId })
 	r.logger.Info().Msgf("Torrent: %s deleted from RD", torrentId)
 	return nil
 }
@@ -583,6 +757,24 @@ func (r *RealDebrid) GetFileDownloadLinks(t *types.Torrent) error {
 			}
 
 			file.DownloadLink = link
+
+			// Split RAR files are downloaded from each volume
+			if len(file.Parts) > 0 {
+				file.Parts = slices.Clone(file.Parts)
+				for i, part := range file.Parts {
+					partLink, err := r.GetDownloadLink(t, &types.File{TorrentId: file.TorrentId, Name: part.Name, Link: part.Link})
+					if err != nil {
+						mu.Lock()
+						if firstErr == nil {
+							firstErr = err
+						}
+						mu.Unlock()
+						return
+					}
+					file.Parts[i].DownloadLink = partLink
+				}
+			}
+
 			mu.Lock()
 			files[file.Name] = file
 			links[link.Link] = link
@@ -795,6 +987,18 @@ func (r *RealDebrid) GetTorrents() ([]*types.Torrent, error) {
 		return nil, fetchError
 	}
 
+	if hardLimit == 0 {
+		// Torrents removed outside of Decypharr are only noticed here
+		ids := make(map[string]struct{}, len(allTorrents))
+		for _, t := range allTorrents {
+			ids[t.Id] = struct{}{}
+		}
+		r.forgetSplitRars(func(id string) bool {
+			_, ok := ids[id]
+			return !ok
+		})
+	}
+
 	return allTorrents, nil
 }
 
@@ -54,6 +54,7 @@ func New(dc config.Debrid, ratelimits map[string]ratelimit.Limiter) (*Torbox, er
 		request.WithRateLimiter(ratelimits["main"]),
 		request.WithLogger(_log),
 		request.WithProxy(dc.Proxy),
+		request.WithMetrics(dc.Name),
 	)
 	autoExpiresLinksAfter, err := time.ParseDuration(dc.AutoExpireLinksAfter)
 	if autoExpiresLinksAfter == 0 || err != nil {
@@ -125,6 +126,9 @@ func (tb *Torbox) IsAvailable(hashes []string) map[string]bool {
 			return result
 		}
 
+		for _, h := range validHashes {
+			result[strings.ToUpper(h)] = false
+		}
 		for h, c := range *res.Data {
 			if c.Size > 0 {
 				result[strings.ToUpper(h)] = true
@@ -177,7 +181,8 @@ func (tb *Torbox) getTorboxStatus(status string, finished bool) string {
 	downloading := []string{"completed", "cached", "paused", "downloading", "uploading",
 		"checkingResumeData", "metaDL", "pausedUP", "queuedUP", "checkingUP",
 		"forcedUP", "allocating", "downloading", "metaDL", "pausedDL",
-		"queuedDL", "checkingDL", "forcedDL", "checkingResumeData", "moving"}
+		"queuedDL", "checkingDL", "forcedDL", "checkingResumeData", "moving",
+		"queued", "verifying", "repairing", "extracting"}
 
 	var determinedStatus string
 	switch {
@@ -191,7 +196,7 @@ func (tb *Torbox) getTorboxStatus(status string, finished bool) string {
 }
 
 func (tb *Torbox) GetTorrent(torrentId string) (*types.Torrent, error) {
-	url := fmt.Sprintf("%s/api/torrents/mylist/?id=%s", tb.Host, torrentId)
+	url := tb.infoUrl(torrentId)
 	req, _ := http.NewRequest(http.MethodGet, url, nil)
 	resp, err := tb.client.MakeRequest(req)
 	if err != nil {
@@ -222,6 +227,9 @@ func (tb *Torbox) GetTorrent(torrentId string) (*types.Torrent, error) {
 		Files:            make(map[string]types.File),
 		Added:            data.CreatedAt.Format(time.RFC3339),
 	}
+	if isUsenetId(torrentId) {
+		t.Id = torrentId
+	}
 	cfg := config.Get()
 
 	totalFiles := 0
@@ -291,7 +299,7 @@ func (tb *Torbox) GetTorrent(torrentId string) (*types.Torrent, error) {
 }
 
 func (tb *Torbox) UpdateTorrent(t *types.Torrent) error {
-	url := fmt.Sprintf("%s/api/torrents/mylist/?id=%s", tb.Host, t.Id)
+	url := tb.infoUrl(t.Id)
 	req, _ := http.NewRequest(http.MethodGet, url, nil)
 	resp, err := tb.client.MakeRequest(req)
 	if err != nil {
@@ -303,6 +311,9 @@ func (tb *Torbox) UpdateTorrent(t *types.Torrent) error {
 		return err
 	}
 	data := res.Data
+	if data == nil {
+		return fmt.Errorf("error getting torrent")
+	}
 	name := data.Name
 
 	t.Name = name
@@ -395,6 +406,9 @@ func (tb *Torbox) CheckStatus(torrent *types.Torrent) (*types.Torrent, error) {
 }
 
 func (tb *Torbox) DeleteTorrent(torrentId string) error {
+	if isUsenetId(torrentId) {
+		return tb.deleteUsenetDownload(torrentId)
+	}
 	url := fmt.Sprintf("%s/api/torrents/controltorrent/%s", tb.Host, torrentId)
 	payload := map[string]string{"torrent_id": torrentId, "action": "Delete"}
 	jsonPayload, _ := json.Marshal(payload)
@@ -455,7 +469,12 @@ func (tb *Torbox) GetFileDownloadLinks(t *types.Torrent) error {
 func (tb *Torbox) GetDownloadLink(t *types.Torrent, file *types.File) (types.DownloadLink, error) {
 	url := fmt.Sprintf("%s/api/torrents/requestdl/", tb.Host)
 	query := gourl.Values{}
-	query.Add("torrent_id", t.Id)
+	if isUsenetId(t.Id) {
+		url = fmt.Sprintf("%s/api/usenet/requestdl", tb.Host)
+		query.Add("usenet_id", strings.TrimPrefix(t.Id, usenetPrefix))
+	} else {
+		query.Add("torrent_id", t.Id)
+	}
 	query.Add("token", tb.APIKey)
 	query.Add("file_id", file.Id)
 	url += "?" + query.Encode()
@@ -535,11 +554,30 @@ func (tb *Torbox) GetTorrents() ([]*types.Torrent, error) {
 		allTorrents = append(allTorrents, torrents...)
 		offset += len(torrents)
 	}
+
+	// Usenet downloads are listed separately
+	offset = 0
+	for {
+		downloads, err := tb.getUsenetDownloads(offset)
+		if err != nil {
+			break
+		}
+		if len(downloads) == 0 {
+			break
+		}
+		allTorrents = append(allTorrents, downloads...)
+		offset += len(downloads)
+	}
 	return allTorrents, nil
 }
 
 func (tb *Torbox) getTorrents(offset int) ([]*types.Torrent, error) {
 	url := fmt.Sprintf("%s/api/torrents/mylist?offset=%d", tb.Host, offset)
+	return tb.listDownloads(url, "")
+}
+
+// listDownloads fetches a page of the given mylist endpoint. idPrefix is prepended to the ids returned
+func (tb *Torbox) listDownloads(url, idPrefix string) ([]*types.Torrent, error) {
 	req, _ := http.NewRequest(http.MethodGet, url, nil)
 	resp, err := tb.client.MakeRequest(req)
 	if err != nil {
@@ -561,7 +599,7 @@ func (tb *Torbox) getTorrents(offset int) ([]*types.Torrent, error) {
 
 	for _, data := range *res.Data {
 		t := &types.Torrent{
-			Id:               strconv.Itoa(data.Id),
+			Id:               idPrefix + strconv.Itoa(data.Id),
 			Name:             data.Name,
 			Bytes:            data.Size,
 			Folder:           data.Name,
@@ -75,3 +75,8 @@ type InfoResponse APIResponse[torboxInfo]
 type DownloadLinksResponse APIResponse[string]
 
 type TorrentsListResponse APIResponse[[]torboxInfo]
+
+type AddNZBResponse APIResponse[struct {
+	Id   int    `json:"usenetdownload_id"`
+	Hash string `json:"hash"`
+}]
@@ -0,0 +1,88 @@
+package torbox
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sirrobot01/decypharr/pkg/debrid/types"
+)
+
+// Usenet downloads live in a different id space from torrents on Torbox.
+// Their ids are prefixed so the rest of the client can tell them apart.
+const usenetPrefix = "usenet-"
+
+func isUsenetId(id string) bool {
+	return strings.HasPrefix(id, usenetPrefix)
+}
+
+func (tb *Torbox) SubmitNZB(torrent *types.Torrent) (*types.Torrent, error) {
+	url := fmt.Sprintf("%s/api/usenet/createusenetdownload", tb.Host)
+	payload := &bytes.Buffer{}
+	writer := multipart.NewWriter(payload)
+	part, err := writer.CreateFormFile("file", torrent.Name+".nzb")
+	if err != nil {
+		return nil, err
+	}
+	if _, err := part.Write(torrent.Magnet.NZB); err != nil {
+		return nil, err
+	}
+	_ = writer.WriteField("name", torrent.Name)
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+	req, _ := http.NewRequest(http.MethodPost, url, payload)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	resp, err := tb.client.MakeRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	var data AddNZBResponse
+	if err := json.Unmarshal(resp, &data); err != nil {
+		return nil, err
+	}
+	if data.Data == nil {
+		return nil, fmt.Errorf("error adding nzb: %s", data.Detail)
+	}
+	torrent.Id = usenetPrefix + strconv.Itoa(data.Data.Id)
+	torrent.MountPath = tb.MountPath
+	torrent.Debrid = tb.name
+	torrent.Added = time.Now().Format(time.RFC3339)
+
+	return torrent, nil
+}
+
+// infoUrl returns the mylist url for a torrent or usenet download
+func (tb *Torbox) infoUrl(id string) string {
+	if isUsenetId(id) {
+		return fmt.Sprintf("%s/api/usenet/mylist?id=%s", tb.Host, strings.TrimPrefix(id, usenetPrefix))
+	}
+	return fmt.Sprintf("%s/api/torrents/mylist/?id=%s", tb.Host, id)
+}
+
+func (tb *Torbox) deleteUsenetDownload(id string) error {
+	url := fmt.Sprintf("%s/api/usenet/controlusenetdownload", tb.Host)
+	usenetId, err := strconv.Atoi(strings.TrimPrefix(id, usenetPrefix))
+	if err != nil {
+		return fmt.Errorf("invalid usenet id %s", id)
+	}
+	payload := map[string]any{"usenet_id": usenetId, "operation": "delete"}
+	jsonPayload, _ := json.Marshal(payload)
+	req, _ := http.NewRequest(http.MethodPost, url, bytes.NewBuffer(jsonPayload))
+	req.Header.Set("Content-Type", "application/json")
+	if _, err := tb.client.MakeRequest(req); err != nil {
+		return err
+	}
+	tb.logger.Info().Msgf("Usenet download %s deleted from Torbox", id)
+	return nil
+}
+
+func (tb *Torbox) getUsenetDownloads(offset int) ([]*types.Torrent, error) {
+	url := fmt.Sprintf("%s/api/usenet/mylist?offset=%d", tb.Host, offset)
+	return tb.listDownloads(url, usenetPrefix)
+}
@@ -0,0 +1,157 @@
+package debrid
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/sirrobot01/decypharr/pkg/debrid/common"
+)
+
+type SelectionPolicy string
+
+const (
+	// SelectionPriority tries the debrids by their configured priority, then config order
+	SelectionPriority SelectionPolicy = "priority"
+	// SelectionCached tries the debrids that already have the hash cached first
+	SelectionCached SelectionPolicy = "cached"
+	// SelectionRoundRobin starts with the debrid after the last one used
+	SelectionRoundRobin SelectionPolicy = "round_robin"
+	// SelectionSlots tries the debrid with the most free slots first
+	SelectionSlots SelectionPolicy = "slots"
+	// SelectionSelected is used when the debrid is set on the request/arr
+	SelectionSelected SelectionPolicy = "selected"
+)
+
+type candidate struct {
+	client common.Client
+	reason string
+}
+
+func parseSelectionPolicy(policy string) SelectionPolicy {
+	switch p := SelectionPolicy(strings.ToLower(strings.TrimSpace(policy))); p {
+	case SelectionCached, SelectionRoundRobin, SelectionSlots:
+		return p
+	default:
+		return SelectionPriority
+	}
+}
+
+// byPriority returns the clients ordered by priority, then by their position in the config
+func (d *Storage) byPriority(clients map[string]common.Client) []candidate {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	names := make([]string, 0, len(clients))
+	for name := range clients {
+		names = append(names, name)
+	}
+	sort.SliceStable(names, func(i, j int) bool {
+		a, b := d.debrids[names[i]], d.debrids[names[j]]
+		if a.priority != b.priority {
+			return a.priority < b.priority
+		}
+		return a.order < b.order
+	})
+	candidates := make([]candidate, 0, len(names))
+	for _, name := range names {
+		candidates = append(candidates, candidate{
+			client: clients[name],
+			reason: fmt.Sprintf("priority %d", d.debrids[name].priority),
+		})
+	}
+	return candidates
+}
+
+// orderClients sorts the clients according to the selection policy.
+// Debrids that can't be ranked by the policy keep their priority order at the end of the list
+func (d *Storage) orderClients(policy SelectionPolicy, clients map[string]common.Client, infohash string) []candidate {
+	candidates := d.byPriority(clients)
+	if len(candidates) < 2 {
+		return candidates
+	}
+
+	switch policy {
+	case SelectionCached:
+		if infohash == "" {
+			return candidates
+		}
+		available := d.Availability([]string{infohash}, clients)[strings.ToLower(infohash)]
+		ordered := make([]candidate, 0, len(candidates))
+		rest := make([]candidate, 0, len(candidates))
+		for _, c := range candidates {
+			if available[c.client.Name()] {
+				c.reason = "cached on " + c.client.Name()
+				ordered = append(ordered, c)
+			} else {
+				c.reason = "not cached, " + c.reason
+				rest = append(rest, c)
+			}
+		}
+		return append(ordered, rest...)
+
+	case SelectionRoundRobin:
+		d.mu.RLock()
+		lastUsed := d.lastUsed
+		d.mu.RUnlock()
+		start := 0
+		for i, c := range candidates {
+			if c.client.Name() == lastUsed {
+				start = i + 1
+				break
+			}
+		}
+		ordered := append(candidates[start%len(candidates):], candidates[:start%len(candidates)]...)
+		for i := range ordered {
+			if lastUsed == "" {
+				ordered[i].reason = "round robin, first pick"
+			} else {
+				ordered[i].reason = "round robin, last used " + lastUsed
+			}
+		}
+		return ordered
+
+	case SelectionSlots:
+		slots := make([]int, len(candidates))
+		var wg sync.WaitGroup
+		for i, c := range candidates {
+			wg.Add(1)
+			go func(i int, c candidate) {
+				defer wg.Done()
+				n, err := c.client.GetAvailableSlots()
+				if err != nil {
+					// Not supported by this debrid
+					n = -1
+				}
+				slots[i] = n
+			}(i, c)
+		}
+		wg.Wait()
+		for i := range candidates {
+			if slots[i] < 0 {
+				candidates[i].reason = "free slots unknown, " + candidates[i].reason
+			} else {
+				candidates[i].reason = fmt.Sprintf("%d free slots", slots[i])
+			}
+		}
+		indexes := make([]int, len(candidates))
+		for i := range indexes {
+			indexes[i] = i
+		}
+		sort.SliceStable(indexes, func(i, j int) bool {
+			return slots[indexes[i]] > slots[indexes[j]]
+		})
+		ordered := make([]candidate, 0, len(candidates))
+		for _, i := range indexes {
+			ordered = append(ordered, candidates[i])
+		}
+		return ordered
+	}
+	return candidates
+}
+
+func (d *Storage) setLastUsed(name string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.lastUsed = name
+}
@@ -1,18 +1,15 @@
 package store
 
 import (
-	"bufio"
 	"cmp"
 	"context"
 	"crypto/tls"
-	"errors"
 	"fmt"
 	"net/http"
 	"os"
 	"path"
 	"path/filepath"
 	"regexp"
-	"strconv"
 	"strings"
 	"sync"
 	"sync/atomic"
@@ -20,7 +17,6 @@ import (
 
 	"github.com/puzpuzpuz/xsync/v4"
 	"github.com/sirrobot01/decypharr/pkg/debrid/common"
-	"github.com/sirrobot01/decypharr/pkg/rclone"
 
 	"github.com/sirrobot01/decypharr/pkg/debrid/types"
 	"golang.org/x/sync/singleflight"
@@ -32,6 +28,7 @@ import (
 	"github.com/rs/zerolog"
 	"github.com/sirrobot01/decypharr/internal/config"
 	"github.com/sirrobot01/decypharr/internal/logger"
+	"github.com/sirrobot01/decypharr/internal/storage"
 	"github.com/sirrobot01/decypharr/internal/utils"
 )
 
@@ -76,8 +73,18 @@ type RepairRequest struct {
 	FileName  string
 }
 
+// Mounter mounts the WebDAV cache locally, either through rclone or the built-in FUSE filesystem
+type Mounter interface {
+	Mount(ctx context.Context) error
+	Unmount() error
+	IsMounted() bool
+	RefreshDir(dirs []string) error
+}
+
 type Cache struct {
-	dir    string
+	dir    string // legacy json cache, only used for migration
+	db     storage.Store
+	bucket string
 	client common.Client
 	logger zerolog.Logger
 
@@ -90,6 +97,7 @@ type Cache struct {
 	repairRequest        *xsync.Map[string, *reInsertRequest]
 	failedToReinsert     *xsync.Map[string, struct{}]
 	failedLinksCounter   *xsync.Map[string, atomic.Int32] // link -> counter
+	strms                *xsync.Map[string, strmEntry]    // infohash -> .strm files written for it
 
 	// repair
 	repairChan chan RepairRequest
@@ -109,16 +117,15 @@ type Cache struct {
 	scheduler    gocron.Scheduler
 	cetScheduler gocron.Scheduler
 
-	saveSemaphore chan struct{}
-
 	config        config.Debrid
 	customFolders []string
-	mounter       *rclone.Mount
+	mounter       Mounter
+	chunks        *chunkCache // nil when chunks aren't cached on disk
 	downloadSG    singleflight.Group
 	streamClient  *http.Client
 }
 
-func NewDebridCache(dc config.Debrid, client common.Client, mounter *rclone.Mount) *Cache {
+func NewDebridCache(dc config.Debrid, client common.Client, mounter Mounter) *Cache {
 	cfg := config.Get()
 	cet, err := time.LoadLocation("CET")
 	if err != nil {
@@ -177,7 +184,9 @@ func NewDebridCache(dc config.Debrid, client common.Client, mounter *rclone.Moun
 	}
 
 	c := &Cache{
-		dir: filepath.Join(cfg.Path, "cache", dc.Name), // path to save cache files
+		dir:    filepath.Join(cfg.Path, "cache", dc.Name), // path of the old cache files
+		db:     storage.Get(),
+		bucket: "cache:" + dc.Name,
 
 		torrents:                     newTorrentCache(dirFilters),
 		client:                       client,
@@ -186,7 +195,6 @@ func NewDebridCache(dc config.Debrid, client common.Client, mounter *rclone.Moun
 		torrentRefreshInterval:       dc.TorrentsRefreshInterval,
 		downloadLinksRefreshInterval: dc.DownloadLinksRefreshInterval,
 		folderNaming:                 WebDavFolderNaming(dc.FolderNaming),
-		saveSemaphore:                make(chan struct{}, 50),
 		cetScheduler:                 cetSc,
 		scheduler:                    scheduler,
 
@@ -199,16 +207,24 @@ func NewDebridCache(dc config.Debrid, client common.Client, mounter *rclone.Moun
 		repairRequest:        xsync.NewMap[string, *reInsertRequest](),
 		failedToReinsert:     xsync.NewMap[string, struct{}](),
 		failedLinksCounter:   xsync.NewMap[string, atomic.Int32](),
+		strms:                xsync.NewMap[string, strmEntry](),
 		streamClient:         httpClient,
 		repairChan:           make(chan RepairRequest, 100), // Initialize the repair channel, max 100 requests buffered
 	}
 
+	c.chunks = getChunkCache(cfg)
+
 	c.listingDebouncer = utils.NewDebouncer[bool](100*time.Millisecond, func(refreshRclone bool) {
 		c.RefreshListings(refreshRclone)
 	})
 	return c
 }
 
+// SetMounter replaces the mounter, for mounts that need the cache itself to serve files
+func (c *Cache) SetMounter(mounter Mounter) {
+	c.mounter = mounter
+}
+
 func (c *Cache) IsReady() chan struct{} {
 	return c.ready
 }
@@ -269,11 +285,8 @@ func (c *Cache) Reset() {
 }
 
 func (c *Cache) Start(ctx context.Context) error {
-	if err := os.MkdirAll(c.dir, 0755); err != nil {
-		return fmt.Errorf("failed to create cache directory: %w", err)
-	}
-
 	c.logger.Info().Msgf("Started indexing...")
+	c.loadStrms()
 
 	if err := c.Sync(ctx); err != nil {
 		return fmt.Errorf("failed to sync cache: %w", err)
@@ -304,34 +317,31 @@ func (c *Cache) Start(ctx context.Context) error {
 func (c *Cache) load(ctx context.Context) (map[string]CachedTorrent, error) {
 	mu := sync.Mutex{}
 
-	if err := os.MkdirAll(c.dir, 0755); err != nil {
-		return nil, fmt.Errorf("failed to create cache directory: %w", err)
+	if err := c.migrateFromJSON(); err != nil {
+		c.logger.Error().Err(err).Msgf("Failed to migrate cache files from %s", c.dir)
 	}
 
-	files, err := os.ReadDir(c.dir)
+	// Copy the raw entries out of the read transaction, decoding is done by the workers
+	entries := make([][]byte, 0)
+	err := c.db.ForEach(c.bucket, func(key string, value []byte) error {
+		entries = append(entries, append([]byte(nil), value...))
+		return nil
+	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to read cache directory: %w", err)
+		return nil, fmt.Errorf("failed to read cache: %w", err)
 	}
 
-	// Get only json files
-	var jsonFiles []os.DirEntry
-	for _, file := range files {
-		if !file.IsDir() && filepath.Ext(file.Name()) == ".json" {
-			jsonFiles = append(jsonFiles, file)
-		}
-	}
-
-	if len(jsonFiles) == 0 {
+	if len(entries) == 0 {
 		return nil, nil
 	}
 
 	// Create channels with appropriate buffering
-	workChan := make(chan os.DirEntry, min(c.workers, len(jsonFiles)))
+	workChan := make(chan []byte, min(c.workers, len(entries)))
 
 	// Create a wait group for workers
 	var wg sync.WaitGroup
 
-	torrents := make(map[string]CachedTorrent, len(jsonFiles))
+	torrents := make(map[string]CachedTorrent, len(entries))
 
 	// Start workers
 	for i := 0; i < c.workers; i++ {
@@ -340,22 +350,14 @@ func (c *Cache) load(ctx context.Context) (map[string]CachedTorrent, error) {
 			defer wg.Done()
 
 			for {
-				file, ok := <-workChan
+				data, ok := <-workChan
 				if !ok {
 					return // Channel closed, exit goroutine
 				}
 
-				fileName := file.Name()
-				filePath := filepath.Join(c.dir, fileName)
-				data, err := os.ReadFile(filePath)
-				if err != nil {
-					c.logger.Error().Err(err).Msgf("Failed to read file: %s", filePath)
-					continue
-				}
-
 				var ct CachedTorrent
 				if err := json.Unmarshal(data, &ct); err != nil {
-					c.logger.Error().Err(err).Msgf("Failed to unmarshal file: %s", filePath)
+					c.logger.Error().Err(err).Msgf("Failed to unmarshal cached torrent")
 					continue
 				}
 
@@ -390,12 +392,12 @@ func (c *Cache) load(ctx context.Context) (map[string]CachedTorrent, error) {
 	}
 
 	// Feed work to workers
-	for _, file := range jsonFiles {
+	for _, data := range entries {
 		select {
 		case <-ctx.Done():
 			break // Context cancelled
 		default:
-			workChan <- file
+			workChan <- data
 		}
 	}
 
@@ -408,6 +410,48 @@ func (c *Cache) load(ctx context.Context) (map[string]CachedTorrent, error) {
 	return torrents, nil
 }
 
+// migrateFromJSON imports the per-torrent json files of older versions into the database.
+// It only runs when the cache bucket is empty, the imported files are moved to a "migrated" folder
+func (c *Cache) migrateFromJSON() error {
+	files, err := os.ReadDir(c.dir)
+	if err != nil {
+		// Nothing to migrate
+		return nil
+	}
+	if count, err := c.db.Count(c.bucket); err != nil || count > 0 {
+		return err
+	}
+
+	items := make(map[string][]byte)
+	for _, file := range files {
+		if file.IsDir() || filepath.Ext(file.Name()) != ".json" {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(c.dir, file.Name()))
+		if err != nil {
+			c.logger.Error().Err(err).Msgf("Failed to read file: %s", file.Name())
+			continue
+		}
+		items[strings.TrimSuffix(file.Name(), ".json")] = data
+	}
+	if len(items) == 0 {
+		return nil
+	}
+	if err := c.db.PutMany(c.bucket, items); err != nil {
+		return err
+	}
+
+	migratedDir := filepath.Join(c.dir, "migrated")
+	if err := os.MkdirAll(migratedDir, 0755); err != nil {
+		return err
+	}
+	for id := range items {
+		_ = os.Rename(filepath.Join(c.dir, id+".json"), filepath.Join(migratedDir, id+".json"))
+	}
+	c.logger.Info().Msgf("Migrated %d torrents from %s", len(items), c.dir)
+	return nil
+}
+
 func (c *Cache) Sync(ctx context.Context) error {
 	cachedTorrents, err := c.load(ctx)
 	if err != nil {
@@ -445,7 +489,7 @@ func (c *Cache) Sync(ctx context.Context) error {
 		for _, id := range deletedTorrents {
 			// Remove from cache and debrid service
 			delete(cachedTorrents, id)
-			// Remove the json file from disk
+			// Remove it from the database
 			c.removeFile(id, false)
 
 		}
@@ -560,7 +604,9 @@ func (c *Cache) setTorrent(t CachedTorrent, callback func(torrent CachedTorrent)
 		updatedTorrent.Files = mergedFiles
 	}
 	c.torrents.set(torrentName, t)
-	go c.SaveTorrent(t)
+	// Saved before returning so a later delete can't be overwritten by it
+	c.SaveTorrent(t)
+	go c.updateStrms(t)
 	if callback != nil {
 		go callback(updatedTorrent)
 	}
@@ -576,6 +622,7 @@ func (c *Cache) setTorrents(torrents map[string]CachedTorrent, callback func())
 			updatedTorrent.Files = mergedFiles
 		}
 		c.torrents.set(torrentName, t)
+		c.updateStrms(t)
 	}
 	c.SaveTorrents()
 	if callback != nil {
@@ -627,84 +674,31 @@ func (c *Cache) GetTorrent(torrentId string) *CachedTorrent {
 	return nil
 }
 
+// SaveTorrents persists all the torrents in a single transaction
 func (c *Cache) SaveTorrents() {
 	torrents := c.torrents.getAll()
-	for _, torrent := range torrents {
-		c.SaveTorrent(torrent)
+	items := make(map[string][]byte, len(torrents))
+	for _, ct := range torrents {
+		marshaled, err := json.Marshal(ct)
+		if err != nil {
+			c.logger.Error().Err(err).Msgf("Failed to marshal torrent: %s", ct.Id)
+			continue
+		}
+		items[ct.Id] = marshaled
+	}
+	if err := c.db.PutMany(c.bucket, items); err != nil {
+		c.logger.Error().Err(err).Msg("Failed to save torrents")
 	}
 }
 
 func (c *Cache) SaveTorrent(ct CachedTorrent) {
-	marshaled, err := json.MarshalIndent(ct, "", "  ")
+	marshaled, err := json.Marshal(ct)
 	if err != nil {
 		c.logger.Error().Err(err).Msgf("Failed to marshal torrent: %s", ct.Id)
 		return
 	}
-
-	// Store just the essential info needed for the file operation
-	saveInfo := struct {
-		id       string
-		jsonData []byte
-	}{
-		id:       ct.Torrent.Id,
-		jsonData: marshaled,
-	}
-
-	// Try to acquire semaphore without blocking
-	select {
-	case c.saveSemaphore <- struct{}{}:
-		go func() {
-			defer func() { <-c.saveSemaphore }()
-			c.saveTorrent(saveInfo.id, saveInfo.jsonData)
-		}()
-	default:
-		c.saveTorrent(saveInfo.id, saveInfo.jsonData)
-	}
-}
-
-func (c *Cache) saveTorrent(id string, data []byte) {
-
-	fileName := id + ".json"
-	filePath := filepath.Join(c.dir, fileName)
-
-	// Use a unique temporary filename for concurrent safety
-	tmpFile := filePath + ".tmp." + strconv.FormatInt(time.Now().UnixNano(), 10)
-
-	f, err := os.Create(tmpFile)
-	if err != nil {
-		c.logger.Error().Err(err).Msgf("Failed to create file: %s", tmpFile)
-		return
-	}
-
-	// Track if we've closed the file
-	fileClosed := false
-	defer func() {
-		// Only close if not already closed
-		if !fileClosed {
-			_ = f.Close()
-		}
-		// Clean up the temp file if it still exists and rename failed
-		_ = os.Remove(tmpFile)
-	}()
-
-	w := bufio.NewWriter(f)
-	if _, err := w.Write(data); err != nil {
-		c.logger.Error().Err(err).Msgf("Failed to write data: %s", tmpFile)
-		return
-	}
-
-	if err := w.Flush(); err != nil {
-		c.logger.Error().Err(err).Msgf("Failed to flush data: %s", tmpFile)
-		return
-	}
-
-	// Close the file before renaming
-	_ = f.Close()
-	fileClosed = true
-
-	if err := os.Rename(tmpFile, filePath); err != nil {
-		c.logger.Error().Err(err).Msgf("Failed to rename file: %s", tmpFile)
-		return
+	if err := c.db.Put(c.bucket, ct.Torrent.Id, marshaled); err != nil {
+		c.logger.Error().Err(err).Msgf("Failed to save torrent: %s", ct.Id)
 	}
 }
 
@@ -824,6 +818,7 @@ func (c *Cache) deleteTorrent(id string, removeFromDebrid bool) bool {
 		if t, ok := c.torrents.getByName(torrentName); ok {
 
 			newFiles := map[string]types.File{}
+			removedFiles := make([]string, 0)
 			newId := ""
 			for _, file := range t.GetFiles() {
 				if file.TorrentId != "" && file.TorrentId != id {
@@ -831,17 +826,27 @@ func (c *Cache) deleteTorrent(id string, removeFromDebrid bool) bool {
 						newId = file.TorrentId
 					}
 					newFiles[file.Name] = file
+				} else {
+					removedFiles = append(removedFiles, file.Name)
 				}
 			}
 			if len(newFiles) == 0 {
 				// Delete the torrent since no files are left
 				c.torrents.remove(torrentName)
+				c.removeStrms(torrent.InfoHash)
+				c.invalidateChunks(torrentName)
 			} else {
 				t.Files = newFiles
 				newId = cmp.Or(newId, t.Id)
 				t.Id = newId
 				c.setTorrent(t, nil) // This gets called after calling deleteTorrent
+				if len(removedFiles) > 0 {
+					c.invalidateChunks(torrentName, removedFiles...)
+				}
 			}
+		} else {
+			c.removeStrms(torrent.InfoHash)
+			c.invalidateChunks(torrentName)
 		}
 		return true
 	}
@@ -857,29 +862,19 @@ func (c *Cache) DeleteTorrents(ids []string) {
 }
 
 func (c *Cache) removeFile(torrentId string, moveToTrash bool) {
-	// Moves the torrent file to the trash
-	filePath := filepath.Join(c.dir, torrentId+".json")
-
-	// Check if the file exists
-	if _, err := os.Stat(filePath); errors.Is(err, os.ErrNotExist) {
-		return
-	}
-
-	if !moveToTrash {
-		// If not moving to trash, delete the file directly
-		if err := os.Remove(filePath); err != nil {
-			c.logger.Error().Err(err).Msgf("Failed to remove file: %s", filePath)
+	if moveToTrash {
+		// Keep a copy of the torrent in the trash bucket
+		data, err := c.db.Get(c.bucket, torrentId)
+		if err != nil {
+			return
+		}
+		if err := c.db.Put(c.bucket+":trash", torrentId, data); err != nil {
+			c.logger.Error().Err(err).Msgf("Failed to move torrent to trash: %s", torrentId)
 			return
 		}
-		return
-	}
-	// Move the file to the trash
-	trashPath := filepath.Join(c.dir, "trash", torrentId+".json")
-	if err := os.MkdirAll(filepath.Dir(trashPath), 0755); err != nil {
-		return
 	}
-	if err := os.Rename(filePath, trashPath); err != nil {
-		return
+	if err := c.db.Delete(c.bucket, torrentId); err != nil {
+		c.logger.Error().Err(err).Msgf("Failed to remove torrent: %s", torrentId)
 	}
 }
 
@@ -906,6 +901,7 @@ func (c *Cache) RemoveFile(torrentId string, filename string) error {
 	}
 	file.Deleted = true
 	torrent.Files[filename] = file
+	c.invalidateChunks(c.GetTorrentFolder(torrent.Torrent), filename)
 
 	// If the torrent has no files left, delete it
 	if len(torrent.GetFiles()) == 0 {
@@ -922,6 +918,49 @@ func (c *Cache) RemoveFile(torrentId string, filename string) error {
 	return nil
 }
 
+// SkipFiles hides the files with the paths from the WebDAV, the other files of the torrent are shown again.
+// It returns the names of the skipped files
+func (c *Cache) SkipFiles(torrentId string, paths []string) ([]string, error) {
+	ct, ok := c.torrents.getByID(torrentId)
+	if !ok {
+		return nil, fmt.Errorf("torrent %s not found", torrentId)
+	}
+	t := ct.Torrent.Copy()
+
+	// Skipped files aren't kept when the cache is loaded, get them back from the debrid if one is selected again
+	refresh := false
+	for _, name := range t.SkippedFiles {
+		if _, ok := t.Files[name]; !ok {
+			refresh = true
+			break
+		}
+	}
+	if refresh {
+		deleted := make(map[string]bool)
+		for name, f := range t.Files {
+			if f.Deleted {
+				deleted[name] = true
+			}
+		}
+		if err := c.client.UpdateTorrent(t); err != nil {
+			return nil, fmt.Errorf("failed to update torrent: %w", err)
+		}
+		for name, f := range t.Files {
+			if deleted[name] {
+				f.Deleted = true
+				t.Files[name] = f
+			}
+		}
+	}
+
+	skipped := t.SkipFiles(paths)
+	ct.Torrent = t
+	c.setTorrent(ct, func(torrent CachedTorrent) {
+		c.listingDebouncer.Call(true)
+	})
+	return skipped, nil
+}
+
 func (c *Cache) Logger() zerolog.Logger {
 	return c.logger
 }
@@ -0,0 +1,490 @@
+package store
+
+import (
+	"container/list"
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/sirrobot01/decypharr/internal/config"
+	"github.com/sirrobot01/decypharr/internal/logger"
+	"github.com/sirrobot01/decypharr/pkg/debrid/types"
+	"golang.org/x/sync/singleflight"
+)
+
+// Size of the chunks the files are cached in
+const chunkSize = 4 * 1024 * 1024
+
+type chunkEntry struct {
+	path   string
+	size   int64
+	pinned bool
+}
+
+// chunkCache keeps the chunks streamed from the debrids on disk, evicting the least recently used past maxSize.
+// Chunks at the start and end of files are pinned: scans and players read them over and over,
+// they're only evicted once there are no other chunks left. Chunks are stored per debrid, torrent and file
+// so they can be dropped when the torrent changes
+type chunkCache struct {
+	dir     string
+	maxSize int64
+	pinSize int64
+	logger  zerolog.Logger
+
+	mu      sync.Mutex
+	size    int64
+	lru     *list.List // Unpinned chunks, the front is the most recently used
+	pinned  *list.List
+	entries map[string]*list.Element
+	fetches singleflight.Group
+}
+
+// The chunk cache is shared by every debrid, so its size cap covers all of them.
+// It's rebuilt when its config changes, e.g on a restart
+var (
+	sharedChunksMu   sync.Mutex
+	sharedChunks     *chunkCache
+	sharedChunksConf config.ChunkCache
+)
+
+// getChunkCache returns the chunk cache shared by the debrids, nil when it's disabled
+func getChunkCache(cfg *config.Config) *chunkCache {
+	sharedChunksMu.Lock()
+	defer sharedChunksMu.Unlock()
+	if cfg.ChunkCache == sharedChunksConf {
+		return sharedChunks
+	}
+	sharedChunks, sharedChunksConf = nil, cfg.ChunkCache
+	if cfg.ChunkCache.Size == "" {
+		return nil
+	}
+	_log := logger.New("chunk-cache")
+	maxSize, err := config.ParseSize(cfg.ChunkCache.Size)
+	if err != nil || maxSize <= 0 {
+		_log.Warn().Msgf("Invalid chunk cache size %s, chunks won't be cached", cfg.ChunkCache.Size)
+		return nil
+	}
+	pinSize, _ := config.ParseSize(cfg.ChunkCache.Pin)
+	sharedChunks = newChunkCache(filepath.Join(cfg.Path, "chunks"), maxSize, pinSize, _log)
+	return sharedChunks
+}
+
+func newChunkCache(dir string, maxSize, pinSize int64, logger zerolog.Logger) *chunkCache {
+	c := &chunkCache{
+		dir:     dir,
+		maxSize: maxSize,
+		pinSize: pinSize,
+		logger:  logger,
+		lru:     list.New(),
+		pinned:  list.New(),
+		entries: make(map[string]*list.Element),
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		logger.Error().Err(err).Msgf("Failed to create chunk cache folder %s", dir)
+	}
+	c.load()
+	return c
+}
+
+// load indexes the chunks left on disk by a previous run, oldest first
+func (c *chunkCache) load() {
+	type chunkFile struct {
+		entry   *chunkEntry
+		modTime time.Time
+	}
+	files := make([]chunkFile, 0)
+	_ = filepath.WalkDir(c.dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return nil
+		}
+		// Half written, or left by the layout before chunks were stored per torrent
+		if rel, _ := filepath.Rel(c.dir, path); strings.HasSuffix(path, ".tmp") || strings.Count(rel, string(filepath.Separator)) != 3 {
+			_ = os.Remove(path)
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+		files = append(files, chunkFile{
+			entry:   &chunkEntry{path: path, size: info.Size(), pinned: strings.HasSuffix(path, ".pin")},
+			modTime: info.ModTime(),
+		})
+		return nil
+	})
+	slices.SortFunc(files, func(a, b chunkFile) int {
+		return a.modTime.Compare(b.modTime)
+	})
+
+	c.mu.Lock()
+	for _, f := range files {
+		c.push(f.entry)
+	}
+	evicted := c.evict()
+	c.mu.Unlock()
+	c.remove(evicted)
+
+	if len(files) > 0 {
+		c.logger.Debug().Msgf("Loaded %d cached chunks", len(files))
+	}
+}
+
+func chunkKey(name string) string {
+	hash := sha1.Sum([]byte(name))
+	return hex.EncodeToString(hash[:])
+}
+
+// torrentDir is the folder of the torrent's chunks
+func (c *chunkCache) torrentDir(debrid, torrentName string) string {
+	key := chunkKey(torrentName)
+	return filepath.Join(c.dir, debrid, key[:2], key)
+}
+
+// filePrefix is what the paths of the file's chunks start with. The size is in the chunk names,
+// a file that changed size doesn't read the old chunks
+func (c *chunkCache) filePrefix(debrid, torrentName, filename string) string {
+	return filepath.Join(c.torrentDir(debrid, torrentName), chunkKey(filename)) + "-"
+}
+
+func (c *chunkCache) chunkPath(debrid, torrentName string, file types.File, index int64) string {
+	path := c.filePrefix(debrid, torrentName, file.Name) + strconv.FormatInt(file.Size, 10) + "-" + strconv.FormatInt(index, 10)
+	if c.isPinned(file, index) {
+		path += ".pin"
+	}
+	return path
+}
+
+func (c *chunkCache) isPinned(file types.File, index int64) bool {
+	start := index * chunkSize
+	return start < c.pinSize || start+chunkSize > file.Size-c.pinSize
+}
+
+// get returns the chunk from disk, or fetches and stores it. The fetch isn't cancelled with ctx,
+// other readers might be waiting for the same chunk
+func (c *chunkCache) get(ctx context.Context, debrid, torrentName string, file types.File, index int64, fetch func(ctx context.Context) ([]byte, error)) ([]byte, error) {
+	path := c.chunkPath(debrid, torrentName, file, index)
+	if data, ok := c.read(path); ok {
+		return data, nil
+	}
+
+	result := c.fetches.DoChan(path, func() (interface{}, error) {
+		if data, ok := c.read(path); ok {
+			return data, nil
+		}
+		data, err := fetch(context.WithoutCancel(ctx))
+		if err != nil {
+			return nil, err
+		}
+		c.write(path, data, c.isPinned(file, index))
+		return data, nil
+	})
+	select {
+	case res := <-result:
+		if res.Err != nil {
+			return nil, res.Err
+		}
+		return res.Val.([]byte), nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (c *chunkCache) has(path string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	_, ok := c.entries[path]
+	return ok
+}
+
+func (c *chunkCache) read(path string) ([]byte, bool) {
+	c.mu.Lock()
+	el, ok := c.entries[path]
+	if ok {
+		if el.Value.(*chunkEntry).pinned {
+			c.pinned.MoveToFront(el)
+		} else {
+			c.lru.MoveToFront(el)
+		}
+	}
+	c.mu.Unlock()
+	if !ok {
+		return nil, false
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		// Removed behind our back
+		c.mu.Lock()
+		c.drop(path)
+		c.mu.Unlock()
+		return nil, false
+	}
+	return data, true
+}
+
+func (c *chunkCache) write(path string, data []byte, pinned bool) {
+	if int64(len(data)) > c.maxSize {
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		c.logger.Error().Err(err).Msg("Failed to create chunk folder")
+		return
+	}
+	// Written to a temporary file first, readers never see half a chunk
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		c.logger.Error().Err(err).Msg("Failed to write chunk")
+		_ = os.Remove(tmp)
+		return
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		c.logger.Error().Err(err).Msg("Failed to write chunk")
+		_ = os.Remove(tmp)
+		return
+	}
+
+	c.mu.Lock()
+	c.drop(path)
+	c.push(&chunkEntry{path: path, size: int64(len(data)), pinned: pinned})
+	evicted := c.evict()
+	c.mu.Unlock()
+	c.remove(evicted)
+}
+
+// push must be called with mu held
+func (c *chunkCache) push(entry *chunkEntry) {
+	if entry.pinned {
+		c.entries[entry.path] = c.pinned.PushFront(entry)
+	} else {
+		c.entries[entry.path] = c.lru.PushFront(entry)
+	}
+	c.size += entry.size
+}
+
+// drop must be called with mu held
+func (c *chunkCache) drop(path string) {
+	el, ok := c.entries[path]
+	if !ok {
+		return
+	}
+	entry := el.Value.(*chunkEntry)
+	if entry.pinned {
+		c.pinned.Remove(el)
+	} else {
+		c.lru.Remove(el)
+	}
+	delete(c.entries, path)
+	c.size -= entry.size
+}
+
+// evict must be called with mu held. It returns the paths of the evicted chunks, they're removed with remove once mu is released
+func (c *chunkCache) evict() []string {
+	var evicted []string
+	for c.size > c.maxSize {
+		oldest := c.lru.Back()
+		if oldest == nil {
+			oldest = c.pinned.Back()
+		}
+		if oldest == nil {
+			break
+		}
+		path := oldest.Value.(*chunkEntry).path
+		c.drop(path)
+		evicted = append(evicted, path)
+	}
+	return evicted
+}
+
+// invalidate removes the chunks whose path starts with prefix
+func (c *chunkCache) invalidate(prefix string) {
+	c.mu.Lock()
+	removed := make([]string, 0)
+	for path := range c.entries {
+		if strings.HasPrefix(path, prefix) {
+			c.drop(path)
+			removed = append(removed, path)
+		}
+	}
+	c.mu.Unlock()
+	c.remove(removed)
+}
+
+func (c *chunkCache) remove(paths []string) {
+	for _, path := range paths {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			c.logger.Debug().Err(err).Msgf("Failed to remove chunk %s", path)
+		}
+	}
+}
+
+// invalidateChunks drops the cached chunks of the torrent's files, all of its files when none are given.
+// Called when the torrent is deleted or repaired, what the debrid serves for it changed
+func (c *Cache) invalidateChunks(torrentName string, filenames ...string) {
+	if c.chunks == nil {
+		return
+	}
+	if len(filenames) == 0 {
+		dir := c.chunks.torrentDir(c.config.Name, torrentName)
+		c.chunks.invalidate(dir + string(filepath.Separator))
+		_ = os.RemoveAll(dir)
+		return
+	}
+	for _, filename := range filenames {
+		c.chunks.invalidate(c.chunks.filePrefix(c.config.Name, torrentName, filename))
+	}
+}
+
+// ChunkCacheEnabled reports whether streamed chunks are cached on disk
+func (c *Cache) ChunkCacheEnabled() bool {
+	return c.chunks != nil
+}
+
+// StreamFile streams start-end(inclusive) of a file of the torrent. It reads through the chunk cache
+// when it's enabled, otherwise straight from the debrid
+func (c *Cache) StreamFile(ctx context.Context, torrentName string, file types.File, start, end int64) (io.ReadCloser, error) {
+	if c.chunks == nil {
+		return c.streamRange(ctx, torrentName, file, start, end)
+	}
+	return &chunkReader{ctx: ctx, cache: c, torrentName: torrentName, file: file, pos: start, end: end}, nil
+}
+
+// PrefetchFile fetches the chunks of start-end(inclusive) of a file into the chunk cache in the background,
+// e.g ahead of sequential reads. It does nothing when the chunk cache is disabled
+func (c *Cache) PrefetchFile(ctx context.Context, torrentName string, file types.File, start, end int64) {
+	if c.chunks == nil {
+		return
+	}
+	end = min(end, file.Size-1)
+	for index := start / chunkSize; index*chunkSize <= end; index++ {
+		if c.chunks.has(c.chunks.chunkPath(c.config.Name, torrentName, file, index)) {
+			continue
+		}
+		go func() {
+			_, _ = c.readChunk(ctx, torrentName, file, index)
+		}()
+	}
+}
+
+// ReadFileStart reads up to the first n bytes of a file for liveness checks. The debrid is always asked,
+// a cached start only shows the file was there when it was stored. A start that isn't cached yet is
+// fetched into the chunk cache, and the file's chunks are dropped once the debrid can't serve it
+func (c *Cache) ReadFileStart(ctx context.Context, torrentName string, file types.File, n int64) ([]byte, error) {
+	end := min(n, file.Size) - 1
+	var body io.ReadCloser
+	var err error
+	if c.chunks != nil && !c.chunks.has(c.chunks.chunkPath(c.config.Name, torrentName, file, 0)) {
+		body, err = c.StreamFile(ctx, torrentName, file, 0, end)
+	} else {
+		body, err = c.streamRange(ctx, torrentName, file, 0, end)
+	}
+	if err == nil {
+		defer func(body io.ReadCloser) {
+			_ = body.Close()
+		}(body)
+		data := make([]byte, end+1)
+		if _, err = io.ReadFull(body, data); err == nil {
+			return data, nil
+		}
+	}
+	if ctx.Err() == nil {
+		c.invalidateChunks(torrentName, file.Name)
+	}
+	return nil, err
+}
+
+// streamRange streams start-end(inclusive) of a file from the debrid
+func (c *Cache) streamRange(ctx context.Context, torrentName string, file types.File, start, end int64) (io.ReadCloser, error) {
+	if len(file.Parts) > 0 {
+		return c.StreamParts(ctx, torrentName, file.Parts, start, end), nil
+	}
+	// Files inside a RAR are at an offset of the link
+	if byteRange, _ := c.GetDownloadByteRange(torrentName, file.Name); byteRange != nil {
+		start += byteRange[0]
+		end += byteRange[0]
+	}
+	return c.StreamReader(ctx, start, end, func() (types.DownloadLink, error) {
+		downloadLink, err := c.GetDownloadLink(torrentName, file.Name, file.Link)
+		if err != nil {
+			return downloadLink, err
+		}
+		if err := downloadLink.Valid(); err != nil {
+			return types.DownloadLink{}, err
+		}
+		return downloadLink, nil
+	})
+}
+
+func (c *Cache) readChunk(ctx context.Context, torrentName string, file types.File, index int64) ([]byte, error) {
+	return c.chunks.get(ctx, c.config.Name, torrentName, file, index, func(ctx context.Context) ([]byte, error) {
+		start := index * chunkSize
+		end := min(start+chunkSize, file.Size) - 1
+		body, err := c.streamRange(ctx, torrentName, file, start, end)
+		if err != nil {
+			return nil, err
+		}
+		defer func(body io.ReadCloser) {
+			_ = body.Close()
+		}(body)
+
+		data := make([]byte, end-start+1)
+		if _, err := io.ReadFull(body, data); err != nil {
+			return nil, fmt.Errorf("failed to read chunk %d of %s: %w", index, file.Name, err)
+		}
+		return data, nil
+	})
+}
+
+// chunkReader reads a range of a file chunk by chunk, the next chunk is fetched while the current one is read
+type chunkReader struct {
+	ctx         context.Context
+	cache       *Cache
+	torrentName string
+	file        types.File
+	pos, end    int64 // Remaining range of the file to read
+	buf         []byte
+}
+
+func (r *chunkReader) Read(p []byte) (int, error) {
+	if len(r.buf) == 0 {
+		if r.pos > r.end || r.pos >= r.file.Size {
+			return 0, io.EOF
+		}
+		index := r.pos / chunkSize
+		data, err := r.cache.readChunk(r.ctx, r.torrentName, r.file, index)
+		if err != nil {
+			return 0, err
+		}
+		chunkStart := index * chunkSize
+		last := min(int64(len(data)), r.end-chunkStart+1)
+		if r.pos-chunkStart >= last {
+			return 0, io.ErrUnexpectedEOF
+		}
+		r.buf = data[r.pos-chunkStart : last]
+
+		if next := chunkStart + chunkSize; next <= r.end && next < r.file.Size {
+			go func() {
+				_, _ = r.cache.readChunk(r.ctx, r.torrentName, r.file, index+1)
+			}()
+		}
+	}
+	n := copy(p, r.buf)
+	r.buf = r.buf[n:]
+	r.pos += int64(n)
+	return n, nil
+}
+
+func (r *chunkReader) Close() error {
+	r.buf = nil
+	return nil
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"sync/atomic"
 
+	"github.com/sirrobot01/decypharr/internal/metrics"
 	"github.com/sirrobot01/decypharr/internal/utils"
 	"github.com/sirrobot01/decypharr/pkg/debrid/types"
 )
@@ -55,7 +56,14 @@ func (c *Cache) fetchDownloadLink(torrentName, filename, fileLink string) (types
 	if ct == nil {
 		return emptyDownloadLink, fmt.Errorf("torrent not found")
 	}
-	file, ok := ct.GetFile(filename)
+	getFile := func(ct *CachedTorrent) (types.File, bool) {
+		if file, ok := ct.GetFile(filename); ok {
+			return file, true
+		}
+		// Volumes of a split RAR are only kept as parts of the files inside them
+		return ct.GetFilePart(filename)
+	}
+	file, ok := getFile(ct)
 	if !ok {
 		return emptyDownloadLink, fmt.Errorf("file %s not found in torrent %s", filename, torrentName)
 	}
@@ -66,7 +74,7 @@ func (c *Cache) fetchDownloadLink(torrentName, filename, fileLink string) (types
 		if ct == nil {
 			return emptyDownloadLink, fmt.Errorf("failed to refresh torrent")
 		} else {
-			file, ok = ct.GetFile(filename)
+			file, ok = getFile(ct)
 			if !ok {
 				return emptyDownloadLink, fmt.Errorf("file %s not found in refreshed torrent %s", filename, torrentName)
 			}
@@ -81,7 +89,7 @@ func (c *Cache) fetchDownloadLink(torrentName, filename, fileLink string) (types
 			return emptyDownloadLink, fmt.Errorf("failed to reinsert torrent. %w", err)
 		}
 		ct = newCt
-		file, ok = ct.GetFile(filename)
+		file, ok = getFile(ct)
 		if !ok {
 			return emptyDownloadLink, fmt.Errorf("file %s not found in reinserted torrent %s", filename, torrentName)
 		}
@@ -102,7 +110,7 @@ func (c *Cache) fetchDownloadLink(torrentName, filename, fileLink string) (types
 				return emptyDownloadLink, fmt.Errorf("failed to reinsert torrent: %w", err)
 			}
 			ct = newCt
-			file, ok = ct.GetFile(filename)
+			file, ok = getFile(ct)
 			if !ok {
 				return emptyDownloadLink, fmt.Errorf("file %s not found in reinserted torrent %s", filename, torrentName)
 			}
@@ -158,6 +166,7 @@ func (c *Cache) MarkLinkAsInvalid(downloadLink types.DownloadLink, reason string
 	c.IncrementFailedLinkCounter(downloadLink.Link)
 
 	c.invalidDownloadLinks.Store(downloadLink.DownloadLink, reason)
+	metrics.IncInvalidLink(c.config.Name, reason)
 	// Remove the download api key from active
 	if reason == "bandwidth_exceeded" {
 		// Disable the account
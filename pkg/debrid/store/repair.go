@@ -4,6 +4,8 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"maps"
+	"slices"
 	"sync"
 	"time"
 
@@ -214,6 +216,11 @@ func (c *Cache) repairWorker(ctx context.Context) {
 	}
 }
 
+// ReInsertTorrent re-submits the torrent to the debrid and swaps it in the cache
+func (c *Cache) ReInsertTorrent(ct *CachedTorrent) (*CachedTorrent, error) {
+	return c.reInsertTorrent(ct)
+}
+
 func (c *Cache) reInsertTorrent(ct *CachedTorrent) (*CachedTorrent, error) {
 	// Check if Magnet is not empty, if empty, reconstruct the magnet
 	torrent := ct.Torrent
@@ -278,6 +285,11 @@ func (c *Cache) reInsertTorrent(ct *CachedTorrent) (*CachedTorrent, error) {
 			return ct, fmt.Errorf("failed to reinsert torrent: empty link")
 		}
 	}
+	// The chunks cached from the broken torrent are dropped, they may be what's broken
+	if len(torrent.Files) > 0 {
+		c.invalidateChunks(c.GetTorrentFolder(torrent), slices.Collect(maps.Keys(torrent.Files))...)
+	}
+
 	// Set torrent to newTorrent
 	newCt := CachedTorrent{
 		Torrent:    newTorrent,
@@ -152,6 +152,89 @@ func (c *Cache) StreamReader(ctx context.Context, start, end int64, linkFunc fun
 	return resp.Body, nil
 }
 
+// StreamParts streams start-end(inclusive) of a file split across parts, e.g the volumes of a split RAR.
+// Each part is only requested once the previous one has been read
+func (c *Cache) StreamParts(ctx context.Context, torrentName string, parts []types.FilePart, start, end int64) io.ReadCloser {
+	return &partsReader{ctx: ctx, cache: c, torrentName: torrentName, parts: parts, start: start, end: end}
+}
+
+type partsReader struct {
+	ctx         context.Context
+	cache       *Cache
+	torrentName string
+	parts       []types.FilePart
+	start, end  int64 // Remaining range of the file to read
+
+	index     int   // Current part
+	offset    int64 // Offset of the current part in the file
+	body      io.ReadCloser
+	remaining int64 // Bytes left to read from body
+}
+
+func (p *partsReader) Read(b []byte) (int, error) {
+	for {
+		if p.body == nil {
+			if err := p.next(); err != nil {
+				return 0, err
+			}
+		}
+		if p.remaining == 0 {
+			// Done with this part, the next one is opened on the next loop
+			_ = p.body.Close()
+			p.body = nil
+			continue
+		}
+		n, err := p.body.Read(b[:min(int64(len(b)), p.remaining)])
+		p.start += int64(n)
+		p.remaining -= int64(n)
+		if err == io.EOF {
+			if p.remaining > 0 {
+				return n, io.ErrUnexpectedEOF
+			}
+			err = nil
+		}
+		if n > 0 || err != nil {
+			return n, err
+		}
+	}
+}
+
+// next opens the part holding p.start
+func (p *partsReader) next() error {
+	for p.index < len(p.parts) && p.offset+p.parts[p.index].Size() <= p.start {
+		p.offset += p.parts[p.index].Size()
+		p.index++
+	}
+	if p.index >= len(p.parts) || p.start > p.end {
+		return io.EOF
+	}
+	part := p.parts[p.index]
+	from := part.ByteRange[0] + p.start - p.offset
+	to := part.ByteRange[0] + min(p.end, p.offset+part.Size()-1) - p.offset
+
+	linkFunc := func() (types.DownloadLink, error) {
+		dl, err := p.cache.GetDownloadLink(p.torrentName, part.Name, part.Link)
+		if err != nil {
+			return dl, err
+		}
+		return dl, dl.Valid()
+	}
+	body, err := p.cache.StreamReader(p.ctx, from, to, linkFunc)
+	if err != nil {
+		return fmt.Errorf("failed to stream %s: %w", part.Name, err)
+	}
+	p.body = body
+	p.remaining = to - from + 1
+	return nil
+}
+
+func (p *partsReader) Close() error {
+	if p.body != nil {
+		return p.body.Close()
+	}
+	return nil
+}
+
 func (c *Cache) doRequest(ctx context.Context, url string, start, end int64) (*http.Response, error) {
 	var lastErr error
 	// Retry loop specifically for connection-level failures (EOF, reset, etc.)
@@ -0,0 +1,100 @@
+package store
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirrobot01/decypharr/internal/config"
+	"github.com/sirrobot01/decypharr/internal/storage"
+)
+
+var (
+	ErrStreamExpired   = errors.New("stream url has expired")
+	ErrStreamSignature = errors.New("invalid stream signature")
+)
+
+var (
+	streamKeyOnce sync.Once
+	streamKey     []byte
+)
+
+// streamSecret is generated once and kept in the database, so signed urls survive restarts
+func streamSecret() []byte {
+	streamKeyOnce.Do(func() {
+		db := storage.Get()
+		key, err := db.Get("settings", "stream_key")
+		if err != nil || len(key) < 32 {
+			key = make([]byte, 32)
+			_, _ = rand.Read(key)
+			_ = db.Put("settings", "stream_key", key)
+		}
+		streamKey = key
+	})
+	return streamKey
+}
+
+// SignStream returns the signature of a single file, valid until expires(unix seconds)
+func SignStream(debrid, torrent, file string, expires int64) string {
+	mac := hmac.New(sha256.New, streamSecret())
+	mac.Write([]byte(debrid + "\x00" + torrent + "\x00" + file + "\x00" + strconv.FormatInt(expires, 10)))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func VerifyStream(debrid, torrent, file, expires, signature string) error {
+	exp, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil {
+		return ErrStreamSignature
+	}
+	expected := SignStream(debrid, torrent, file, exp)
+	if !hmac.Equal([]byte(expected), []byte(signature)) {
+		return ErrStreamSignature
+	}
+	if time.Now().Unix() > exp {
+		return ErrStreamExpired
+	}
+	return nil
+}
+
+func (c *Cache) streamTTL() time.Duration {
+	ttl, err := time.ParseDuration(c.config.StreamTokenTTL)
+	if err != nil || ttl <= 0 {
+		return 7 * 24 * time.Hour
+	}
+	return ttl
+}
+
+// baseURL is the address players reach decypharr at, with the url base
+func (c *Cache) baseURL() string {
+	cfg := config.Get()
+	base := c.config.StrmBaseURL
+	if base == "" {
+		host := cfg.BindAddress
+		if host == "" || host == "0.0.0.0" {
+			host = "localhost"
+		}
+		base = fmt.Sprintf("http://%s:%s", host, cfg.Port)
+	}
+	return strings.TrimRight(base, "/") + cfg.URLBase
+}
+
+// StreamURL returns a signed /stream url for a single file, valid for ttl(the configured one if 0)
+func (c *Cache) StreamURL(folder, file string, ttl time.Duration) (string, time.Time) {
+	if ttl <= 0 {
+		ttl = c.streamTTL()
+	}
+	expires := time.Now().Add(ttl)
+	q := url.Values{}
+	q.Set("exp", strconv.FormatInt(expires.Unix(), 10))
+	q.Set("sig", SignStream(c.config.Name, folder, file, expires.Unix()))
+	u := c.baseURL() + "stream/" + url.PathEscape(c.config.Name) + "/" + url.PathEscape(folder) + "/" + url.PathEscape(file) + "?" + q.Encode()
+	return u, expires
+}
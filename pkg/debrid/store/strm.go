@@ -0,0 +1,223 @@
+package store
+
+import (
+	"bytes"
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/sirrobot01/decypharr/internal/config"
+	"github.com/sirrobot01/decypharr/internal/utils"
+	"github.com/sirrobot01/decypharr/pkg/debrid/types"
+)
+
+// strmEntry tracks the .strm files written for a torrent, so they can follow renames and re-inserts
+type strmEntry struct {
+	InfoHash string            `json:"info_hash"`
+	Folder   string            `json:"folder"`           // webdav folder the urls point to
+	Files    map[string]string `json:"files"`            // .strm path => file name
+	Expires  time.Time         `json:"expires,omitzero"` // when the signed urls expire, zero for webdav urls
+}
+
+func (c *Cache) strmBucket() string {
+	return "strm:" + c.config.Name
+}
+
+func (c *Cache) loadStrms() {
+	err := c.db.ForEach(c.strmBucket(), func(key string, value []byte) error {
+		var e strmEntry
+		if err := json.Unmarshal(value, &e); err != nil {
+			return nil // skip bad entries
+		}
+		c.strms.Store(e.InfoHash, e)
+		return nil
+	})
+	if err != nil {
+		c.logger.Error().Err(err).Msg("Failed to load strm files")
+	}
+}
+
+func (c *Cache) saveStrm(e strmEntry) {
+	c.strms.Store(e.InfoHash, e)
+	data, err := json.Marshal(e)
+	if err != nil {
+		return
+	}
+	if err := c.db.Put(c.strmBucket(), e.InfoHash, data); err != nil {
+		c.logger.Error().Err(err).Msgf("Failed to save strm files of %s", e.InfoHash)
+	}
+}
+
+// removeStrms deletes the .strm files of a torrent that's gone, players would only get errors from them
+func (c *Cache) removeStrms(infohash string) {
+	infohash = strings.ToLower(infohash)
+	e, ok := c.strms.LoadAndDelete(infohash)
+	if !ok {
+		return
+	}
+	for strmPath := range e.Files {
+		if err := os.Remove(strmPath); err != nil && !os.IsNotExist(err) {
+			c.logger.Error().Err(err).Msgf("Failed to remove %s", strmPath)
+		}
+	}
+	if err := c.db.Delete(c.strmBucket(), infohash); err != nil {
+		c.logger.Error().Err(err).Msgf("Failed to remove strm files of %s", infohash)
+	}
+	c.logger.Debug().Msgf("Removed %d strm files of %s", len(e.Files), e.Folder)
+}
+
+// useStreamURLs reports whether .strm files get signed /stream urls. Players can't send the WebDAV credentials,
+// so they're used whenever WebDAV auth is on
+func (c *Cache) useStreamURLs() bool {
+	cfg := config.Get()
+	return c.config.StrmUseStream || (cfg.UseAuth && cfg.EnableWebdavAuth)
+}
+
+// StrmURL returns the url of a file as written in .strm files, and when it expires.
+// It's the webdav url, or a signed /stream url with StrmUseStream or WebDAV auth
+func (c *Cache) StrmURL(folder, file string) (string, time.Time) {
+	if c.useStreamURLs() {
+		return c.StreamURL(folder, file, 0)
+	}
+	return c.baseURL() + "webdav/" + url.PathEscape(c.config.Name) + "/__all__/" +
+		url.PathEscape(folder) + "/" + url.PathEscape(file), time.Time{}
+}
+
+// WriteStrms writes a .strm file per file of the torrent into dir, see StrmURL.
+// The torrent must already be in the cache
+func (c *Cache) WriteStrms(t *types.Torrent, dir string) (string, error) {
+	folder := c.GetTorrentFolder(t)
+	ct := c.GetTorrentByName(folder)
+	if ct == nil {
+		return "", fmt.Errorf("torrent %s is not in the %s webdav", t.Name, c.config.Name)
+	}
+	files := ct.GetFiles()
+	if len(files) == 0 {
+		return "", fmt.Errorf("no valid files found")
+	}
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		return "", fmt.Errorf("failed to create directory: %s: %v", dir, err)
+	}
+
+	e := strmEntry{
+		InfoHash: strings.ToLower(t.InfoHash),
+		Folder:   folder,
+		Files:    make(map[string]string, len(files)),
+	}
+	for _, f := range files {
+		strmPath := filepath.Join(dir, utils.RemoveExtension(f.Name)+".strm")
+		u, expires := c.StrmURL(folder, f.Name)
+		if err := writeStrm(strmPath, u); err != nil {
+			return "", err
+		}
+		e.Files[strmPath] = f.Name
+		e.Expires = expires
+	}
+	c.saveStrm(e)
+	c.logger.Info().Msgf("Created %d strm files for %s", len(files), t.Name)
+	return dir, nil
+}
+
+// updateStrms rewrites the .strm files of a torrent whose folder or files changed, e.g after a re-insert
+func (c *Cache) updateStrms(ct CachedTorrent) {
+	c.rewriteStrms(ct, false)
+}
+
+// rewriteStrms rewrites the .strm files of a torrent if anything changed, or always with force
+func (c *Cache) rewriteStrms(ct CachedTorrent, force bool) {
+	if ct.Torrent == nil {
+		return
+	}
+	e, ok := c.strms.Load(strings.ToLower(ct.InfoHash))
+	if !ok {
+		return
+	}
+	folder := c.GetTorrentFolder(ct.Torrent)
+	files := ct.GetFiles()
+	if len(files) == 0 {
+		return
+	}
+	names := make(map[string]struct{}, len(files))
+	for _, f := range files {
+		names[f.Name] = struct{}{}
+	}
+
+	changed := force || folder != e.Folder
+	updated := strmEntry{InfoHash: e.InfoHash, Folder: folder, Files: make(map[string]string, len(e.Files))}
+	for strmPath, name := range e.Files {
+		if _, exists := names[name]; !exists {
+			if len(files) != 1 || len(e.Files) != 1 {
+				// The file was removed, e.g by a repair. The .strm is dead, without it the arr sees the file missing
+				if err := os.Remove(strmPath); err != nil && !os.IsNotExist(err) {
+					c.logger.Error().Err(err).Msgf("Failed to remove %s", strmPath)
+				}
+				changed = true
+				continue
+			}
+			// Single file torrent, the file was renamed
+			name = files[0].Name
+			changed = true
+		}
+		updated.Files[strmPath] = name
+	}
+	if !changed {
+		return
+	}
+	if len(updated.Files) == 0 {
+		c.removeStrms(e.InfoHash)
+		return
+	}
+	for strmPath, name := range updated.Files {
+		u, expires := c.StrmURL(folder, name)
+		if err := writeStrm(strmPath, u); err != nil {
+			c.logger.Error().Err(err).Msgf("Failed to update %s", strmPath)
+		}
+		updated.Expires = expires
+	}
+	c.saveStrm(updated)
+	c.logger.Debug().Msgf("Updated %d strm files for %s", len(updated.Files), cmp.Or(ct.Name, folder))
+}
+
+// resignStrms rewrites the .strm files whose signed urls are past half their lifetime, so players never get an expired one.
+// It also switches the files over when StrmUseStream or WebDAV auth is toggled
+func (c *Cache) resignStrms() {
+	ttl := c.streamTTL()
+	useStream := c.useStreamURLs()
+	count := 0
+	c.strms.Range(func(infohash string, e strmEntry) bool {
+		signed := !e.Expires.IsZero()
+		if signed == useStream && (!signed || time.Until(e.Expires) > ttl/2) {
+			return true
+		}
+		if ct := c.GetTorrentByName(e.Folder); ct != nil && strings.EqualFold(ct.InfoHash, infohash) {
+			c.rewriteStrms(*ct, true)
+			count++
+		}
+		return true
+	})
+	if count > 0 {
+		c.logger.Debug().Msgf("Re-signed strm files of %d torrents", count)
+	}
+}
+
+// writeStrm writes the url if it changed, through a temp file so players never read a partial one
+func writeStrm(path, u string) error {
+	data := []byte(u + "\n")
+	if existing, err := os.ReadFile(path); err == nil && bytes.Equal(existing, data) {
+		return nil
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("failed to write strm file: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		_ = os.Remove(tmp)
+		return fmt.Errorf("failed to write strm file: %w", err)
+	}
+	return nil
+}
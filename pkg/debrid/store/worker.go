@@ -2,6 +2,7 @@ package store
 
 import (
 	"context"
+	"time"
 
 	"github.com/go-co-op/gocron/v2"
 	"github.com/sirrobot01/decypharr/internal/utils"
@@ -57,6 +58,11 @@ func (c *Cache) StartWorker(ctx context.Context) error {
 		}
 	}
 
+	// Re-sign the .strm files before their /stream urls expire
+	if _, err := c.scheduler.NewJob(gocron.DurationJob(time.Hour), gocron.NewTask(c.resignStrms), gocron.WithContext(ctx)); err != nil {
+		c.logger.Error().Err(err).Msg("Failed to create strm re-sign job")
+	}
+
 	// Start the scheduler
 	c.scheduler.Start()
 	c.cetScheduler.Start()
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
 	"sync"
 	"time"
 
@@ -31,9 +32,14 @@ type Torrent struct {
 	Links            []string        `json:"links"`
 	MountPath        string          `json:"mount_path"`
 	DeletedFiles     []string        `json:"deleted_files"`
+	SkippedFiles     []string        `json:"skipped_files,omitempty"` // Names of the files deselected through their priority
 
 	Debrid string `json:"debrid"`
 
+	// Why this debrid was picked, see debrid.Process
+	SelectionPolicy string `json:"selection_policy,omitempty"`
+	SelectionReason string `json:"selection_reason,omitempty"`
+
 	Arr *arr.Arr `json:"arr"`
 
 	SizeDownloaded   int64 `json:"-"` // This is used for local download
@@ -68,8 +74,11 @@ func (t *Torrent) Copy() *Torrent {
 		Speed:            t.Speed,
 		Seeders:          t.Seeders,
 		Links:            append([]string{}, t.Links...),
+		SkippedFiles:     append([]string{}, t.SkippedFiles...),
 		MountPath:        t.MountPath,
 		Debrid:           t.Debrid,
+		SelectionPolicy:  t.SelectionPolicy,
+		SelectionReason:  t.SelectionReason,
 		Arr:              t.Arr,
 	}
 }
@@ -102,19 +111,53 @@ func (t *Torrent) GetFile(filename string) (File, bool) {
 	if !ok {
 		return File{}, false
 	}
-	return f, !f.Deleted
+	return f, !f.Deleted && !t.isSkipped(filename)
+}
+
+// GetFilePart returns the file holding a part of another file, e.g a volume of a split RAR.
+// The volumes aren't in Files themselves, only the files inside them are
+func (t *Torrent) GetFilePart(name string) (File, bool) {
+	for _, f := range t.Files {
+		if f.Deleted || t.isSkipped(f.Name) {
+			continue
+		}
+		for _, p := range f.Parts {
+			if p.Name == name {
+				return File{TorrentId: f.TorrentId, Name: p.Name, Path: p.Name, Link: p.Link, Generated: f.Generated}, true
+			}
+		}
+	}
+	return File{}, false
 }
 
 func (t *Torrent) GetFiles() []File {
 	files := make([]File, 0, len(t.Files))
 	for _, f := range t.Files {
-		if !f.Deleted {
+		if !f.Deleted && !t.isSkipped(f.Name) {
 			files = append(files, f)
 		}
 	}
 	return files
 }
 
+// SkipFiles skips the files with the paths, the other files are selected again.
+// Skipped files are left out of GetFile and GetFiles. It returns the names of the skipped files
+func (t *Torrent) SkipFiles(paths []string) []string {
+	skipped := make([]string, 0, len(paths))
+	for name, f := range t.Files {
+		if slices.Contains(paths, f.Path) {
+			skipped = append(skipped, name)
+		}
+	}
+	slices.Sort(skipped)
+	t.SkippedFiles = skipped
+	return skipped
+}
+
+func (t *Torrent) isSkipped(name string) bool {
+	return slices.Contains(t.SkippedFiles, name)
+}
+
 type File struct {
 	TorrentId    string       `json:"torrent_id"`
 	Id           string       `json:"id"`
@@ -122,6 +165,7 @@ type File struct {
 	Size         int64        `json:"size"`
 	IsRar        bool         `json:"is_rar"`
 	ByteRange    *[2]int64    `json:"byte_range,omitempty"`
+	Parts        []FilePart   `json:"parts,omitempty"`
 	Path         string       `json:"path"`
 	Link         string       `json:"link"`
 	AccountId    string       `json:"account_id"`
@@ -130,6 +174,19 @@ type File struct {
 	DownloadLink DownloadLink `json:"-"`
 }
 
+// FilePart is a piece of a file that's stored in another file of the torrent, e.g a volume of a split RAR
+type FilePart struct {
+	Name         string       `json:"name"` // Name of the file holding the piece
+	Link         string       `json:"link"`
+	ByteRange    [2]int64     `json:"byte_range"` // Where the piece is in that file, inclusive
+	DownloadLink DownloadLink `json:"-"`
+}
+
+// Size returns the size of the piece
+func (p FilePart) Size() int64 {
+	return p.ByteRange[1] - p.ByteRange[0] + 1
+}
+
 func (t *Torrent) Cleanup(remove bool) {
 	if remove {
 		err := os.Remove(t.Filename)
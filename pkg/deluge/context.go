@@ -0,0 +1,67 @@
+package deluge
+
+import (
+	"crypto/rand"
+	"net/http"
+	"time"
+
+	"github.com/sirrobot01/decypharr/internal/config"
+)
+
+const sessionCookie = "_session_id"
+
+// Sessions expire after being unused for this long, like Deluge's session_timeout
+const sessionTimeout = time.Hour
+
+// login checks the auth.login password and starts a session.
+// With auth enabled the password is decypharr's password or API token, the Deluge api has no username
+func (d *Deluge) login(w http.ResponseWriter, password string) bool {
+	cfg := config.Get()
+	if cfg.UseAuth {
+		auth := cfg.GetAuth()
+		if password == "" || (password != auth.APIToken && !config.VerifyAuth(auth.Username, password)) {
+			return false
+		}
+	}
+	d.pruneSessions()
+	sessionId := rand.Text()
+	d.sessions.Store(sessionId, time.Now())
+	http.SetCookie(w, &http.Cookie{
+		Name:     sessionCookie,
+		Value:    sessionId,
+		Path:     "/",
+		HttpOnly: true,
+	})
+	return true
+}
+
+// authenticated checks the session cookie of the request, every call but auth.login needs one when auth is enabled
+func (d *Deluge) authenticated(r *http.Request) bool {
+	if !config.Get().UseAuth {
+		return true
+	}
+	cookie, err := r.Cookie(sessionCookie)
+	if err != nil {
+		return false
+	}
+	lastUsed, ok := d.sessions.Load(cookie.Value)
+	if !ok {
+		return false
+	}
+	if time.Since(lastUsed) > sessionTimeout {
+		d.sessions.Delete(cookie.Value)
+		return false
+	}
+	d.sessions.Store(cookie.Value, time.Now())
+	return true
+}
+
+// pruneSessions forgets the expired sessions, clients that never log out would pile them up
+func (d *Deluge) pruneSessions() {
+	d.sessions.Range(func(id string, lastUsed time.Time) bool {
+		if time.Since(lastUsed) > sessionTimeout {
+			d.sessions.Delete(id)
+		}
+		return true
+	})
+}
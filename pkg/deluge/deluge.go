@@ -0,0 +1,39 @@
+package deluge
+
+import (
+	"sync"
+	"time"
+
+	"github.com/puzpuzpuz/xsync/v4"
+	"github.com/rs/zerolog"
+	"github.com/sirrobot01/decypharr/internal/config"
+	"github.com/sirrobot01/decypharr/internal/logger"
+	"github.com/sirrobot01/decypharr/pkg/wire"
+)
+
+// Deluge emulates enough of the Deluge Web JSON-RPC for the arrs and other tools to use decypharr as a Deluge client.
+// It shares the download folder, categories and torrent storage with the qbittorrent API, categories map to labels
+type Deluge struct {
+	DownloadFolder string
+	Categories     []string
+	storage        *wire.TorrentStorage
+	logger         zerolog.Logger
+	sessions       *xsync.Map[string, time.Time] // Logged in session ids
+
+	mu      sync.Mutex
+	labels  []string                   // Labels created through label.add
+	pending map[string]*pendingTorrent // Torrents waiting for their label, keyed by hash
+}
+
+func New() *Deluge {
+	_cfg := config.Get()
+	cfg := _cfg.QBitTorrent
+	return &Deluge{
+		DownloadFolder: cfg.DownloadFolder,
+		Categories:     cfg.Categories,
+		storage:        wire.Get().Torrents(),
+		logger:         logger.New("deluge"),
+		sessions:       xsync.NewMap[string, time.Time](),
+		pending:        make(map[string]*pendingTorrent),
+	}
+}
@@ -0,0 +1,253 @@
+package deluge
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/sirrobot01/decypharr/internal/request"
+	"github.com/sirrobot01/decypharr/internal/utils"
+)
+
+const (
+	version = "2.1.1"
+	hostId  = "decypharr"
+)
+
+func (d *Deluge) handleJSON(w http.ResponseWriter, r *http.Request) {
+	var req Request
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	var (
+		result any
+		err    error
+	)
+	if req.Method != "auth.login" && req.Method != "auth.check_session" && !d.authenticated(r) {
+		err = &Error{Message: "Not authenticated", Code: errorNotAuthenticated}
+	} else {
+		result, err = d.call(w, r, req)
+	}
+
+	res := Response{Result: result, Id: req.Id}
+	if err != nil {
+		d.logger.Debug().Err(err).Str("method", req.Method).Msg("RPC call failed")
+		var rpcErr *Error
+		if !errors.As(err, &rpcErr) {
+			rpcErr = &Error{Message: err.Error(), Code: errorCall}
+		}
+		res.Result = nil
+		res.Error = rpcErr
+	}
+	request.JSONResponse(w, res, http.StatusOK)
+}
+
+// param decodes the i-th param into v, a missing param leaves v untouched
+func param(params []json.RawMessage, i int, v any) error {
+	if i >= len(params) {
+		return nil
+	}
+	if err := json.Unmarshal(params[i], v); err != nil {
+		return fmt.Errorf("invalid param %d: %w", i, err)
+	}
+	return nil
+}
+
+func (d *Deluge) call(w http.ResponseWriter, r *http.Request, req Request) (any, error) {
+	ctx := r.Context()
+	params := req.Params
+	switch req.Method {
+	case "auth.login":
+		var password string
+		if err := param(params, 0, &password); err != nil {
+			return nil, err
+		}
+		return d.login(w, password), nil
+	case "auth.check_session":
+		return d.authenticated(r), nil
+	case "auth.delete_session":
+		if cookie, err := r.Cookie(sessionCookie); err == nil {
+			d.sessions.Delete(cookie.Value)
+		}
+		return true, nil
+
+	case "web.connected", "web.disconnect":
+		return true, nil
+	case "web.get_hosts":
+		return [][]any{{hostId, "127.0.0.1", 58846, "localclient"}}, nil
+	case "web.get_host_status":
+		return []any{hostId, "Connected", version}, nil
+	case "web.connect":
+		return []string{}, nil
+	case "web.update_ui":
+		var keys []string
+		var filter map[string]any
+		if err := param(params, 0, &keys); err != nil {
+			return nil, err
+		}
+		if err := param(params, 1, &filter); err != nil {
+			return nil, err
+		}
+		return d.updateUI(keys, filter), nil
+
+	case "daemon.info", "daemon.get_version":
+		return version, nil
+	case "core.get_libtorrent_version":
+		return "2.0.9.0", nil
+	case "core.get_config":
+		return d.config(), nil
+	case "core.get_config_value":
+		var key string
+		if err := param(params, 0, &key); err != nil {
+			return nil, err
+		}
+		return d.config()[key], nil
+	case "core.get_config_values":
+		var keys []string
+		if err := param(params, 0, &keys); err != nil {
+			return nil, err
+		}
+		return selectFields(d.config(), keys), nil
+	case "core.get_enabled_plugins", "core.get_available_plugins":
+		return []string{"Label"}, nil
+	case "core.enable_plugin", "core.disable_plugin":
+		return true, nil
+
+	case "label.get_labels":
+		return d.getLabels(), nil
+	case "label.add":
+		var label string
+		if err := param(params, 0, &label); err != nil {
+			return nil, err
+		}
+		d.addLabel(strings.ToLower(strings.TrimSpace(label)))
+		return nil, nil
+	case "label.set_torrent":
+		var hash, label string
+		if err := param(params, 0, &hash); err != nil {
+			return nil, err
+		}
+		if err := param(params, 1, &label); err != nil {
+			return nil, err
+		}
+		return nil, d.setLabel(ctx, hash, strings.TrimSpace(label))
+	case "label.get_options", "label.get_config":
+		return map[string]any{}, nil
+
+	case "core.add_torrent_magnet", "core.add_torrent_url":
+		var uri string
+		if err := param(params, 0, &uri); err != nil {
+			return nil, err
+		}
+		magnet, err := utils.GetMagnetFromUrl(strings.TrimSpace(uri))
+		if err != nil {
+			return nil, fmt.Errorf("error parsing magnet link: %w", err)
+		}
+		return d.addPending(magnet), nil
+	case "core.add_torrent_file":
+		var filedump string
+		if err := param(params, 1, &filedump); err != nil {
+			return nil, err
+		}
+		data, err := base64.StdEncoding.DecodeString(filedump)
+		if err != nil {
+			return nil, fmt.Errorf("invalid torrent file: %w", err)
+		}
+		magnet, err := utils.GetMagnetFromBytes(data)
+		if err != nil {
+			return nil, fmt.Errorf("error reading torrent file: %w", err)
+		}
+		return d.addPending(magnet), nil
+
+	case "core.get_torrents_status":
+		var filter map[string]any
+		var keys []string
+		if err := param(params, 0, &filter); err != nil {
+			return nil, err
+		}
+		if err := param(params, 1, &keys); err != nil {
+			return nil, err
+		}
+		return d.torrentsStatus(filter, keys), nil
+	case "core.get_torrent_status":
+		var hash string
+		var keys []string
+		if err := param(params, 0, &hash); err != nil {
+			return nil, err
+		}
+		if err := param(params, 1, &keys); err != nil {
+			return nil, err
+		}
+		t := d.storage.Get(strings.ToLower(hash), "")
+		if t == nil {
+			return map[string]any{}, nil
+		}
+		return selectFields(torrentFields(t), keys), nil
+
+	case "core.remove_torrent":
+		var hash string
+		if err := param(params, 0, &hash); err != nil {
+			return nil, err
+		}
+		t := d.storage.Get(strings.ToLower(hash), "")
+		if t == nil {
+			return nil, fmt.Errorf("torrent %s not found", hash)
+		}
+		d.storage.Delete(t.Hash, t.Category, false)
+		return true, nil
+	case "core.remove_torrents":
+		var hashes []string
+		if err := param(params, 0, &hashes); err != nil {
+			return nil, err
+		}
+		for _, hash := range hashes {
+			if t := d.storage.Get(strings.ToLower(hash), ""); t != nil {
+				d.storage.Delete(t.Hash, t.Category, false)
+			}
+		}
+		return []any{}, nil
+
+	case "core.pause_torrent", "core.pause_torrents", "core.resume_torrent", "core.resume_torrents",
+		"core.set_torrent_options", "core.force_recheck", "core.force_reannounce", "core.set_config",
+		"core.queue_top", "core.queue_up", "core.queue_down", "core.queue_bottom", "label.set_options", "label.set_config":
+		// Nothing to do for torrents on a debrid
+		return nil, nil
+	default:
+		return nil, &Error{Message: "Unknown method", Code: errorUnknownMethod}
+	}
+}
+
+func (d *Deluge) updateUI(keys []string, filter map[string]any) UpdateUI {
+	stats := Stats{MaxDownload: -1, MaxUpload: -1, MaxNumConnections: -1}
+	for _, t := range d.torrents() {
+		stats.DownloadRate += t.Dlspeed
+		stats.UploadRate += t.Upspeed
+	}
+	return UpdateUI{
+		Connected: true,
+		Torrents:  d.torrentsStatus(filter, keys),
+		Filters:   map[string]any{},
+		Stats:     stats,
+	}
+}
+
+func (d *Deluge) config() map[string]any {
+	return map[string]any{
+		"download_location":      d.DownloadFolder,
+		"move_completed":         false,
+		"move_completed_path":    d.DownloadFolder,
+		"torrentfiles_location":  d.DownloadFolder,
+		"add_paused":             false,
+		"stop_seed_at_ratio":     true,
+		"stop_seed_ratio":        1.0,
+		"remove_seed_at_ratio":   false,
+		"max_active_downloading": -1,
+		"max_active_seeding":     -1,
+		"max_active_limit":       -1,
+	}
+}
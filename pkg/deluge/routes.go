@@ -0,0 +1,13 @@
+package deluge
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func (d *Deluge) Routes() http.Handler {
+	r := chi.NewRouter()
+	r.Post("/json", d.handleJSON)
+	return r
+}
@@ -0,0 +1,236 @@
+package deluge
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/sirrobot01/decypharr/internal/utils"
+	"github.com/sirrobot01/decypharr/pkg/arr"
+	"github.com/sirrobot01/decypharr/pkg/wire"
+)
+
+// How long an added torrent waits for label.set_torrent before it's processed without a label.
+// The arrs add the torrent first and label it right after
+const labelTimeout = 10 * time.Second
+
+type pendingTorrent struct {
+	magnet *utils.Magnet
+	timer  *time.Timer
+}
+
+// All torrent-related helpers goes here
+
+// addPending holds the added torrent until it's labelled, the label picks the arr and folder it's processed for
+func (d *Deluge) addPending(magnet *utils.Magnet) string {
+	hash := strings.ToLower(magnet.InfoHash)
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if _, ok := d.pending[hash]; ok || d.storage.Get(hash, "") != nil {
+		return hash
+	}
+	d.pending[hash] = &pendingTorrent{
+		magnet: magnet,
+		timer: time.AfterFunc(labelTimeout, func() {
+			if _, err := d.start(context.Background(), hash, ""); err != nil {
+				d.logger.Error().Err(err).Str("hash", hash).Msg("Failed to add torrent")
+			}
+		}),
+	}
+	return hash
+}
+
+// start processes the pending torrent for the label. It reports whether the torrent was pending
+func (d *Deluge) start(ctx context.Context, hash, label string) (bool, error) {
+	d.mu.Lock()
+	p, ok := d.pending[hash]
+	if ok {
+		p.timer.Stop()
+		delete(d.pending, hash)
+	}
+	d.mu.Unlock()
+	if !ok {
+		return false, nil
+	}
+
+	_arr := wire.Get().Arr().Get(label)
+	if _arr == nil {
+		// Arr is not configured
+		_arr = arr.New(label, "", "", false, false, nil, "", "")
+	}
+	importReq := wire.NewImportRequest("", d.DownloadFolder, p.magnet, _arr, wire.ImportAction(_arr, ""), false, "", wire.ImportTypeDeluge, false)
+	if err := wire.Get().AddTorrent(ctx, importReq); err != nil {
+		return true, fmt.Errorf("failed to process torrent: %w", err)
+	}
+	return true, nil
+}
+
+// setLabel labels a torrent. Only torrents that are still pending can be labelled, the others are already in their category
+func (d *Deluge) setLabel(ctx context.Context, hash, label string) error {
+	hash = strings.ToLower(hash)
+	pending, err := d.start(ctx, hash, label)
+	if pending {
+		return err
+	}
+	t := d.storage.Get(hash, "")
+	if t == nil {
+		return fmt.Errorf("unknown torrent %s", hash)
+	}
+	if t.Category != label {
+		d.logger.Debug().Str("hash", hash).Msgf("Torrent is already in category %s, not moving it to %s", t.Category, label)
+	}
+	return nil
+}
+
+func (d *Deluge) addLabel(label string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if !slices.Contains(d.labels, label) {
+		d.labels = append(d.labels, label)
+	}
+}
+
+// getLabels returns the categories, the labels created through label.add and the categories of the torrents
+func (d *Deluge) getLabels() []string {
+	d.mu.Lock()
+	labels := slices.Concat(d.Categories, d.labels)
+	d.mu.Unlock()
+	for _, t := range d.torrents() {
+		labels = append(labels, t.Category)
+	}
+	labels = utils.RemoveItem(labels, "")
+	slices.Sort(labels)
+	return slices.Compact(labels)
+}
+
+// torrents returns the torrents, oldest first
+func (d *Deluge) torrents() []*wire.Torrent {
+	return d.storage.GetTorrents("", "", nil, "added_on", true)
+}
+
+// torrentsStatus returns the fields of the torrents matching the filter, keyed by hash
+func (d *Deluge) torrentsStatus(filter map[string]any, keys []string) map[string]map[string]any {
+	torrents := make(map[string]map[string]any)
+	for _, t := range d.torrents() {
+		if matchFilter(t, filter) {
+			torrents[t.Hash] = selectFields(torrentFields(t), keys)
+		}
+	}
+	return torrents
+}
+
+// matchFilter matches the label, id and state filters of core.get_torrents_status and web.update_ui
+func matchFilter(t *wire.Torrent, filter map[string]any) bool {
+	for key, value := range filter {
+		switch key {
+		case "label":
+			if label, _ := value.(string); t.Category != label {
+				return false
+			}
+		case "id":
+			switch ids := value.(type) {
+			case string:
+				if !strings.EqualFold(t.Hash, ids) {
+					return false
+				}
+			case []any:
+				if !slices.ContainsFunc(ids, func(id any) bool {
+					hash, _ := id.(string)
+					return strings.EqualFold(t.Hash, hash)
+				}) {
+					return false
+				}
+			}
+		case "state":
+			if state, _ := value.(string); state != "" && state != "All" && torrentState(t) != state {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// selectFields keeps the requested keys, all fields are returned without keys
+func selectFields(fields map[string]any, keys []string) map[string]any {
+	if len(keys) == 0 {
+		return fields
+	}
+	selected := make(map[string]any, len(keys))
+	for _, key := range keys {
+		if value, ok := fields[key]; ok {
+			selected[key] = value
+		}
+	}
+	return selected
+}
+
+func torrentState(t *wire.Torrent) string {
+	switch t.State {
+	case "pausedUP":
+		return "Paused"
+	case "error":
+		return "Error"
+	case "queued":
+		return "Queued"
+	default:
+		return "Downloading"
+	}
+}
+
+// torrentFields returns all the supported fields of a torrent, keyed by their Deluge name
+func torrentFields(t *wire.Torrent) map[string]any {
+	finished := t.State == "pausedUP"
+	message := "OK"
+	if t.State == "error" {
+		message = "Failed to process torrent on debrid"
+	}
+	totalDone, eta, ratio := t.Completed, t.Eta, 0.0
+	if finished {
+		totalDone, eta, ratio = t.Size, 0, 1
+	}
+
+	// The content path is the symlink folder, its name can differ from the torrent name
+	name := t.Name
+	savePath := strings.TrimSuffix(t.SavePath, string(os.PathSeparator))
+	if t.ContentPath != "" {
+		name = filepath.Base(t.ContentPath)
+		savePath = filepath.Dir(t.ContentPath)
+	}
+
+	return map[string]any{
+		"hash":                  t.Hash,
+		"name":                  name,
+		"state":                 torrentState(t),
+		"message":               message,
+		"progress":              t.Progress * 100,
+		"eta":                   eta,
+		"is_finished":           finished,
+		"is_seed":               finished,
+		"save_path":             savePath,
+		"download_location":     savePath,
+		"total_size":            t.Size,
+		"total_wanted":          t.Size,
+		"total_done":            totalDone,
+		"total_uploaded":        t.Uploaded,
+		"time_added":            t.AddedOn,
+		"active_time":           0,
+		"seeding_time":          0,
+		"ratio":                 ratio,
+		"is_auto_managed":       true,
+		"stop_at_ratio":         true,
+		"stop_ratio":            1.0,
+		"remove_at_ratio":       false,
+		"download_payload_rate": t.Dlspeed,
+		"upload_payload_rate":   t.Upspeed,
+		"num_seeds":             t.NumSeeds,
+		"num_peers":             0,
+		"num_files":             len(t.Files),
+		"queue":                 -1,
+		"label":                 t.Category,
+		"tracker_host":          "",
+	}
+}
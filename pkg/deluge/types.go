@@ -0,0 +1,51 @@
+package deluge
+
+import "encoding/json"
+
+// Error codes of the Deluge web json api
+const (
+	errorNotAuthenticated = 1
+	errorUnknownMethod    = 2
+	errorCall             = 3
+)
+
+type Request struct {
+	Method string            `json:"method"`
+	Params []json.RawMessage `json:"params"`
+	Id     any               `json:"id"`
+}
+
+type Response struct {
+	Result any    `json:"result"`
+	Error  *Error `json:"error"`
+	Id     any    `json:"id"`
+}
+
+type Error struct {
+	Message string `json:"message"`
+	Code    int    `json:"code"`
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+type UpdateUI struct {
+	Connected bool                      `json:"connected"`
+	Torrents  map[string]map[string]any `json:"torrents"`
+	Filters   map[string]any            `json:"filters"`
+	Stats     Stats                     `json:"stats"`
+}
+
+type Stats struct {
+	MaxDownload            int    `json:"max_download"`
+	MaxUpload              int    `json:"max_upload"`
+	MaxNumConnections      int    `json:"max_num_connections"`
+	NumConnections         int    `json:"num_connections"`
+	UploadRate             int64  `json:"upload_rate"`
+	DownloadRate           int64  `json:"download_rate"`
+	FreeSpace              int64  `json:"free_space"`
+	ExternalIP             string `json:"external_ip"`
+	DhtNodes               int    `json:"dht_nodes"`
+	HasIncomingConnections bool   `json:"has_incoming_connections"`
+}
@@ -0,0 +1,256 @@
+//go:build linux
+
+package fuse
+
+import (
+	"context"
+	"hash/fnv"
+	"os"
+	"path"
+	"slices"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/hanwen/go-fuse/v2/fs"
+	gofuse "github.com/hanwen/go-fuse/v2/fuse"
+	"github.com/sirrobot01/decypharr/pkg/debrid/store"
+	"github.com/sirrobot01/decypharr/pkg/debrid/types"
+	"github.com/sirrobot01/decypharr/pkg/version"
+)
+
+// The tree is the same as the WebDAV's: parent folders, torrent folders and their files.
+// Nodes only keep their path, what they serve is looked up in the cache on every call
+
+// node is what every node shares. ctx is the mount's, chunks are fetched with it
+type node struct {
+	fs.Inode
+	mount *Mount
+	ctx   context.Context
+}
+
+type rootNode struct {
+	node
+}
+
+type folderNode struct {
+	node
+	folder string
+}
+
+type torrentNode struct {
+	node
+	folder      string
+	torrentName string
+}
+
+type fileNode struct {
+	node
+	torrentName string
+	filename    string
+}
+
+var (
+	_ = (fs.NodeReaddirer)((*rootNode)(nil))
+	_ = (fs.NodeLookuper)((*rootNode)(nil))
+	_ = (fs.NodeReaddirer)((*folderNode)(nil))
+	_ = (fs.NodeLookuper)((*folderNode)(nil))
+	_ = (fs.NodeRmdirer)((*folderNode)(nil))
+	_ = (fs.NodeReaddirer)((*torrentNode)(nil))
+	_ = (fs.NodeLookuper)((*torrentNode)(nil))
+	_ = (fs.NodeUnlinker)((*torrentNode)(nil))
+	_ = (fs.NodeGetattrer)((*fileNode)(nil))
+	_ = (fs.NodeOpener)((*fileNode)(nil))
+)
+
+// inode gives every path a stable inode number, so the same path gets the same node
+func inode(p string) uint64 {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(p))
+	return max(h.Sum64(), 2) // 1 is the root
+}
+
+func setTimes(out *gofuse.Attr, t time.Time) {
+	out.SetTimes(&t, &t, &t)
+}
+
+func (n *rootNode) parents() []string {
+	return append([]string{"__all__", "torrents", "__bad__"}, n.mount.cache.GetCustomFolders()...)
+}
+
+func (n *rootNode) Readdir(ctx context.Context) (fs.DirStream, syscall.Errno) {
+	entries := make([]gofuse.DirEntry, 0)
+	for _, parent := range n.parents() {
+		entries = append(entries, gofuse.DirEntry{Name: parent, Mode: syscall.S_IFDIR, Ino: inode(parent)})
+	}
+	entries = append(entries, gofuse.DirEntry{Name: "version.txt", Mode: syscall.S_IFREG, Ino: inode("version.txt")})
+	return fs.NewListDirStream(entries), 0
+}
+
+func (n *rootNode) Lookup(ctx context.Context, name string, out *gofuse.EntryOut) (*fs.Inode, syscall.Errno) {
+	if name == "version.txt" {
+		data := []byte(version.GetInfo().String())
+		out.Attr.Size = uint64(len(data))
+		setTimes(&out.Attr, time.Now())
+		return n.NewInode(ctx, &fs.MemRegularFile{Data: data}, fs.StableAttr{Mode: syscall.S_IFREG, Ino: inode(name)}), 0
+	}
+	if !slices.Contains(n.parents(), name) {
+		return nil, syscall.ENOENT
+	}
+	setTimes(&out.Attr, time.Now())
+	child := &folderNode{node: node{mount: n.mount, ctx: n.ctx}, folder: name}
+	return n.NewInode(ctx, child, fs.StableAttr{Mode: syscall.S_IFDIR, Ino: inode(name)}), 0
+}
+
+func (n *folderNode) Readdir(ctx context.Context) (fs.DirStream, syscall.Errno) {
+	listing := n.mount.cache.GetListing(n.folder)
+	entries := make([]gofuse.DirEntry, 0, len(listing))
+	for _, fi := range listing {
+		entries = append(entries, gofuse.DirEntry{
+			Name: fi.Name(),
+			Mode: syscall.S_IFDIR,
+			Ino:  inode(path.Join(n.folder, fi.Name())),
+		})
+	}
+	return fs.NewListDirStream(entries), 0
+}
+
+// torrent returns the torrent if it's listed in the folder, custom folders only list some of the torrents
+func (n *folderNode) torrent(name string) *store.CachedTorrent {
+	if !slices.ContainsFunc(n.mount.cache.GetListing(n.folder), func(fi os.FileInfo) bool { return fi.Name() == name }) {
+		return nil
+	}
+	return n.mount.cache.GetTorrentByName(name)
+}
+
+func (n *folderNode) Lookup(ctx context.Context, name string, out *gofuse.EntryOut) (*fs.Inode, syscall.Errno) {
+	cached := n.torrent(name)
+	if cached == nil {
+		return nil, syscall.ENOENT
+	}
+	setTimes(&out.Attr, cached.AddedOn)
+	child := &torrentNode{node: node{mount: n.mount, ctx: n.ctx}, folder: n.folder, torrentName: name}
+	return n.NewInode(ctx, child, fs.StableAttr{Mode: syscall.S_IFDIR, Ino: inode(path.Join(n.folder, name))}), 0
+}
+
+// Rmdir removes the torrent from the cache and the debrid, like a DELETE on the WebDAV
+func (n *folderNode) Rmdir(ctx context.Context, name string) syscall.Errno {
+	cached := n.torrent(name)
+	if cached == nil {
+		return syscall.ENOENT
+	}
+	n.mount.cache.OnRemove(cached.Id)
+	return 0
+}
+
+func (n *torrentNode) Readdir(ctx context.Context) (fs.DirStream, syscall.Errno) {
+	cached := n.mount.cache.GetTorrentByName(n.torrentName)
+	if cached == nil {
+		return nil, syscall.ENOENT
+	}
+	files := cached.GetFiles()
+	slices.SortFunc(files, func(a, b types.File) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+	entries := make([]gofuse.DirEntry, 0, len(files))
+	for _, f := range files {
+		entries = append(entries, gofuse.DirEntry{
+			Name: f.Name,
+			Mode: syscall.S_IFREG,
+			Ino:  inode(path.Join(n.folder, n.torrentName, f.Name)),
+		})
+	}
+	return fs.NewListDirStream(entries), 0
+}
+
+func (n *torrentNode) Lookup(ctx context.Context, name string, out *gofuse.EntryOut) (*fs.Inode, syscall.Errno) {
+	cached := n.mount.cache.GetTorrentByName(n.torrentName)
+	if cached == nil {
+		return nil, syscall.ENOENT
+	}
+	f, ok := cached.GetFile(name)
+	if !ok {
+		return nil, syscall.ENOENT
+	}
+	out.Attr.Size = uint64(f.Size)
+	setTimes(&out.Attr, cached.AddedOn)
+	child := &fileNode{node: node{mount: n.mount, ctx: n.ctx}, torrentName: n.torrentName, filename: name}
+	return n.NewInode(ctx, child, fs.StableAttr{Mode: syscall.S_IFREG, Ino: inode(path.Join(n.folder, n.torrentName, name))}), 0
+}
+
+// Unlink removes the file from the torrent, like a DELETE on the WebDAV
+func (n *torrentNode) Unlink(ctx context.Context, name string) syscall.Errno {
+	cached := n.mount.cache.GetTorrentByName(n.torrentName)
+	if cached == nil {
+		return syscall.ENOENT
+	}
+	if err := n.mount.cache.RemoveFile(cached.Id, name); err != nil {
+		n.mount.logger.Error().Err(err).Msgf("Failed to remove file %s from torrent %s", name, n.torrentName)
+		return syscall.EIO
+	}
+	return 0
+}
+
+func (n *fileNode) Getattr(ctx context.Context, fh fs.FileHandle, out *gofuse.AttrOut) syscall.Errno {
+	cached := n.mount.cache.GetTorrentByName(n.torrentName)
+	if cached == nil {
+		return syscall.ENOENT
+	}
+	f, ok := cached.GetFile(n.filename)
+	if !ok {
+		return syscall.ENOENT
+	}
+	out.Size = uint64(f.Size)
+	setTimes(&out.Attr, cached.AddedOn)
+	return 0
+}
+
+func (n *fileNode) Open(ctx context.Context, flags uint32) (fs.FileHandle, uint32, syscall.Errno) {
+	if flags&(syscall.O_WRONLY|syscall.O_RDWR) != 0 {
+		return nil, 0, syscall.EROFS
+	}
+	cached := n.mount.cache.GetTorrentByName(n.torrentName)
+	if cached == nil {
+		return nil, 0, syscall.ENOENT
+	}
+	f, ok := cached.GetFile(n.filename)
+	if !ok {
+		return nil, 0, syscall.ENOENT
+	}
+	// The content never changes, the kernel can keep its page cache between opens
+	return &fileHandle{mount: n.mount, ctx: n.ctx, file: &file{File: f, torrentName: n.torrentName}, next: -1}, gofuse.FOPEN_KEEP_CACHE, 0
+}
+
+// fileHandle reads an open file through the debrid's chunk cache, reading ahead while reads are sequential
+type fileHandle struct {
+	mount *Mount
+	ctx   context.Context
+	file  *file
+
+	mu   sync.Mutex
+	next int64 // Where the next read starts if reads are sequential
+}
+
+var _ = (fs.FileReader)((*fileHandle)(nil))
+
+func (h *fileHandle) Read(ctx context.Context, dest []byte, off int64) (gofuse.ReadResult, syscall.Errno) {
+	h.mu.Lock()
+	sequential := off == h.next || off == 0
+	h.next = off + int64(len(dest))
+	h.mu.Unlock()
+
+	if sequential && h.mount.readAhead > 0 {
+		h.mount.prefetch(h.ctx, h.file, off+int64(len(dest)))
+	}
+
+	n, err := h.mount.readAt(ctx, h.file, dest, off)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, syscall.EINTR
+		}
+		h.mount.logger.Error().Err(err).Str("file", h.file.Name).Msg("Failed to read file")
+		return nil, syscall.EIO
+	}
+	return gofuse.ReadResultData(dest[:n]), 0
+}
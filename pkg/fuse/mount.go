@@ -0,0 +1,152 @@
+package fuse
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/sirrobot01/decypharr/internal/config"
+	"github.com/sirrobot01/decypharr/pkg/debrid/store"
+)
+
+const (
+	defaultReadAhead    = 32 * 1024 * 1024
+	defaultDirCacheTime = 5 * time.Minute
+)
+
+// Mount serves a debrid's WebDAV cache as a local filesystem in-process,
+// without rclone or the extra HTTP hop through our own WebDAV server. Files are read through the cache's on-disk
+// chunk cache
+type Mount struct {
+	Provider  string
+	LocalPath string
+	cache     *store.Cache
+	logger    zerolog.Logger
+
+	readAhead    int64 // Bytes fetched ahead of sequential reads
+	dirCacheTime time.Duration
+	allowOther   bool
+	uid, gid     uint32
+
+	mu     sync.Mutex
+	server *server
+}
+
+// NewMount creates a FUSE mount of the cache, at the debrid's fuse mount path or under the global fuse mount path
+func NewMount(provider, customMountPath string, cache *store.Cache) *Mount {
+	cfg := config.Get()
+	mountPath := customMountPath
+	if mountPath == "" {
+		mountPath = filepath.Join(cfg.Fuse.MountPath, provider)
+	}
+
+	dirCacheTime, err := time.ParseDuration(cfg.Fuse.DirCacheTime)
+	if err != nil {
+		dirCacheTime = defaultDirCacheTime
+	}
+
+	return &Mount{
+		Provider:     provider,
+		LocalPath:    mountPath,
+		cache:        cache,
+		logger:       cache.Logger(),
+		readAhead:    parseSize(cfg.Fuse.ReadAhead, defaultReadAhead),
+		dirCacheTime: dirCacheTime,
+		allowOther:   cfg.Fuse.AllowOther,
+		uid:          cfg.Fuse.UID,
+		gid:          cfg.Fuse.GID,
+	}
+}
+
+func parseSize(size string, fallback int64) int64 {
+	if size == "" {
+		return fallback
+	}
+	parsed, err := config.ParseSize(size)
+	if err != nil || parsed <= 0 {
+		return fallback
+	}
+	return parsed
+}
+
+// Mount mounts the filesystem, it's unmounted again once ctx is done
+func (m *Mount) Mount(ctx context.Context) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.server != nil {
+		m.logger.Info().Msgf("Mount %s is already mounted at %s", m.Provider, m.LocalPath)
+		return nil
+	}
+	if !m.cache.ChunkCacheEnabled() {
+		return fmt.Errorf("mount failed for %s: the chunk cache is required, check chunk_cache.size", m.Provider)
+	}
+
+	m.logger.Info().
+		Str("provider", m.Provider).
+		Str("mount_path", m.LocalPath).
+		Msg("Creating FUSE mount")
+
+	srv, err := m.mount(ctx)
+	if err != nil {
+		return fmt.Errorf("mount failed for %s: %w", m.Provider, err)
+	}
+	m.server = srv
+
+	go func() {
+		<-ctx.Done()
+		if err := m.unmount(srv); err != nil {
+			m.logger.Error().Err(err).Msgf("Failed to unmount %s", m.Provider)
+		}
+	}()
+
+	m.logger.Info().Msgf("Successfully mounted %s at %s", m.Provider, m.LocalPath)
+	return nil
+}
+
+// Unmount unmounts the filesystem, files still open keep it busy until they're closed
+func (m *Mount) Unmount() error {
+	m.mu.Lock()
+	srv := m.server
+	m.mu.Unlock()
+	return m.unmount(srv)
+}
+
+func (m *Mount) unmount(srv *server) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	// Already unmounted, or mounted again since
+	if srv == nil || m.server != srv {
+		return nil
+	}
+	if err := srv.unmount(); err != nil {
+		return fmt.Errorf("failed to unmount %s: %w", m.Provider, err)
+	}
+	m.server = nil
+	m.logger.Info().Msgf("Successfully unmounted %s", m.Provider)
+	return nil
+}
+
+func (m *Mount) IsMounted() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.server != nil
+}
+
+// RefreshDir invalidates what the kernel cached of the listings, called from Cache.RefreshListings.
+// The kernel only caches what was looked up, so all of it is checked and dirs only matter to rclone
+func (m *Mount) RefreshDir(dirs []string) error {
+	m.mu.Lock()
+	srv := m.server
+	m.mu.Unlock()
+
+	if srv == nil {
+		return fmt.Errorf("provider %s not properly mounted. Skipping refreshes", m.Provider)
+	}
+	srv.refresh(m.cache)
+	return nil
+}
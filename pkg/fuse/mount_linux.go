@@ -0,0 +1,104 @@
+//go:build linux
+
+package fuse
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"syscall"
+
+	"github.com/hanwen/go-fuse/v2/fs"
+	gofuse "github.com/hanwen/go-fuse/v2/fuse"
+	"github.com/sirrobot01/decypharr/pkg/debrid/store"
+)
+
+type server struct {
+	fuse *gofuse.Server
+	root *fs.Inode
+	path string
+}
+
+func (m *Mount) mount(ctx context.Context) (*server, error) {
+	if err := prepareMountPoint(m.LocalPath); err != nil {
+		return nil, fmt.Errorf("failed to prepare mount point %s: %w", m.LocalPath, err)
+	}
+
+	root := &rootNode{node: node{mount: m, ctx: ctx}}
+	srv, err := fs.Mount(m.LocalPath, root, &fs.Options{
+		MountOptions: gofuse.MountOptions{
+			AllowOther:    m.allowOther,
+			FsName:        "decypharr-" + m.Provider,
+			Name:          "decypharr",
+			DisableXAttrs: true,
+			DirectMount:   true, // Falls back to fusermount when not root
+		},
+		EntryTimeout: &m.dirCacheTime,
+		AttrTimeout:  &m.dirCacheTime,
+		UID:          m.uid,
+		GID:          m.gid,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &server{fuse: srv, root: root.EmbeddedInode(), path: m.LocalPath}, nil
+}
+
+func (s *server) unmount() error {
+	if err := s.fuse.Unmount(); err != nil {
+		// Files are still open, detach it so it goes away once they're closed
+		if forceUnmount(s.path) != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// refresh drops what the kernel cached of torrents and files that are gone.
+// Listings themselves aren't cached, they're always read from the cache
+func (s *server) refresh(cache *store.Cache) {
+	for _, folder := range s.root.Children() {
+		if !folder.IsDir() {
+			continue
+		}
+		for torrentName, torrent := range folder.Children() {
+			cached := cache.GetTorrentByName(torrentName)
+			if cached == nil {
+				folder.NotifyEntry(torrentName)
+				continue
+			}
+			for filename := range torrent.Children() {
+				if _, ok := cached.GetFile(filename); !ok {
+					torrent.NotifyEntry(filename)
+				}
+			}
+		}
+	}
+}
+
+// prepareMountPoint creates the mount point, unmounting what a previous run that didn't exit cleanly left there
+func prepareMountPoint(path string) error {
+	if _, err := os.Stat(path); errors.Is(err, syscall.ENOTCONN) {
+		if err := forceUnmount(path); err != nil {
+			return err
+		}
+	}
+	return os.MkdirAll(path, 0755)
+}
+
+// forceUnmount lazily unmounts the path with the system commands
+func forceUnmount(path string) error {
+	methods := [][]string{
+		{"fusermount3", "-uz", path},
+		{"fusermount", "-uz", path},
+		{"umount", "-l", path},
+	}
+	for _, method := range methods {
+		if err := exec.Command(method[0], method[1:]...).Run(); err == nil {
+			return nil
+		}
+	}
+	return fmt.Errorf("all force unmount attempts failed for %s", path)
+}
@@ -0,0 +1,22 @@
+//go:build !linux
+
+package fuse
+
+import (
+	"context"
+	"errors"
+
+	"github.com/sirrobot01/decypharr/pkg/debrid/store"
+)
+
+type server struct{}
+
+func (m *Mount) mount(ctx context.Context) (*server, error) {
+	return nil, errors.New("fuse mounts are only supported on linux")
+}
+
+func (s *server) unmount() error {
+	return nil
+}
+
+func (s *server) refresh(cache *store.Cache) {}
@@ -0,0 +1,44 @@
+package fuse
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/sirrobot01/decypharr/pkg/debrid/types"
+)
+
+// file is a file of a torrent as it was when opened
+type file struct {
+	types.File
+	torrentName string
+}
+
+// readAt reads the file at off into dest. It goes through the cache's StreamFile, so reads are served from the
+// on-disk chunk cache shared with the WebDAV
+func (m *Mount) readAt(ctx context.Context, f *file, dest []byte, off int64) (int, error) {
+	if off >= f.Size {
+		return 0, nil
+	}
+	end := min(off+int64(len(dest)), f.Size)
+
+	body, err := m.cache.StreamFile(ctx, f.torrentName, f.File, off, end-1)
+	if err != nil {
+		return 0, err
+	}
+	defer func(body io.ReadCloser) {
+		_ = body.Close()
+	}(body)
+
+	n, err := io.ReadFull(body, dest[:end-off])
+	if err != nil {
+		return n, fmt.Errorf("failed to read %s at %d: %w", f.Name, off, err)
+	}
+	return n, nil
+}
+
+// prefetch fetches what follows the read at off..end into the chunk cache in the background.
+// The chunks are fetched with fetchCtx, so they outlive the read
+func (m *Mount) prefetch(fetchCtx context.Context, f *file, end int64) {
+	m.cache.PrefetchFile(fetchCtx, f.torrentName, f.File, end, end+m.readAhead-1)
+}
@@ -2,12 +2,17 @@ package qbit
 
 import (
 	"net/http"
+	"os"
 	"path/filepath"
+	"slices"
+	"strconv"
 	"strings"
 
 	"github.com/sirrobot01/decypharr/internal/config"
 	"github.com/sirrobot01/decypharr/internal/request"
+	"github.com/sirrobot01/decypharr/internal/utils"
 	"github.com/sirrobot01/decypharr/pkg/arr"
+	"github.com/sirrobot01/decypharr/pkg/wire"
 )
 
 func (q *QBit) handleLogin(w http.ResponseWriter, r *http.Request) {
@@ -72,7 +77,7 @@ func (q *QBit) handleTorrentsInfo(w http.ResponseWriter, r *http.Request) {
 	category := getCategory(ctx)
 	filter := strings.Trim(r.URL.Query().Get("filter"), "")
 	hashes := getHashes(ctx)
-	torrents := q.storage.GetAllSorted(category, filter, hashes, "added_on", false)
+	torrents := q.storage.GetTorrents(category, filter, hashes, "added_on", false)
 	request.JSONResponse(w, torrents, http.StatusOK)
 }
 
@@ -98,10 +103,6 @@ func (q *QBit) handleTorrentsAdd(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	action := "symlink"
-	if strings.ToLower(r.FormValue("sequentialDownload")) == "true" {
-		action = "download"
-	}
 	debridName := r.FormValue("debrid")
 	category := r.FormValue("category")
 	_arr := getArrFromContext(ctx)
@@ -109,6 +110,11 @@ func (q *QBit) handleTorrentsAdd(w http.ResponseWriter, r *http.Request) {
 		// Arr is not in context
 		_arr = arr.New(category, "", "", false, false, nil, "", "")
 	}
+	action := ""
+	if strings.ToLower(r.FormValue("sequentialDownload")) == "true" {
+		action = "download"
+	}
+	action = wire.ImportAction(_arr, action)
 	atleastOne := false
 
 	// Handle magnet URLs
@@ -210,15 +216,7 @@ func (q *QBit) handleTorrentRecheck(w http.ResponseWriter, r *http.Request) {
 }
 
 func (q *QBit) handleCategories(w http.ResponseWriter, r *http.Request) {
-	var categories = map[string]TorrentCategory{}
-	for _, cat := range q.Categories {
-		path := filepath.Join(q.DownloadFolder, cat)
-		categories[cat] = TorrentCategory{
-			Name:     cat,
-			SavePath: path,
-		}
-	}
-	request.JSONResponse(w, categories, http.StatusOK)
+	request.JSONResponse(w, q.categories(), http.StatusOK)
 }
 
 func (q *QBit) handleCreateCategory(w http.ResponseWriter, r *http.Request) {
@@ -234,7 +232,17 @@ func (q *QBit) handleCreateCategory(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	q.Categories = append(q.Categories, name)
+	err = q.updateCategories(func(categories []string) []string {
+		if slices.Contains(categories, name) {
+			return categories
+		}
+		return append(categories, name)
+	})
+	if err != nil {
+		q.logger.Error().Err(err).Msg("Failed to save categories")
+		http.Error(w, "Failed to save categories", http.StatusInternalServerError)
+		return
+	}
 
 	request.JSONResponse(w, nil, http.StatusOK)
 }
@@ -326,3 +334,122 @@ func (q *QBit) handleCreateTags(w http.ResponseWriter, r *http.Request) {
 	q.addTags(tags)
 	request.JSONResponse(w, nil, http.StatusOK)
 }
+
+func (q *QBit) handleRemoveCategories(w http.ResponseWriter, r *http.Request) {
+	err := r.ParseForm()
+	if err != nil {
+		http.Error(w, "Failed to parse form data", http.StatusBadRequest)
+		return
+	}
+	categories := strings.Split(r.FormValue("categories"), "\n")
+	for i, category := range categories {
+		categories[i] = strings.TrimSpace(category)
+	}
+	err = q.updateCategories(func(current []string) []string {
+		return utils.RemoveItem(current, categories...)
+	})
+	if err != nil {
+		q.logger.Error().Err(err).Msg("Failed to save categories")
+		http.Error(w, "Failed to save categories", http.StatusInternalServerError)
+		return
+	}
+	request.JSONResponse(w, nil, http.StatusOK)
+}
+
+func (q *QBit) handleSyncMainData(w http.ResponseWriter, r *http.Request) {
+	rid, _ := strconv.ParseInt(r.FormValue("rid"), 10, 64)
+	request.JSONResponse(w, q.mainData.update(rid, q.snapshot()), http.StatusOK)
+}
+
+func (q *QBit) handleTransferInfo(w http.ResponseWriter, r *http.Request) {
+	torrents := q.storage.GetTorrents("", "", nil, "", false)
+	request.JSONResponse(w, transferInfo(torrents), http.StatusOK)
+}
+
+func (q *QBit) handleTorrentTrackers(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	torrent := q.storage.Get(r.FormValue("hash"), getCategory(ctx))
+	if torrent == nil {
+		http.Error(w, "Torrent hash not found", http.StatusNotFound)
+		return
+	}
+	request.JSONResponse(w, q.GetTorrentTrackers(torrent), http.StatusOK)
+}
+
+func (q *QBit) handleRenameFile(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	torrent := q.storage.Get(r.FormValue("hash"), getCategory(ctx))
+	if torrent == nil {
+		http.Error(w, "Torrent hash not found", http.StatusNotFound)
+		return
+	}
+	oldPath := r.FormValue("oldPath")
+	newPath := r.FormValue("newPath")
+	if oldPath == "" || newPath == "" {
+		http.Error(w, "oldPath and newPath are required", http.StatusBadRequest)
+		return
+	}
+	if err := wire.Get().RenameFile(torrent, oldPath, newPath); err != nil {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+func (q *QBit) handleSetLocation(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	hashes := getHashes(ctx)
+	if len(hashes) == 0 {
+		http.Error(w, "No hashes provided", http.StatusBadRequest)
+		return
+	}
+	if slices.Contains(hashes, "all") {
+		hashes = nil
+	}
+	location := strings.TrimSpace(r.FormValue("location"))
+	if location == "" {
+		http.Error(w, "No location provided", http.StatusBadRequest)
+		return
+	}
+	if err := os.MkdirAll(location, os.ModePerm); err != nil {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	// NZBs are SABnzbd's, they're not moved through the qBittorrent API
+	for _, torrent := range q.storage.GetTorrents("", "", hashes, "", false) {
+		if err := q.setLocation(torrent, location); err != nil {
+			q.logger.Error().Err(err).Str("hash", torrent.Hash).Msg("Failed to move torrent")
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+func (q *QBit) handleFilePrio(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	torrent := q.storage.Get(r.FormValue("hash"), getCategory(ctx))
+	if torrent == nil {
+		http.Error(w, "Torrent hash not found", http.StatusNotFound)
+		return
+	}
+	priority, err := strconv.Atoi(r.FormValue("priority"))
+	if err != nil || !slices.Contains([]int{0, 1, 6, 7}, priority) {
+		http.Error(w, "Priority is invalid", http.StatusBadRequest)
+		return
+	}
+	ids := make([]int, 0)
+	for _, id := range strings.Split(r.FormValue("id"), "|") {
+		index, err := strconv.Atoi(strings.TrimSpace(id))
+		if err != nil {
+			http.Error(w, "File IDs are invalid", http.StatusBadRequest)
+			return
+		}
+		ids = append(ids, index)
+	}
+	if err := wire.Get().SetFilePriority(torrent, ids, priority); err != nil {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
@@ -1,6 +1,8 @@
 package qbit
 
 import (
+	"sync"
+
 	"github.com/rs/zerolog"
 	"github.com/sirrobot01/decypharr/internal/config"
 	"github.com/sirrobot01/decypharr/internal/logger"
@@ -12,9 +14,11 @@ type QBit struct {
 	Password       string
 	DownloadFolder string
 	Categories     []string
+	categoriesMu   sync.RWMutex
 	storage        *wire.TorrentStorage
 	logger         zerolog.Logger
 	Tags           []string
+	mainData       *syncState
 }
 
 func New() *QBit {
@@ -27,6 +31,7 @@ func New() *QBit {
 		Categories:     cfg.Categories,
 		storage:        wire.Get().Torrents(),
 		logger:         logger.New("qbit"),
+		mainData:       newSyncState(),
 	}
 }
 
@@ -35,4 +40,5 @@ func (q *QBit) Reset() {
 		q.storage.Reset()
 	}
 	q.Tags = nil
+	q.mainData.reset()
 }
@@ -45,6 +45,24 @@ func (q *QBit) Routes() http.Handler {
 			r.Post("/properties", q.handleTorrentProperties)
 			r.Post("/files", q.handleTorrentFiles)
 
+			r.Get("/trackers", q.handleTorrentTrackers)
+			r.Post("/trackers", q.handleTorrentTrackers)
+			r.Post("/renameFile", q.handleRenameFile)
+			r.Post("/setLocation", q.handleSetLocation)
+			r.Post("/filePrio", q.handleFilePrio)
+			r.Post("/removeCategories", q.handleRemoveCategories)
+		})
+
+		r.Route("/sync", func(r chi.Router) {
+			r.Use(q.authContext)
+			r.Get("/maindata", q.handleSyncMainData)
+			r.Post("/maindata", q.handleSyncMainData)
+		})
+
+		r.Route("/transfer", func(r chi.Router) {
+			r.Use(q.authContext)
+			r.Get("/info", q.handleTransferInfo)
+			r.Post("/info", q.handleTransferInfo)
 		})
 
 		r.Route("/app", func(r chi.Router) {
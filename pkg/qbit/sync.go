@@ -0,0 +1,153 @@
+package qbit
+
+import (
+	"encoding/json"
+	"reflect"
+	"slices"
+	"sync"
+
+	"github.com/sirrobot01/decypharr/internal/utils"
+	"github.com/sirrobot01/decypharr/pkg/wire"
+)
+
+// How many maindata snapshots are kept for rid based diffs. Older rids get a full update
+const maxSyncSnapshots = 32
+
+type syncSnapshot struct {
+	torrents    map[string]map[string]any
+	categories  map[string]TorrentCategory
+	tags        []string
+	serverState map[string]any
+}
+
+// syncState hands out the rids for /sync/maindata and remembers what each client was sent
+type syncState struct {
+	mu        sync.Mutex
+	rid       int64
+	snapshots map[int64]*syncSnapshot
+}
+
+func newSyncState() *syncState {
+	return &syncState{
+		snapshots: make(map[int64]*syncSnapshot),
+	}
+}
+
+func (s *syncState) reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.snapshots = make(map[int64]*syncSnapshot)
+}
+
+// update stores the snapshot under a new rid and returns what changed since rid
+func (s *syncState) update(rid int64, current *syncSnapshot) *MainData {
+	s.mu.Lock()
+	previous := s.snapshots[rid]
+	s.rid++
+	data := &MainData{Rid: s.rid}
+	s.snapshots[s.rid] = current
+	delete(s.snapshots, s.rid-maxSyncSnapshots)
+	s.mu.Unlock()
+
+	if rid == 0 || previous == nil {
+		data.FullUpdate = true
+		data.Torrents = current.torrents
+		data.Categories = current.categories
+		data.Tags = current.tags
+		data.ServerState = current.serverState
+		return data
+	}
+
+	data.Torrents = make(map[string]map[string]any)
+	for hash, torrent := range current.torrents {
+		if changed := diffFields(previous.torrents[hash], torrent); len(changed) > 0 {
+			data.Torrents[hash] = changed
+		}
+	}
+	for hash := range previous.torrents {
+		if _, ok := current.torrents[hash]; !ok {
+			data.TorrentsRemoved = append(data.TorrentsRemoved, hash)
+		}
+	}
+
+	data.Categories = make(map[string]TorrentCategory)
+	for name, category := range current.categories {
+		if old, ok := previous.categories[name]; !ok || old != category {
+			data.Categories[name] = category
+		}
+	}
+	for name := range previous.categories {
+		if _, ok := current.categories[name]; !ok {
+			data.CategoriesRemoved = append(data.CategoriesRemoved, name)
+		}
+	}
+
+	data.Tags = make([]string, 0)
+	for _, tag := range current.tags {
+		if !slices.Contains(previous.tags, tag) {
+			data.Tags = append(data.Tags, tag)
+		}
+	}
+	for _, tag := range previous.tags {
+		if !slices.Contains(current.tags, tag) {
+			data.TagsRemoved = append(data.TagsRemoved, tag)
+		}
+	}
+
+	data.ServerState = diffFields(previous.serverState, current.serverState)
+	return data
+}
+
+// diffFields returns the fields of current that are new or changed
+func diffFields(previous, current map[string]any) map[string]any {
+	changed := make(map[string]any)
+	for key, value := range current {
+		if old, ok := previous[key]; !ok || !reflect.DeepEqual(old, value) {
+			changed[key] = value
+		}
+	}
+	return changed
+}
+
+// toFields flattens v into its JSON fields, so snapshots can be compared field by field
+func toFields(v any) map[string]any {
+	fields := make(map[string]any)
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fields
+	}
+	_ = json.Unmarshal(data, &fields)
+	return fields
+}
+
+func (q *QBit) snapshot() *syncSnapshot {
+	torrents := q.storage.GetTorrents("", "", nil, "", false)
+	snapshot := &syncSnapshot{
+		torrents:   make(map[string]map[string]any, len(torrents)),
+		categories: q.categories(),
+		tags:       utils.RemoveItem(q.Tags, ""),
+		serverState: toFields(ServerState{
+			TransferInfo:    transferInfo(torrents),
+			RefreshInterval: 1500,
+		}),
+	}
+	for _, t := range torrents {
+		fields := toFields(t)
+		// Files have their own endpoint, maindata never has them
+		delete(fields, "files")
+		snapshot.torrents[t.Hash] = fields
+	}
+	return snapshot
+}
+
+// transferInfo sums up the torrents as the global transfer stats
+func transferInfo(torrents []*wire.Torrent) TransferInfo {
+	info := TransferInfo{ConnectionStatus: "connected"}
+	for _, t := range torrents {
+		info.DlInfoSpeed += t.Dlspeed
+		info.UpInfoSpeed += t.Upspeed
+		info.DlInfoData += t.Downloaded
+		info.UpInfoData += t.Uploaded
+	}
+	return info
+}
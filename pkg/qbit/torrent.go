@@ -5,9 +5,14 @@ import (
 	"fmt"
 	"io"
 	"mime/multipart"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
 	"strings"
 	"time"
 
+	"github.com/sirrobot01/decypharr/internal/config"
 	"github.com/sirrobot01/decypharr/internal/utils"
 	"github.com/sirrobot01/decypharr/pkg/arr"
 	"github.com/sirrobot01/decypharr/pkg/wire"
@@ -122,3 +127,71 @@ func (q *QBit) addTags(tags []string) bool {
 	}
 	return true
 }
+
+func (q *QBit) categories() map[string]TorrentCategory {
+	q.categoriesMu.RLock()
+	defer q.categoriesMu.RUnlock()
+	categories := make(map[string]TorrentCategory, len(q.Categories))
+	for _, cat := range q.Categories {
+		categories[cat] = TorrentCategory{
+			Name:     cat,
+			SavePath: filepath.Join(q.DownloadFolder, cat),
+		}
+	}
+	return categories
+}
+
+// updateCategories changes the categories and saves them in the config, so they survive restarts
+func (q *QBit) updateCategories(update func(categories []string) []string) error {
+	q.categoriesMu.Lock()
+	defer q.categoriesMu.Unlock()
+	q.Categories = update(slices.Clone(q.Categories))
+	cfg := config.Get()
+	cfg.QBitTorrent.Categories = slices.Clone(q.Categories)
+	return cfg.Save()
+}
+
+// GetTorrentTrackers lists the trackers of the magnet, after the DHT/PeX/LSD entries qBittorrent always reports.
+// The debrid does the actual downloading, so they're all reported as working
+func (q *QBit) GetTorrentTrackers(t *wire.Torrent) []TorrentTracker {
+	trackers := []TorrentTracker{
+		{URL: "** [DHT] **", Status: 2, NumSeeds: t.NumSeeds},
+		{URL: "** [PeX] **", Status: 2},
+		{URL: "** [LSD] **", Status: 2},
+	}
+	urls := make([]string, 0)
+	if magnet, err := url.Parse(t.MagnetUri); err == nil {
+		urls = append(urls, magnet.Query()["tr"]...)
+	}
+	if t.Tracker != "" && !slices.Contains(urls, t.Tracker) {
+		urls = append(urls, t.Tracker)
+	}
+	for tier, tracker := range urls {
+		trackers = append(trackers, TorrentTracker{
+			URL:      tracker,
+			Status:   2,
+			Tier:     tier,
+			NumSeeds: t.NumSeeds,
+		})
+	}
+	return trackers
+}
+
+// setLocation moves the torrent's folder into location
+func (q *QBit) setLocation(t *wire.Torrent, location string) error {
+	t.Lock()
+	defer t.Unlock()
+	if t.ContentPath != "" {
+		newPath := filepath.Join(location, filepath.Base(t.ContentPath))
+		if _, err := os.Lstat(t.ContentPath); err == nil && newPath != t.ContentPath {
+			if err := os.Rename(t.ContentPath, newPath); err != nil {
+				return err
+			}
+		}
+		t.ContentPath = newPath
+		t.TorrentPath = newPath
+	}
+	t.SavePath = location + string(os.PathSeparator)
+	q.storage.Update(t)
+	return nil
+}
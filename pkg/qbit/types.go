@@ -202,6 +202,51 @@ type TorrentProperties struct {
 	UpSpeedAvg             int    `json:"up_speed_avg,omitempty"`
 }
 
+type TransferInfo struct {
+	DlInfoSpeed      int64  `json:"dl_info_speed"`
+	DlInfoData       int64  `json:"dl_info_data"`
+	UpInfoSpeed      int64  `json:"up_info_speed"`
+	UpInfoData       int64  `json:"up_info_data"`
+	DlRateLimit      int64  `json:"dl_rate_limit"`
+	UpRateLimit      int64  `json:"up_rate_limit"`
+	DhtNodes         int    `json:"dht_nodes"`
+	ConnectionStatus string `json:"connection_status"`
+}
+
+type ServerState struct {
+	TransferInfo
+	AlltimeDl         int64 `json:"alltime_dl"`
+	AlltimeUl         int64 `json:"alltime_ul"`
+	FreeSpaceOnDisk   int64 `json:"free_space_on_disk"`
+	Queueing          bool  `json:"queueing"`
+	RefreshInterval   int   `json:"refresh_interval"`
+	UseAltSpeedLimits bool  `json:"use_alt_speed_limits"`
+}
+
+// MainData is the /sync/maindata response. Unless it's a full update, it only has what changed since the requested rid
+type MainData struct {
+	Rid               int64                      `json:"rid"`
+	FullUpdate        bool                       `json:"full_update,omitempty"`
+	Torrents          map[string]map[string]any  `json:"torrents"`
+	TorrentsRemoved   []string                   `json:"torrents_removed,omitempty"`
+	Categories        map[string]TorrentCategory `json:"categories"`
+	CategoriesRemoved []string                   `json:"categories_removed,omitempty"`
+	Tags              []string                   `json:"tags"`
+	TagsRemoved       []string                   `json:"tags_removed,omitempty"`
+	ServerState       map[string]any             `json:"server_state"`
+}
+
+type TorrentTracker struct {
+	URL           string `json:"url"`
+	Status        int    `json:"status"`
+	Tier          int    `json:"tier"`
+	NumPeers      int    `json:"num_peers"`
+	NumSeeds      int    `json:"num_seeds"`
+	NumLeeches    int    `json:"num_leeches"`
+	NumDownloaded int    `json:"num_downloaded"`
+	Msg           string `json:"msg"`
+}
+
 func getAppPreferences() *AppPreferences {
 	preferences := &AppPreferences{
 		AddTrackers:                        "",
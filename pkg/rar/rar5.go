@@ -0,0 +1,255 @@
+// RAR5 headers, see https://www.rarlab.com/technote.htm
+
+package rar
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"math"
+)
+
+var (
+	Rar5Marker = []byte{0x52, 0x61, 0x72, 0x21, 0x1A, 0x07, 0x01, 0x00}
+
+	// RAR5 header types
+	Header5Main       = uint64(1)
+	Header5File       = uint64(2)
+	Header5Service    = uint64(3)
+	Header5Encryption = uint64(4)
+	Header5End        = uint64(5)
+
+	// RAR5 header flags
+	Flag5HasExtra    = uint64(0x01)
+	Flag5HasData     = uint64(0x02)
+	Flag5SplitBefore = uint64(0x08)
+	Flag5SplitAfter  = uint64(0x10)
+
+	// RAR5 file flags
+	Flag5Directory = uint64(0x01)
+	Flag5HasTime   = uint64(0x02)
+	Flag5HasCRC    = uint64(0x04)
+
+	// RAR5 file extra records
+	Extra5Encryption = uint64(0x01)
+
+	// Largest header the spec allows, anything bigger is garbage
+	MaxHeader5Size = uint64(2 << 20)
+)
+
+var (
+	ErrEncryptedHeaders = errors.New("archive headers are encrypted")
+	ErrHeaderCRC        = errors.New("header CRC mismatch")
+)
+
+// header5 is a RAR5 block header, with the type specific fields left in data
+type header5 struct {
+	Type      uint64
+	Flags     uint64
+	ExtraSize uint64
+	DataSize  uint64
+	Size      int64  // Full size of the header, from the CRC to the end of the extra area
+	data      []byte // Type specific fields followed by the extra area
+}
+
+// vintReader reads the variable length integers RAR5 uses for most fields
+type vintReader struct {
+	buf []byte
+	pos int
+	err error
+}
+
+func (v *vintReader) vint() uint64 {
+	var n uint64
+	for i := 0; i < 10; i++ {
+		if v.pos >= len(v.buf) {
+			v.err = ErrInvalidFormat
+			return 0
+		}
+		b := v.buf[v.pos]
+		v.pos++
+		n |= uint64(b&0x7F) << (7 * i)
+		if b&0x80 == 0 {
+			return n
+		}
+	}
+	v.err = ErrInvalidFormat
+	return 0
+}
+
+func (v *vintReader) uint32() uint32 {
+	b := v.bytes(4)
+	if len(b) < 4 {
+		return 0
+	}
+	return binary.LittleEndian.Uint32(b)
+}
+
+func (v *vintReader) bytes(n uint64) []byte {
+	if v.err != nil {
+		return nil
+	}
+	if n > uint64(len(v.buf)-v.pos) {
+		v.err = ErrInvalidFormat
+		return nil
+	}
+	b := v.buf[v.pos : v.pos+int(n)]
+	v.pos += int(n)
+	return b
+}
+
+// readHeader5 reads the RAR5 header at pos and checks its CRC
+func (r *Reader) readHeader5(pos int64) (*header5, error) {
+	// CRC32 and the header size, a vint of up to 3 bytes for sane headers
+	prefix, err := r.readBytes(pos, 4+10)
+	if err != nil {
+		return nil, err
+	}
+	v := &vintReader{buf: prefix, pos: 4}
+	size := v.vint()
+	if v.err != nil || size == 0 || size > MaxHeader5Size {
+		return nil, fmt.Errorf("%w: bad header size at position %d", ErrInvalidFormat, pos)
+	}
+	sizeLen := v.pos - 4
+
+	total := 4 + sizeLen + int(size)
+	raw, err := r.readBytes(pos, total)
+	if err != nil {
+		return nil, err
+	}
+	if len(raw) < total {
+		return nil, fmt.Errorf("incomplete block header at position %d", pos)
+	}
+	if crc32.ChecksumIEEE(raw[4:]) != binary.LittleEndian.Uint32(raw[:4]) {
+		return nil, fmt.Errorf("%w at position %d", ErrHeaderCRC, pos)
+	}
+
+	v = &vintReader{buf: raw, pos: 4 + sizeLen}
+	h := &header5{Size: int64(total)}
+	h.Type = v.vint()
+	h.Flags = v.vint()
+	if h.Flags&Flag5HasExtra != 0 {
+		h.ExtraSize = v.vint()
+	}
+	if h.Flags&Flag5HasData != 0 {
+		h.DataSize = v.vint()
+	}
+	if v.err != nil || h.ExtraSize > uint64(total-v.pos) {
+		return nil, fmt.Errorf("%w: bad header at position %d", ErrInvalidFormat, pos)
+	}
+	// DataSize is an unbounded vint, a corrupt one would take the next position past the end of int64
+	if h.DataSize > uint64(math.MaxInt64-pos-h.Size) {
+		return nil, fmt.Errorf("%w: bad data size at position %d", ErrInvalidFormat, pos)
+	}
+	h.data = raw[v.pos:]
+	return h, nil
+}
+
+// extra returns the extra area of the header
+func (h *header5) extra() []byte {
+	return h.data[uint64(len(h.data))-h.ExtraSize:]
+}
+
+// readArchiveHeader5 checks the RAR5 main archive header and returns the position after it
+func (r *Reader) readArchiveHeader5() (int64, error) {
+	pos := r.Marker + int64(len(Rar5Marker))
+	h, err := r.readHeader5(pos)
+	if err != nil {
+		return 0, err
+	}
+	if h.Type == Header5Encryption {
+		return 0, ErrEncryptedHeaders
+	}
+	if h.Type != Header5Main {
+		return 0, ErrInvalidFormat
+	}
+	return pos + h.Size + int64(h.DataSize), nil
+}
+
+// readFiles5 reads all file entries of a RAR5 archive
+func (r *Reader) readFiles5() error {
+	pos, err := r.readArchiveHeader5()
+	if err != nil {
+		return err
+	}
+
+	for {
+		h, err := r.readHeader5(pos)
+		if err != nil {
+			return fmt.Errorf("error reading block header: %w", err)
+		}
+		switch h.Type {
+		case Header5End:
+			return nil
+		case Header5File:
+			file, err := r.parseFileHeader5(h, pos)
+			if err != nil {
+				return err
+			}
+			r.Files = append(r.Files, file)
+		}
+		// Service headers(comments, recovery records...) are skipped along with their data
+		pos += h.Size + int64(h.DataSize)
+	}
+}
+
+// parseFileHeader5 parses the type specific fields and the extra area of a RAR5 file header
+func (r *Reader) parseFileHeader5(h *header5, position int64) (*File, error) {
+	v := &vintReader{buf: h.data[:uint64(len(h.data))-h.ExtraSize]}
+	fileFlags := v.vint()
+	unpackSize := v.vint()
+	_ = v.vint() // attributes
+	if fileFlags&Flag5HasTime != 0 {
+		_ = v.uint32() // mtime
+	}
+	var fileCRC uint32
+	if fileFlags&Flag5HasCRC != 0 {
+		fileCRC = v.uint32()
+	}
+	compression := v.vint()
+	_ = v.vint() // host OS
+	nameSize := v.vint()
+	// Names are always UTF-8 in RAR5, with / as the separator
+	name := string(v.bytes(nameSize))
+	if v.err != nil {
+		return nil, fmt.Errorf("%w: bad file header at position %d", ErrInvalidFormat, position)
+	}
+
+	dataOffset := position + h.Size
+	return &File{
+		Path:           name,
+		Size:           int64(unpackSize),
+		CompressedSize: int64(h.DataSize),
+		// Map to the RAR3 method bytes, 0 => 0x30(Store)
+		Method:      0x30 + byte((compression>>7)&0x07),
+		CRC:         fileCRC,
+		IsDirectory: fileFlags&Flag5Directory != 0,
+		Encrypted:   hasExtraRecord5(h.extra(), Extra5Encryption),
+		SplitBefore: h.Flags&Flag5SplitBefore != 0,
+		SplitAfter:  h.Flags&Flag5SplitAfter != 0,
+		DataOffset:  dataOffset,
+		NextOffset:  dataOffset + int64(h.DataSize),
+	}, nil
+}
+
+// hasExtraRecord5 reports whether the extra area has a record of the given type
+func hasExtraRecord5(extra []byte, recordType uint64) bool {
+	v := &vintReader{buf: extra}
+	for v.pos < len(extra) {
+		size := v.vint()
+		start := v.pos
+		typ := v.vint()
+		if v.err != nil {
+			return false
+		}
+		if typ == recordType {
+			return true
+		}
+		if size > uint64(len(extra)-start) {
+			return false
+		}
+		v.pos = start + int(size)
+	}
+	return false
+}
@@ -1,5 +1,5 @@
 // Source: https://github.com/eliasbenb/RARAR.py
-// Note that this code only translates the original Python for RAR3 (not RAR5) support.
+// Note that this code only translates the original Python for RAR3 support, RAR5 is in rar5.go.
 
 package rar
 
@@ -31,6 +31,8 @@ var (
 	BlockEnd    = byte(0x7B)
 
 	// Header flags
+	FlagSplitBefore    = 0x01
+	FlagSplitAfter     = 0x02
 	FlagDirectory      = 0xE0
 	FlagHasHighSize    = 0x100
 	FlagHasUnicodeName = 0x200
@@ -55,6 +57,7 @@ var (
 	ErrRangeRequestsNotSupported    = errors.New("server does not support range requests")
 	ErrCompressionNotSupported      = errors.New("compression method not supported")
 	ErrDirectoryExtractNotSupported = errors.New("directory extract not supported")
+	ErrSplitFile                    = errors.New("file is split across volumes")
 )
 
 // Name returns the base filename of the file
@@ -65,6 +68,12 @@ func (f *File) Name() string {
 	return f.Path
 }
 
+// IsStored reports whether the file data is stored as-is, so ByteRange can be served directly
+func (f *File) IsStored() bool {
+	return f.Method == 0x30 && !f.Encrypted && !f.IsDirectory
+}
+
+// ByteRange returns the range of the file data in the archive, or in its first volume for split files, see Parts
 func (f *File) ByteRange() *[2]int64 {
 	return &[2]int64{f.DataOffset, f.DataOffset + f.CompressedSize - 1}
 }
@@ -222,7 +231,7 @@ func (f *HttpFile) ReadAt(p []byte, off int64) (n int, err error) {
 	return result.(int), nil
 }
 
-// NewReader creates a new RAR3 reader
+// NewReader creates a new RAR3/RAR5 reader
 func NewReader(url string) (*Reader, error) {
 	file, err := NewHttpFile(url)
 	if err != nil {
@@ -241,6 +250,16 @@ func NewReader(url string) (*Reader, error) {
 		return nil, err
 	}
 	reader.Marker = marker
+
+	if reader.Version == 5 {
+		headerEnd, err := reader.readArchiveHeader5()
+		if err != nil {
+			return nil, err
+		}
+		reader.HeaderEndPos = headerEnd
+		return reader, nil
+	}
+
 	pos := reader.Marker + int64(len(Rar3Marker)) // Skip marker block
 
 	headerData, err := reader.readBytes(pos, 7)
@@ -285,7 +304,17 @@ func (r *Reader) readBytes(start int64, length int) ([]byte, error) {
 	return data, nil
 }
 
-// findMarker finds the RAR marker in the file
+// markerIndex returns the position of the first RAR3 or RAR5 marker in chunk, and the version
+func markerIndex(chunk []byte) (int, int) {
+	rar3 := bytes.Index(chunk, Rar3Marker)
+	rar5 := bytes.Index(chunk, Rar5Marker)
+	if rar5 != -1 && (rar3 == -1 || rar5 < rar3) {
+		return rar5, 5
+	}
+	return rar3, 3
+}
+
+// findMarker finds the RAR marker in the file, and sets the archive version
 func (r *Reader) findMarker() (int64, error) {
 	// First try to find marker in the first chunk
 	firstChunkSize := 8192 // 8KB
@@ -294,13 +323,14 @@ func (r *Reader) findMarker() (int64, error) {
 		return 0, err
 	}
 
-	markerPos := bytes.Index(chunk, Rar3Marker)
+	markerPos, version := markerIndex(chunk)
 	if markerPos != -1 {
+		r.Version = version
 		return int64(markerPos), nil
 	}
 
 	// If not found, continue searching
-	position := int64(firstChunkSize - len(Rar3Marker) + 1)
+	position := int64(firstChunkSize - len(Rar5Marker) + 1)
 	maxSearch := int64(MaxSearchSize)
 
 	for position < maxSearch {
@@ -310,13 +340,14 @@ func (r *Reader) findMarker() (int64, error) {
 			break
 		}
 
-		markerPos := bytes.Index(chunk, Rar3Marker)
+		markerPos, version := markerIndex(chunk)
 		if markerPos != -1 {
+			r.Version = version
 			return position + int64(markerPos), nil
 		}
 
 		// Move forward by chunk size minus the marker length
-		position += int64(max(1, len(chunk)-len(Rar3Marker)+1))
+		position += int64(max(1, len(chunk)-len(Rar5Marker)+1))
 	}
 
 	return 0, ErrMarkerNotFound
@@ -653,6 +684,8 @@ func (r *Reader) parseFileHeader(headerData []byte, position int64) (*File, erro
 		Method:         method,
 		CRC:            fileCRC,
 		IsDirectory:    isDirectory,
+		SplitBefore:    headFlags&FlagSplitBefore != 0,
+		SplitAfter:     headFlags&FlagSplitAfter != 0,
 		DataOffset:     dataOffset,
 		NextOffset:     nextOffset,
 	}, nil
@@ -661,8 +694,11 @@ func (r *Reader) parseFileHeader(headerData []byte, position int64) (*File, erro
 // GetFiles returns all files in the archive
 func (r *Reader) GetFiles() ([]*File, error) {
 	if len(r.Files) == 0 {
-		err := r.readFiles()
-		if err != nil {
+		readFiles := r.readFiles
+		if r.Version == 5 {
+			readFiles = r.readFiles5
+		}
+		if err := readFiles(); err != nil {
 			return nil, err
 		}
 	}
@@ -677,9 +713,14 @@ func (r *Reader) ExtractFile(file *File) ([]byte, error) {
 	}
 
 	// Only support "Store" method
-	if file.Method != 0x30 { // 0x30 = "Store"
+	if !file.IsStored() {
 		return nil, ErrCompressionNotSupported
 	}
 
+	// The rest of the data is in other volumes, see ReadVolumes
+	if file.SplitBefore || file.SplitAfter || len(file.Parts) > 1 {
+		return nil, ErrSplitFile
+	}
+
 	return r.readBytes(file.DataOffset, int(file.CompressedSize))
 }
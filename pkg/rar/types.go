@@ -13,8 +13,19 @@ type File struct {
 	Method         byte
 	CRC            uint32
 	IsDirectory    bool
+	Encrypted      bool
+	SplitBefore    bool // Data continues from the previous volume
+	SplitAfter     bool // Data continues in the next volume
 	DataOffset     int64
 	NextOffset     int64
+	Parts          []Part // Where the data is in each volume, set by ReadVolumes
+}
+
+// Part is the data of a split file stored in one volume
+type Part struct {
+	Volume     int // Index of the volume
+	DataOffset int64
+	Size       int64
 }
 
 // Access point for a RAR archive served through HTTP
@@ -27,11 +38,12 @@ type HttpFile struct {
 	RetryDelay time.Duration
 }
 
-// Reader reads RAR3 format archives
+// Reader reads RAR3 and RAR5 format archives
 type Reader struct {
 	File         *HttpFile
 	ChunkSize    int
 	Marker       int64
+	Version      int   // 3 or 5
 	HeaderEndPos int64 // Position after the archive header
 	Files        []*File
 }
@@ -0,0 +1,117 @@
+package rar
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var (
+	// name.part01.rar, name.part02.rar...
+	partVolumeRe = regexp.MustCompile(`(?i)^(.+)\.part(\d+)\.rar$`)
+	// name.rar, name.r00...name.r99, name.s00...
+	oldVolumeRe = regexp.MustCompile(`(?i)^(.+)\.([r-z])(\d{2})$`)
+)
+
+// VolumeIndex returns the archive name and the position of a volume in it, for both the
+// name.partN.rar and the name.rar, name.r00, name.r01 naming schemes
+func VolumeIndex(name string) (string, int, bool) {
+	if m := partVolumeRe.FindStringSubmatch(name); m != nil {
+		n, _ := strconv.Atoi(m[2])
+		return m[1], n, true
+	}
+	if strings.EqualFold(name[max(0, len(name)-4):], ".rar") {
+		return name[:len(name)-4], 0, true
+	}
+	if m := oldVolumeRe.FindStringSubmatch(name); m != nil {
+		n, _ := strconv.Atoi(m[3])
+		letter := strings.ToLower(m[2])[0]
+		return m[1], 1 + int(letter-'r')*100 + n, true
+	}
+	return "", 0, false
+}
+
+// IsVolume reports whether name looks like a RAR archive or volume
+func IsVolume(name string) bool {
+	_, _, ok := VolumeIndex(name)
+	return ok
+}
+
+// GroupVolumes groups volume names by archive, in volume order. Archives with a single volume are left out
+func GroupVolumes(names []string) map[string][]string {
+	type volume struct {
+		name  string
+		index int
+	}
+	archives := make(map[string][]volume)
+	for _, name := range names {
+		if archive, index, ok := VolumeIndex(name); ok {
+			archives[archive] = append(archives[archive], volume{name, index})
+		}
+	}
+	groups := make(map[string][]string)
+	for archive, volumes := range archives {
+		if len(volumes) < 2 {
+			continue
+		}
+		sort.Slice(volumes, func(i, j int) bool { return volumes[i].index < volumes[j].index })
+		for _, v := range volumes {
+			groups[archive] = append(groups[archive], v.name)
+		}
+	}
+	return groups
+}
+
+// ReadVolumes reads the files of a multi-volume archive, with the volume urls in order.
+// Files split across volumes are joined into one File, with Parts pointing into each volume.
+// Files missing a volume are left out
+func ReadVolumes(urls []string) ([]*File, error) {
+	files := make([]*File, 0)
+	var open *File // file continuing in the next volume
+	for i, url := range urls {
+		reader, err := NewReader(url)
+		if err != nil {
+			return nil, fmt.Errorf("volume %d: %w", i+1, err)
+		}
+		volumeFiles, err := reader.GetFiles()
+		if err != nil {
+			return nil, fmt.Errorf("volume %d: %w", i+1, err)
+		}
+
+		for _, f := range volumeFiles {
+			part := Part{Volume: i, DataOffset: f.DataOffset, Size: f.CompressedSize}
+			if f.SplitBefore {
+				if open == nil || open.Path != f.Path {
+					// The start of the file is in a missing volume
+					if open != nil {
+						// and the previous file never ended
+						files = files[:len(files)-1]
+					}
+					open = nil
+					continue
+				}
+				open.Parts = append(open.Parts, part)
+				open.CompressedSize += f.CompressedSize
+				open.Encrypted = open.Encrypted || f.Encrypted
+				open.SplitAfter = f.SplitAfter
+			} else {
+				if open != nil {
+					// The previous file never ended
+					files = files[:len(files)-1]
+				}
+				f.Parts = []Part{part}
+				open = f
+				files = append(files, f)
+			}
+			if !f.SplitAfter {
+				open = nil
+			}
+		}
+	}
+	if open != nil {
+		files = files[:len(files)-1]
+	}
+	return files, nil
+}
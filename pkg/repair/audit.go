@@ -0,0 +1,362 @@
+package repair
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirrobot01/decypharr/internal/storage"
+	"github.com/sirrobot01/decypharr/pkg/arr"
+	"github.com/sirrobot01/decypharr/pkg/debrid/store"
+	"github.com/sirrobot01/decypharr/pkg/debrid/types"
+)
+
+const (
+	auditBucket    = "repair_audit"
+	auditRetention = 90 * 24 * time.Hour
+)
+
+type DetectionMethod string
+
+const (
+	DetectionSymlink DetectionMethod = "symlink" // file or symlink target is not readable
+	DetectionZurg    DetectionMethod = "zurg"
+	DetectionWebDav  DetectionMethod = "webdav" // link check against the debrid cache
+	DetectionDeep    DetectionMethod = "deep"   // sampled through the debrid cache, truncated or bad container headers
+)
+
+type AuditAction string
+
+const (
+	ActionReported     AuditAction = "reported"      // found broken, waiting to be processed
+	ActionDeleted      AuditAction = "deleted"       // deleted from the arr, search failed
+	ActionSearched     AuditAction = "searched"      // deleted from the arr and searched again
+	ActionDeleteFailed AuditAction = "delete_failed" // the local file is still removed, see arr.batchDeleteFiles
+)
+
+// AuditEntry is the record of a single broken file found by a repair run
+type AuditEntry struct {
+	ID            string          `json:"id"`
+	JobID         string          `json:"job_id"`
+	Arr           string          `json:"arr"`
+	Media         string          `json:"media"`
+	File          arr.ContentFile `json:"file"`
+	SymlinkTarget string          `json:"symlink_target"`
+	Debrid        string          `json:"debrid"`
+	Torrent       string          `json:"torrent"`
+	InfoHash      string          `json:"info_hash"`
+	Detection     DetectionMethod `json:"detection"`
+	Reason        string          `json:"reason"`
+	Action        AuditAction     `json:"action"`
+	ArrResponse   string          `json:"arr_response"`
+	DetectedAt    time.Time       `json:"detected_at"`
+	ProcessedAt   time.Time       `json:"processed_at"`
+	UndoneAt      time.Time       `json:"undone_at"`
+	UndoError     string          `json:"undo_error"`
+}
+
+// finding is what a checker knows about a broken file, keyed by the file path on the job
+type finding struct {
+	method   DetectionMethod
+	reason   string
+	debrid   string
+	torrent  string
+	infoHash string
+}
+
+func (j *Job) addFinding(file arr.ContentFile, f finding) {
+	if j.findings == nil {
+		return
+	}
+	j.findings.Store(file.Path, f)
+}
+
+// auditID is stable for a file within a run, so pending jobs can find their entries after a restart
+func auditID(job *Job, file arr.ContentFile) string {
+	key := job.ID + ":" + strconv.FormatInt(job.StartedAt.UnixNano(), 10) + ":" + file.Path
+	return uuid.NewSHA1(uuid.NameSpaceOID, []byte(key)).String()
+}
+
+type auditLog struct {
+	db storage.Store
+}
+
+func newAuditLog(db storage.Store) *auditLog {
+	return &auditLog{db: db}
+}
+
+func (l *auditLog) put(entries ...*AuditEntry) error {
+	items := make(map[string][]byte, len(entries))
+	for _, e := range entries {
+		data, err := json.Marshal(e)
+		if err != nil {
+			return err
+		}
+		items[e.ID] = data
+	}
+	return l.db.PutMany(auditBucket, items)
+}
+
+func (l *auditLog) get(id string) (*AuditEntry, error) {
+	data, err := l.db.Get(auditBucket, id)
+	if err != nil {
+		return nil, err
+	}
+	var e AuditEntry
+	if err := json.Unmarshal(data, &e); err != nil {
+		return nil, err
+	}
+	return &e, nil
+}
+
+// list returns the entries matching filter, newest first
+func (l *auditLog) list(filter func(e *AuditEntry) bool) ([]*AuditEntry, error) {
+	entries := make([]*AuditEntry, 0)
+	err := l.db.ForEach(auditBucket, func(key string, value []byte) error {
+		var e AuditEntry
+		if err := json.Unmarshal(value, &e); err != nil {
+			return nil // skip bad entries
+		}
+		if filter == nil || filter(&e) {
+			entries = append(entries, &e)
+		}
+		return nil
+	})
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].DetectedAt.After(entries[j].DetectedAt)
+	})
+	return entries, err
+}
+
+func (l *auditLog) prune(before time.Time) error {
+	keys := make([]string, 0)
+	err := l.db.ForEach(auditBucket, func(key string, value []byte) error {
+		var e AuditEntry
+		if err := json.Unmarshal(value, &e); err != nil || e.DetectedAt.Before(before) {
+			keys = append(keys, key)
+		}
+		return nil
+	})
+	if err != nil || len(keys) == 0 {
+		return err
+	}
+	return l.db.Delete(auditBucket, keys...)
+}
+
+// recordBroken writes a reported entry for every broken file found for a media
+func (r *Repair) recordBroken(job *Job, arrName, media string, files []arr.ContentFile) []*AuditEntry {
+	now := time.Now()
+	entries := make([]*AuditEntry, 0, len(files))
+	for _, file := range files {
+		f := finding{method: r.detectionMethod()}
+		if job.findings != nil {
+			if v, ok := job.findings.Load(file.Path); ok {
+				f = v
+			}
+		}
+		entries = append(entries, &AuditEntry{
+			ID:            auditID(job, file),
+			JobID:         job.ID,
+			Arr:           arrName,
+			Media:         media,
+			File:          file,
+			SymlinkTarget: getSymlinkTarget(file.Path),
+			Debrid:        f.debrid,
+			Torrent:       f.torrent,
+			InfoHash:      f.infoHash,
+			Detection:     f.method,
+			Reason:        f.reason,
+			Action:        ActionReported,
+			DetectedAt:    now,
+		})
+	}
+	if err := r.audit.put(entries...); err != nil {
+		r.logger.Error().Err(err).Msgf("Failed to save audit log for job %s", job.ID)
+	}
+	return entries
+}
+
+func (r *Repair) detectionMethod() DetectionMethod {
+	if r.useWebdav {
+		return DetectionWebDav
+	} else if r.IsZurg {
+		return DetectionZurg
+	}
+	return DetectionSymlink
+}
+
+// processBroken deletes the broken files from the arr, searches for them again and records the outcome
+func (r *Repair) processBroken(job *Job, a *arr.Arr, items []arr.ContentFile) error {
+	deleteErr := a.DeleteFiles(items)
+	searchErr := a.SearchMissing(items)
+
+	action := ActionSearched
+	if deleteErr != nil {
+		action = ActionDeleteFailed
+	} else if searchErr != nil {
+		action = ActionDeleted
+	}
+	response := fmt.Sprintf("delete: %s, search: %s", errOrOk(deleteErr), errOrOk(searchErr))
+
+	now := time.Now()
+	entries := make([]*AuditEntry, 0, len(items))
+	for _, file := range items {
+		e, err := r.audit.get(auditID(job, file))
+		if err != nil {
+			// Job ran before the audit log existed
+			e = &AuditEntry{
+				ID:         auditID(job, file),
+				JobID:      job.ID,
+				Arr:        a.Name,
+				File:       file,
+				Detection:  r.detectionMethod(),
+				DetectedAt: job.StartedAt,
+			}
+		}
+		e.Action = action
+		e.ArrResponse = response
+		e.ProcessedAt = now
+		entries = append(entries, e)
+	}
+	if err := r.audit.put(entries...); err != nil {
+		r.logger.Error().Err(err).Msgf("Failed to save audit log for job %s", job.ID)
+	}
+	if deleteErr != nil {
+		return fmt.Errorf("failed to delete broken items: %w", deleteErr)
+	}
+	if searchErr != nil {
+		return fmt.Errorf("failed to search missing items: %w", searchErr)
+	}
+	return nil
+}
+
+func errOrOk(err error) string {
+	if err != nil {
+		return err.Error()
+	}
+	return "ok"
+}
+
+// GetAudit returns the audit entries of a job, or of every job if jobID is empty
+func (r *Repair) GetAudit(jobID string) ([]*AuditEntry, error) {
+	return r.audit.list(func(e *AuditEntry) bool {
+		return jobID == "" || e.JobID == jobID
+	})
+}
+
+// Undo reverts processed entries that turned out to be false positives.
+// The torrent is re-inserted in the debrid, the symlink restored and the arr asked to rescan
+func (r *Repair) Undo(ids []string) ([]*AuditEntry, error) {
+	caches := r.deb.Caches()
+	undone := make([]*AuditEntry, 0, len(ids))
+	rescan := make(map[string][]arr.ContentFile)
+	var errs []error
+
+	for _, id := range ids {
+		e, err := r.audit.get(id)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("audit entry %s: %w", id, err))
+			continue
+		}
+		if !e.UndoneAt.IsZero() {
+			errs = append(errs, fmt.Errorf("audit entry %s already undone", id))
+			continue
+		}
+		if e.Action == ActionReported {
+			errs = append(errs, fmt.Errorf("audit entry %s: nothing to undo", id))
+			continue
+		}
+
+		if err := r.undoEntry(e, caches); err != nil {
+			e.UndoError = err.Error()
+			errs = append(errs, fmt.Errorf("audit entry %s: %w", id, err))
+		} else {
+			e.UndoError = ""
+			e.UndoneAt = time.Now()
+			rescan[e.Arr] = append(rescan[e.Arr], e.File)
+		}
+		undone = append(undone, e)
+	}
+
+	for arrName, files := range rescan {
+		a := r.arrs.Get(arrName)
+		if a == nil {
+			errs = append(errs, fmt.Errorf("arr %s not found, can't rescan", arrName))
+			continue
+		}
+		if err := a.Rescan(files); err != nil {
+			errs = append(errs, fmt.Errorf("rescan %s: %w", arrName, err))
+		}
+	}
+
+	if len(undone) > 0 {
+		if err := r.audit.put(undone...); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return undone, errors.Join(errs...)
+}
+
+func (r *Repair) undoEntry(e *AuditEntry, caches map[string]*store.Cache) error {
+	if e.Debrid != "" && e.Torrent != "" {
+		cache, ok := caches[e.Debrid]
+		if !ok {
+			return fmt.Errorf("no webdav cache for %s", e.Debrid)
+		}
+		ct := cache.GetTorrentByName(e.Torrent)
+		if ct == nil {
+			if e.InfoHash == "" {
+				return fmt.Errorf("torrent %s is no longer in %s", e.Torrent, e.Debrid)
+			}
+			ct = &store.CachedTorrent{Torrent: &types.Torrent{Name: e.Torrent, InfoHash: e.InfoHash}}
+		}
+		if _, err := cache.ReInsertTorrent(ct); err != nil {
+			return fmt.Errorf("failed to reinsert torrent: %w", err)
+		}
+	}
+
+	target := e.SymlinkTarget
+	if target == "" && e.File.IsSymlink {
+		return fmt.Errorf("symlink target of %s is unknown", e.File.Path)
+	}
+	if target == "" {
+		return nil
+	}
+	if _, err := os.Lstat(e.File.Path); err == nil {
+		// Already there, e.g. re-imported by the arr
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(e.File.Path), 0755); err != nil {
+		return err
+	}
+	if err := os.Symlink(target, e.File.Path); err != nil {
+		return fmt.Errorf("failed to restore symlink: %w", err)
+	}
+	return nil
+}
+
+// newFinding fills in the debrid and torrent behind a torrent folder, so the entry can be undone later
+func (r *Repair) newFinding(method DetectionMethod, reason, torrentPath string) finding {
+	f := finding{method: method, reason: reason}
+	if r.deb == nil {
+		return f
+	}
+	debridName := r.findDebridForPath(filepath.Dir(torrentPath), r.deb.Clients())
+	if debridName == "" {
+		return f
+	}
+	f.debrid = debridName
+	f.torrent = filepath.Clean(filepath.Base(torrentPath))
+	if cache, ok := r.deb.Caches()[debridName]; ok {
+		if ct := cache.GetTorrentByName(f.torrent); ct != nil && ct.Torrent != nil {
+			f.infoHash = ct.InfoHash
+		}
+	}
+	return f
+}
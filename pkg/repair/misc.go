@@ -1,13 +1,18 @@
 package repair
 
 import (
+	"bytes"
+	"context"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"slices"
 
 	"github.com/sirrobot01/decypharr/pkg/arr"
 	"github.com/sirrobot01/decypharr/pkg/debrid/common"
 	"github.com/sirrobot01/decypharr/pkg/debrid/store"
+	"go.uber.org/ratelimit"
 )
 
 func fileIsSymlinked(file string) bool {
@@ -33,7 +38,9 @@ func getSymlinkTarget(file string) string {
 	return ""
 }
 
-func fileIsReadable(filePath string) error {
+// fileIsReadable checks the file exists and reads its first 1kb. open reads the file from elsewhere than the path
+// e.g the debrid's cache, nil opens the path
+func fileIsReadable(filePath string, open func() (io.ReadCloser, error)) error {
 	// First check if file exists and is accessible
 	info, err := os.Stat(filePath)
 	if err != nil {
@@ -46,7 +53,12 @@ func fileIsReadable(filePath string) error {
 	}
 
 	// Try to read the first 1024 bytes
-	err = checkFileStart(filePath)
+	if open == nil {
+		open = func() (io.ReadCloser, error) {
+			return os.Open(filePath)
+		}
+	}
+	err = checkFileStart(open)
 	if err != nil {
 		return err
 	}
@@ -54,8 +66,8 @@ func fileIsReadable(filePath string) error {
 	return nil
 }
 
-func checkFileStart(filePath string) error {
-	f, err := os.Open(filePath)
+func checkFileStart(open func() (io.ReadCloser, error)) error {
+	f, err := open()
 	if err != nil {
 		return err
 	}
@@ -86,7 +98,7 @@ func collectFiles(media arr.Content) map[string][]arr.ContentFile {
 	return uniqueParents
 }
 
-func (r *Repair) checkTorrentFiles(torrentPath string, files []arr.ContentFile, clients map[string]common.Client, caches map[string]*store.Cache) []arr.ContentFile {
+func (r *Repair) checkTorrentFiles(job *Job, torrentPath string, files []arr.ContentFile, clients map[string]common.Client, caches map[string]*store.Cache) []arr.ContentFile {
 	brokenFiles := make([]arr.ContentFile, 0)
 
 	emptyFiles := make([]arr.ContentFile, 0)
@@ -120,6 +132,14 @@ func (r *Repair) checkTorrentFiles(torrentPath string, files []arr.ContentFile,
 	if !ok {
 		r.logger.Debug().Msgf("Can't find torrent %s in %s. Marking as broken", torrentName, debridName)
 		// Return all files as broken
+		for _, file := range files {
+			job.addFinding(file, finding{
+				method:  DetectionWebDav,
+				reason:  fmt.Sprintf("torrent not found in %s", debridName),
+				debrid:  debridName,
+				torrent: torrentName,
+			})
+		}
 		return files
 	}
 
@@ -142,6 +162,13 @@ func (r *Repair) checkTorrentFiles(torrentPath string, files []arr.ContentFile,
 		// Filter broken files
 		for _, contentFile := range files {
 			if brokenSet[contentFile.TargetPath] {
+				job.addFinding(contentFile, finding{
+					method:   DetectionWebDav,
+					reason:   "download link check failed",
+					debrid:   debridName,
+					torrent:  torrentName,
+					infoHash: torrent.InfoHash,
+				})
 				brokenFiles = append(brokenFiles, contentFile)
 			}
 		}
@@ -150,6 +177,35 @@ func (r *Repair) checkTorrentFiles(torrentPath string, files []arr.ContentFile,
 	return brokenFiles
 }
 
+// cachedFileOpener reads the start of the file through its debrid cache, sharing the chunk cache with
+// WebDAV and the mounts while still asking the debrid whether the file is there. nil when it can't
+func (r *Repair) cachedFileOpener(ctx context.Context, torrentPath string, file arr.ContentFile) func() (io.ReadCloser, error) {
+	debridName := r.findDebridForPath(filepath.Dir(torrentPath), r.deb.Clients())
+	cache, ok := r.deb.Caches()[debridName]
+	if !ok || !cache.ChunkCacheEnabled() {
+		return nil
+	}
+	torrentName := filepath.Clean(filepath.Base(torrentPath))
+	torrent := cache.GetTorrentByName(torrentName)
+	if torrent == nil {
+		return nil
+	}
+	f, ok := torrent.GetFile(file.TargetPath)
+	if !ok || f.Size == 0 {
+		return nil
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return func() (io.ReadCloser, error) {
+		data, err := cache.ReadFileStart(ctx, torrentName, f, 1024)
+		if err != nil {
+			return nil, err
+		}
+		return io.NopCloser(bytes.NewReader(data)), nil
+	}
+}
+
 func (r *Repair) findDebridForPath(dir string, clients map[string]common.Client) string {
 	// Check cache first
 	if debridName, exists := r.debridPathCache.Load(dir); exists {
@@ -178,3 +234,63 @@ func (r *Repair) findDebridForPath(dir string, clients map[string]common.Client)
 
 	return ""
 }
+
+// getCorruptFiles samples the files of media that aren't already broken, see verifier
+func (r *Repair) getCorruptFiles(job *Job, media arr.Content, brokenFiles []arr.ContentFile) []arr.ContentFile {
+	ctx := job.ctx
+	caches := r.deb.Caches()
+	if ctx == nil || len(caches) == 0 {
+		return nil
+	}
+	clients := r.deb.Clients()
+
+	var corruptFiles []arr.ContentFile
+	for torrentPath, files := range collectFiles(media) {
+		debridName := r.findDebridForPath(filepath.Dir(torrentPath), clients)
+		cache, ok := caches[debridName]
+		if !ok {
+			continue
+		}
+		torrentName := filepath.Clean(filepath.Base(torrentPath))
+		torrent := cache.GetTorrentByName(torrentName)
+		if torrent == nil {
+			continue
+		}
+		var limiter ratelimit.Limiter
+		if deb := r.deb.Debrid(debridName); deb != nil {
+			limiter = deb.RepairLimiter()
+		}
+
+		for _, file := range files {
+			if ctx.Err() != nil {
+				return corruptFiles
+			}
+			if slices.ContainsFunc(brokenFiles, func(f arr.ContentFile) bool { return f.Path == file.Path }) {
+				continue
+			}
+			f, ok := torrent.GetFile(file.TargetPath)
+			if !ok {
+				continue
+			}
+			v := &verifier{ctx: ctx, cache: cache, limiter: limiter, torrentName: torrentName, file: f}
+			err := v.verify()
+			if err == nil {
+				continue
+			}
+			if !isCorrupt(err) {
+				r.logger.Debug().Err(err).Msgf("Could not verify %s", file.Path)
+				continue
+			}
+			r.logger.Debug().Msgf("Corrupt file found at %s: %v", file.Path, err)
+			job.addFinding(file, finding{
+				method:   DetectionDeep,
+				reason:   err.Error(),
+				debrid:   debridName,
+				torrent:  torrentName,
+				infoHash: torrent.InfoHash,
+			})
+			corruptFiles = append(corruptFiles, file)
+		}
+	}
+	return corruptFiles
+}
@@ -18,10 +18,14 @@ import (
 
 	"github.com/go-co-op/gocron/v2"
 	"github.com/google/uuid"
+	"github.com/puzpuzpuz/xsync/v4"
 	"github.com/rs/zerolog"
 	"github.com/sirrobot01/decypharr/internal/config"
 	"github.com/sirrobot01/decypharr/internal/logger"
+	"github.com/sirrobot01/decypharr/internal/metrics"
+	"github.com/sirrobot01/decypharr/internal/notify"
 	"github.com/sirrobot01/decypharr/internal/request"
+	"github.com/sirrobot01/decypharr/internal/storage"
 	"github.com/sirrobot01/decypharr/internal/utils"
 	"github.com/sirrobot01/decypharr/pkg/arr"
 	"github.com/sirrobot01/decypharr/pkg/debrid"
@@ -29,24 +33,28 @@ import (
 )
 
 type Repair struct {
-	Jobs        map[string]*Job
-	arrs        *arr.Storage
-	deb         *debrid.Storage
-	interval    string
-	ZurgURL     string
-	IsZurg      bool
-	useWebdav   bool
-	autoProcess bool
-	logger      zerolog.Logger
-	filename    string
-	workers     int
-	scheduler   gocron.Scheduler
+	Jobs         map[string]*Job
+	arrs         *arr.Storage
+	deb          *debrid.Storage
+	interval     string
+	deepInterval string
+	ZurgURL      string
+	IsZurg       bool
+	useWebdav    bool
+	autoProcess  bool
+	logger       zerolog.Logger
+	filename     string
+	workers      int
+	scheduler    gocron.Scheduler
+	audit        *auditLog
 
 	debridPathCache sync.Map // debridPath:debridName cache.Emptied after each run
 	torrentsMap     sync.Map //debridName: map[string]*store.CacheTorrent. Emptied after each run
 	ctx             context.Context
 }
 
+var ErrJobRunning = errors.New("job already running")
+
 type JobStatus string
 
 const (
@@ -69,11 +77,13 @@ type Job struct {
 	FailedAt    time.Time                    `json:"failed_at"`
 	AutoProcess bool                         `json:"auto_process"`
 	Recurrent   bool                         `json:"recurrent"`
+	Deep        bool                         `json:"deep"` // Also samples the files, see verify.go
 
 	Error string `json:"error"`
 
 	cancelFunc context.CancelFunc
 	ctx        context.Context
+	findings   *xsync.Map[string, finding] // file path -> why it was marked as broken
 }
 
 func New(arrs *arr.Storage, engine *debrid.Storage) *Repair {
@@ -83,16 +93,18 @@ func New(arrs *arr.Storage, engine *debrid.Storage) *Repair {
 		workers = cfg.Repair.Workers
 	}
 	r := &Repair{
-		arrs:        arrs,
-		logger:      logger.New("repair"),
-		interval:    cfg.Repair.Interval,
-		ZurgURL:     cfg.Repair.ZurgURL,
-		useWebdav:   cfg.Repair.UseWebDav,
-		autoProcess: cfg.Repair.AutoProcess,
-		filename:    filepath.Join(cfg.Path, "repair.json"),
-		deb:         engine,
-		workers:     workers,
-		ctx:         context.Background(),
+		arrs:         arrs,
+		logger:       logger.New("repair"),
+		interval:     cfg.Repair.Interval,
+		deepInterval: cfg.Repair.DeepVerifyInterval,
+		ZurgURL:      cfg.Repair.ZurgURL,
+		useWebdav:    cfg.Repair.UseWebDav,
+		autoProcess:  cfg.Repair.AutoProcess,
+		filename:     filepath.Join(cfg.Path, "repair.json"),
+		deb:          engine,
+		workers:      workers,
+		ctx:          context.Background(),
+		audit:        newAuditLog(storage.Get()),
 	}
 	if r.ZurgURL != "" {
 		r.IsZurg = true
@@ -131,11 +143,29 @@ func (r *Repair) Start(ctx context.Context) error {
 		if err2 != nil {
 			r.logger.Error().Err(err2).Msg("Error creating repair job")
 		} else {
-			r.scheduler.Start()
 			r.logger.Info().Msgf("Repair job scheduled every %s", r.interval)
 		}
 	}
 
+	if r.deepInterval != "" {
+		if jd, err := utils.ConvertToJobDef(r.deepInterval); err != nil {
+			r.logger.Error().Err(err).Str("interval", r.deepInterval).Msg("Error converting deep verify interval")
+		} else if _, err := r.scheduler.NewJob(jd, gocron.NewTask(func() {
+			r.logger.Info().Msgf("Deep verify job started at %s", time.Now().Format("15:04:05"))
+			if err := r.AddDeepJob([]string{}, []string{}, r.autoProcess, true); err != nil {
+				r.logger.Error().Err(err).Msg("Error running deep verify job")
+			}
+		})); err != nil {
+			r.logger.Error().Err(err).Msg("Error creating deep verify job")
+		} else {
+			r.logger.Info().Msgf("Deep verify job scheduled every %s", r.deepInterval)
+		}
+	}
+
+	if len(r.scheduler.Jobs()) > 0 {
+		r.scheduler.Start()
+	}
+
 	<-ctx.Done()
 
 	r.logger.Info().Msg("Stopping repair scheduler")
@@ -144,7 +174,7 @@ func (r *Repair) Start(ctx context.Context) error {
 	return nil
 }
 
-func (j *Job) discordContext() string {
+func (j *Job) notifyMessage() string {
 	format := `
 		**ID**: %s
 		**Arrs**: %s
@@ -159,6 +189,23 @@ func (j *Job) discordContext() string {
 	return fmt.Sprintf(format, j.ID, strings.Join(j.Arrs, ","), strings.Join(j.MediaIDs, ", "), j.Status, j.StartedAt.Format(dateFmt), j.CompletedAt.Format(dateFmt))
 }
 
+// notifyData is exposed to notification templates as .Data
+func (j *Job) notifyData() map[string]any {
+	broken := 0
+	for _, items := range j.BrokenItems {
+		broken += len(items)
+	}
+	return map[string]any{
+		"id":           j.ID,
+		"arrs":         j.Arrs,
+		"media_ids":    j.MediaIDs,
+		"status":       string(j.Status),
+		"error":        j.Error,
+		"broken_items": broken,
+		"auto_process": j.AutoProcess,
+	}
+}
+
 func (r *Repair) getArrs(arrNames []string) []string {
 	arrs := make([]string, 0)
 	if len(arrNames) == 0 {
@@ -183,8 +230,12 @@ func (r *Repair) getArrs(arrNames []string) []string {
 	return arrs
 }
 
-func jobKey(arrNames []string, mediaIDs []string) string {
-	return fmt.Sprintf("%s-%s", strings.Join(arrNames, ","), strings.Join(mediaIDs, ","))
+func jobKey(arrNames []string, mediaIDs []string, deep bool) string {
+	key := fmt.Sprintf("%s-%s", strings.Join(arrNames, ","), strings.Join(mediaIDs, ","))
+	if deep {
+		return "deep:" + key
+	}
+	return key
 }
 
 func (r *Repair) reset(j *Job) {
@@ -195,6 +246,7 @@ func (r *Repair) reset(j *Job) {
 	j.FailedAt = time.Time{}
 	j.BrokenItems = nil
 	j.Error = ""
+	j.findings = xsync.NewMap[string, finding]()
 	if j.Recurrent || j.Arrs == nil {
 		j.Arrs = r.getArrs([]string{}) // Get new arrs
 	}
@@ -208,11 +260,15 @@ func (r *Repair) newJob(arrsNames []string, mediaIDs []string) *Job {
 		MediaIDs:  mediaIDs,
 		StartedAt: time.Now(),
 		Status:    JobStarted,
+		findings:  xsync.NewMap[string, finding](),
 	}
 }
 
 // initRun initializes the repair run, setting up necessary configurations, checks and caches
 func (r *Repair) initRun(ctx context.Context) {
+	if err := r.audit.prune(time.Now().Add(-auditRetention)); err != nil {
+		r.logger.Error().Err(err).Msg("Failed to prune repair audit log")
+	}
 	if r.useWebdav {
 		// Webdav use is enabled, initialize debrid torrent caches
 		caches := r.deb.Caches()
@@ -259,16 +315,27 @@ func (r *Repair) preRunChecks() error {
 }
 
 func (r *Repair) AddJob(arrsNames []string, mediaIDs []string, autoProcess, recurrent bool) error {
-	key := jobKey(arrsNames, mediaIDs)
+	return r.addJob(arrsNames, mediaIDs, autoProcess, recurrent, false)
+}
+
+// AddDeepJob is AddJob, but files that pass the usual checks are also sampled through the debrid cache
+// and their container headers checked. Only files on a WebDAV cache can be sampled
+func (r *Repair) AddDeepJob(arrsNames []string, mediaIDs []string, autoProcess, recurrent bool) error {
+	return r.addJob(arrsNames, mediaIDs, autoProcess, recurrent, true)
+}
+
+func (r *Repair) addJob(arrsNames []string, mediaIDs []string, autoProcess, recurrent, deep bool) error {
+	key := jobKey(arrsNames, mediaIDs, deep)
 	job, ok := r.Jobs[key]
 	if job != nil && job.Status == JobStarted {
-		return fmt.Errorf("job already running")
+		return ErrJobRunning
 	}
 	if !ok {
 		job = r.newJob(arrsNames, mediaIDs)
 	}
 	job.AutoProcess = autoProcess
 	job.Recurrent = recurrent
+	job.Deep = deep
 	r.reset(job)
 
 	job.ctx, job.cancelFunc = context.WithCancel(r.ctx)
@@ -289,6 +356,7 @@ func (r *Repair) AddJob(arrsNames []string, mediaIDs []string, autoProcess, recu
 				job.CompletedAt = time.Now()
 			}
 		}
+		recordOutcome(job)
 		r.onComplete() // Clear caches and maps after job completion
 	}()
 	return nil
@@ -386,6 +454,7 @@ func (r *Repair) repair(job *Job) error {
 			job.Status = JobCancelled
 			job.CompletedAt = time.Now()
 			job.Error = "Job was cancelled"
+			notify.Send(notify.EventRepairCancelled, "warning", job.notifyMessage(), job.notifyData())
 			return fmt.Errorf("job cancelled")
 		}
 
@@ -393,11 +462,7 @@ func (r *Repair) repair(job *Job) error {
 		job.Error = err.Error()
 		job.Status = JobFailed
 		job.CompletedAt = time.Now()
-		go func() {
-			if err := request.SendDiscordMessage("repair_failed", "error", job.discordContext()); err != nil {
-				r.logger.Error().Msgf("Error sending discord message: %v", err)
-			}
-		}()
+		notify.Send(notify.EventRepairFailed, "error", job.notifyMessage(), job.notifyData())
 		return err
 	}
 
@@ -405,11 +470,7 @@ func (r *Repair) repair(job *Job) error {
 		job.CompletedAt = time.Now()
 		job.Status = JobCompleted
 
-		go func() {
-			if err := request.SendDiscordMessage("repair_complete", "success", job.discordContext()); err != nil {
-				r.logger.Error().Msgf("Error sending discord message: %v", err)
-			}
-		}()
+		notify.Send(notify.EventRepairComplete, "success", job.notifyMessage(), job.notifyData())
 
 		return nil
 	}
@@ -419,18 +480,10 @@ func (r *Repair) repair(job *Job) error {
 		// Job is already processed
 		job.CompletedAt = time.Now() // Mark as completed
 		job.Status = JobCompleted
-		go func() {
-			if err := request.SendDiscordMessage("repair_complete", "success", job.discordContext()); err != nil {
-				r.logger.Error().Msgf("Error sending discord message: %v", err)
-			}
-		}()
+		notify.Send(notify.EventRepairComplete, "success", job.notifyMessage(), job.notifyData())
 	} else {
 		job.Status = JobPending
-		go func() {
-			if err := request.SendDiscordMessage("repair_pending", "pending", job.discordContext()); err != nil {
-				r.logger.Error().Msgf("Error sending discord message: %v", err)
-			}
-		}()
+		notify.Send(notify.EventRepairPending, "pending", job.notifyMessage(), job.notifyData())
 	}
 	return nil
 }
@@ -440,11 +493,13 @@ func (r *Repair) repairArr(job *Job, _arr string, tmdbId string) ([]arr.ContentF
 	a := r.arrs.Get(_arr)
 
 	r.logger.Info().Msgf("Starting repair for %s", a.Name)
-	media, err := a.GetMedia(tmdbId)
+	mediaId, scope := parseMediaID(tmdbId)
+	media, err := a.GetMedia(mediaId)
 	if err != nil {
 		r.logger.Info().Msgf("Failed to get %s media: %v", a.Name, err)
 		return brokenItems, err
 	}
+	media = scope.filter(media)
 	r.logger.Info().Msgf("Found %d %s media", len(media), a.Name)
 
 	if len(media) == 0 {
@@ -475,17 +530,13 @@ func (r *Repair) repairArr(job *Job, _arr string, tmdbId string) ([]arr.ContentF
 				items := r.getBrokenFiles(job, m)
 				if items != nil {
 					r.logger.Debug().Msgf("Found %d broken files for %s", len(items), m.Title)
+					r.recordBroken(job, a.Name, m.Title, items)
 					if job.AutoProcess {
 						r.logger.Info().Msgf("Auto processing %d broken items for %s", len(items), m.Title)
 
-						// Delete broken items
-						if err := a.DeleteFiles(items); err != nil {
-							r.logger.Debug().Msgf("Failed to delete broken items for %s: %v", m.Title, err)
-						}
-
-						// Search for missing items
-						if err := a.SearchMissing(items); err != nil {
-							r.logger.Debug().Msgf("Failed to search missing items for %s: %v", m.Title, err)
+						// Delete broken items and search for missing items
+						if err := r.processBroken(job, a, items); err != nil {
+							r.logger.Debug().Msgf("Failed to process broken items for %s: %v", m.Title, err)
 						}
 					}
 
@@ -551,14 +602,21 @@ func (r *Repair) checkMountUp(media []arr.Content) error {
 }
 
 func (r *Repair) getBrokenFiles(job *Job, media arr.Content) []arr.ContentFile {
-
+	var brokenFiles []arr.ContentFile
 	if r.useWebdav {
-		return r.getWebdavBrokenFiles(job, media)
+		brokenFiles = r.getWebdavBrokenFiles(job, media)
 	} else if r.IsZurg {
-		return r.getZurgBrokenFiles(job, media)
+		brokenFiles = r.getZurgBrokenFiles(job, media)
 	} else {
-		return r.getFileBrokenFiles(job, media)
+		brokenFiles = r.getFileBrokenFiles(job, media)
+	}
+	if job.Deep {
+		brokenFiles = append(brokenFiles, r.getCorruptFiles(job, media, brokenFiles)...)
 	}
+	if len(brokenFiles) == 0 {
+		return nil
+	}
+	return brokenFiles
 }
 
 func (r *Repair) getFileBrokenFiles(job *Job, media arr.Content) []arr.ContentFile {
@@ -572,8 +630,9 @@ func (r *Repair) getFileBrokenFiles(job *Job, media arr.Content) []arr.ContentFi
 		// Check stat
 		// Check file stat first
 		for _, file := range files {
-			if err := fileIsReadable(file.Path); err != nil {
+			if err := fileIsReadable(file.Path, r.cachedFileOpener(job.ctx, parent, file)); err != nil {
 				r.logger.Debug().Msgf("Broken file found at: %s", parent)
+				job.addFinding(file, r.newFinding(DetectionSymlink, err.Error(), parent))
 				brokenFiles = append(brokenFiles, file)
 			}
 		}
@@ -615,12 +674,14 @@ func (r *Repair) getZurgBrokenFiles(job *Job, media arr.Content) []arr.ContentFi
 			fullURL := fmt.Sprintf("%s/http/__all__/%s/%s", r.ZurgURL, torrentName, encodedFile)
 			if _, err := os.Stat(file.Path); os.IsNotExist(err) {
 				r.logger.Debug().Msgf("Broken symlink found: %s", fullURL)
+				job.addFinding(file, r.newFinding(DetectionZurg, "broken symlink", parent))
 				brokenFiles = append(brokenFiles, file)
 				continue
 			}
 			resp, err := client.Get(fullURL)
 			if err != nil {
 				r.logger.Error().Err(err).Msgf("Failed to reach %s", fullURL)
+				job.addFinding(file, r.newFinding(DetectionZurg, err.Error(), parent))
 				brokenFiles = append(brokenFiles, file)
 				continue
 			}
@@ -629,6 +690,7 @@ func (r *Repair) getZurgBrokenFiles(job *Job, media arr.Content) []arr.ContentFi
 				if err := resp.Body.Close(); err != nil {
 					return nil
 				}
+				job.addFinding(file, r.newFinding(DetectionZurg, fmt.Sprintf("zurg returned %s", resp.Status), parent))
 				brokenFiles = append(brokenFiles, file)
 				continue
 			}
@@ -641,6 +703,7 @@ func (r *Repair) getZurgBrokenFiles(job *Job, media arr.Content) []arr.ContentFi
 				r.logger.Trace().Msgf("Found download url: %s", downloadUrl)
 			} else {
 				r.logger.Debug().Msgf("Failed to get download url for %s", fullURL)
+				job.addFinding(file, r.newFinding(DetectionZurg, "no download url", parent))
 				brokenFiles = append(brokenFiles, file)
 				continue
 			}
@@ -677,7 +740,7 @@ func (r *Repair) getWebdavBrokenFiles(job *Job, media arr.Content) []arr.Content
 			return brokenFiles
 		default:
 		}
-		brokenFilesForTorrent := r.checkTorrentFiles(torrentPath, files, clients, caches)
+		brokenFilesForTorrent := r.checkTorrentFiles(job, torrentPath, files, clients, caches)
 		if len(brokenFilesForTorrent) > 0 {
 			brokenFiles = append(brokenFiles, brokenFilesForTorrent...)
 		}
@@ -733,6 +796,7 @@ func (r *Repair) ProcessJob(id string) error {
 		r.logger.Info().Msgf("No broken items found for job %s", id)
 		job.CompletedAt = time.Now()
 		job.Status = JobCompleted
+		recordOutcome(job)
 		return nil
 	}
 
@@ -760,14 +824,8 @@ func (r *Repair) ProcessJob(id string) error {
 				return nil
 			}
 
-			if err := a.DeleteFiles(items); err != nil {
-				r.logger.Error().Err(err).Msgf("Failed to delete broken items for %s", arrName)
-				return nil
-			}
-			// Search for missing items
-			if err := a.SearchMissing(items); err != nil {
-				r.logger.Error().Err(err).Msgf("Failed to search missing items for %s", arrName)
-				return nil
+			if err := r.processBroken(job, a, items); err != nil {
+				r.logger.Error().Err(err).Msgf("Failed to process broken items for %s", arrName)
 			}
 			return nil
 		})
@@ -790,6 +848,7 @@ func (r *Repair) ProcessJob(id string) error {
 			job.Status = JobCompleted
 			r.logger.Info().Msgf("Job %s completed successfully", id)
 		}
+		recordOutcome(job)
 
 		r.saveToFile()
 	}()
@@ -797,6 +856,14 @@ func (r *Repair) ProcessJob(id string) error {
 	return nil
 }
 
+// recordOutcome counts the job in the repair metrics once it reaches a final state
+func recordOutcome(job *Job) {
+	switch job.Status {
+	case JobCompleted, JobFailed, JobCancelled:
+		metrics.IncRepairJob(string(job.Status))
+	}
+}
+
 func (r *Repair) saveToFile() {
 	// Save jobs to file
 	data, err := json.Marshal(r.Jobs)
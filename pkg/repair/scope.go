@@ -0,0 +1,65 @@
+package repair
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/sirrobot01/decypharr/pkg/arr"
+)
+
+// mediaScope narrows a media ID down to a season or a single episode, e.g "81189:S01E02"
+type mediaScope struct {
+	season  int // 0 for the whole media
+	episode int // 0 for the whole season
+}
+
+// ScopedMediaID returns a media ID that only repairs the given season/episode of a series
+func ScopedMediaID(id string, season, episode int) string {
+	if season <= 0 {
+		return id
+	}
+	if episode <= 0 {
+		return fmt.Sprintf("%s:S%02d", id, season)
+	}
+	return fmt.Sprintf("%s:S%02dE%02d", id, season, episode)
+}
+
+func parseMediaID(mediaID string) (string, mediaScope) {
+	id, scope, ok := strings.Cut(mediaID, ":")
+	if !ok {
+		return mediaID, mediaScope{}
+	}
+	var s mediaScope
+	scope = strings.ToUpper(scope)
+	if _, err := fmt.Sscanf(scope, "S%dE%d", &s.season, &s.episode); err != nil {
+		s.episode = 0
+		if _, err := fmt.Sscanf(scope, "S%d", &s.season); err != nil {
+			return id, mediaScope{}
+		}
+	}
+	return id, s
+}
+
+func (s mediaScope) filter(media []arr.Content) []arr.Content {
+	if s.season == 0 {
+		return media
+	}
+	filtered := make([]arr.Content, 0, len(media))
+	for _, m := range media {
+		files := make([]arr.ContentFile, 0)
+		for _, f := range m.Files {
+			if f.SeasonNumber != s.season {
+				continue
+			}
+			if s.episode != 0 && f.EpisodeNumber != s.episode {
+				continue
+			}
+			files = append(files, f)
+		}
+		if len(files) > 0 {
+			m.Files = files
+			filtered = append(filtered, m)
+		}
+	}
+	return filtered
+}
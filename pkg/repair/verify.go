@@ -0,0 +1,526 @@
+package repair
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/sirrobot01/decypharr/pkg/debrid/store"
+	"github.com/sirrobot01/decypharr/pkg/debrid/types"
+	"go.uber.org/ratelimit"
+)
+
+const (
+	// Bytes read per sample. The head sample also holds the container headers
+	sampleSize = 64 * 1024
+	// Samples spread over the file between the head and the tail
+	middleSamples = 3
+	// Extra samples read around a zero-filled one before the file is flagged, files can have runs of zeros of their own
+	zeroConfirmSamples = 2
+	// Distance between the extra samples, past the zero padding containers use
+	zeroConfirmStride = 1 << 20
+	// MP4 boxes walked before giving up on finding moov, each one past the head sample is a request
+	maxMP4Boxes = 64
+	// Matroska seek entries followed, each one past the head sample is a request
+	maxSeekEntries = 8
+)
+
+// Matroska element ids
+const (
+	ebmlHeader      = 0x1A45DFA3
+	ebmlDocType     = 0x4282
+	mkvSegment      = 0x18538067
+	mkvSeekHead     = 0x114D9B74
+	mkvSeek         = 0x4DBB
+	mkvSeekID       = 0x53AB
+	mkvSeekPosition = 0x53AC
+	mkvInfo         = 0x1549A966
+	mkvTracks       = 0x1654AE6B
+	mkvCluster      = 0x1F43B675
+	mkvCues         = 0x1C53BB6B
+	mkvChapters     = 0x1043A770
+	mkvTags         = 0x1254C367
+	mkvAttachments  = 0x1941A469
+	mkvVoid         = 0xEC
+	mkvCRC32        = 0xBF
+)
+
+// Elements that can follow each other at the top of a segment
+var mkvTopLevel = map[uint64]bool{
+	mkvSeekHead: true, mkvInfo: true, mkvTracks: true, mkvCluster: true, mkvCues: true,
+	mkvChapters: true, mkvTags: true, mkvAttachments: true, mkvVoid: true, mkvCRC32: true,
+}
+
+// corruptError is something wrong with the file itself. Any other error means it couldn't be checked
+type corruptError struct {
+	reason string
+}
+
+func (e *corruptError) Error() string {
+	return e.reason
+}
+
+func corruptf(format string, args ...any) error {
+	return &corruptError{reason: fmt.Sprintf(format, args...)}
+}
+
+func isCorrupt(err error) bool {
+	var ce *corruptError
+	return errors.As(err, &ce)
+}
+
+// verifier samples byte ranges of a file through the debrid cache and checks the container headers,
+// for files the debrid says are alive but are truncated or corrupt
+type verifier struct {
+	ctx         context.Context
+	cache       *store.Cache
+	limiter     ratelimit.Limiter
+	torrentName string
+	file        types.File
+}
+
+func (v *verifier) verify() error {
+	size := v.file.Size
+	if size <= 0 {
+		return corruptf("file is empty")
+	}
+
+	head, err := v.read(0, sampleSize)
+	if err != nil {
+		return err
+	}
+	ext := strings.ToLower(filepath.Ext(v.file.Name))
+	switch {
+	case ext == ".mkv" || ext == ".mka" || ext == ".webm" || bytes.HasPrefix(head, []byte{0x1A, 0x45, 0xDF, 0xA3}):
+		err = v.verifyMatroska(head)
+	case ext == ".mp4" || ext == ".m4v" || ext == ".mov" || (len(head) >= 8 && string(head[4:8]) == "ftyp"):
+		err = v.verifyMP4(head)
+	}
+	if err != nil {
+		return err
+	}
+	if size <= sampleSize {
+		return nil
+	}
+
+	offsets := make([]int64, 0, middleSamples+1)
+	for i := int64(1); i <= middleSamples; i++ {
+		offsets = append(offsets, size*i/(middleSamples+1))
+	}
+	offsets = append(offsets, size-sampleSize)
+	for _, offset := range offsets {
+		data, err := v.read(offset, sampleSize)
+		if err != nil {
+			return err
+		}
+		// Debrids fill missing pieces with zeros
+		if isZeroed(data) {
+			if err := v.confirmZeroed(offset); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// confirmZeroed samples more windows around a zero-filled sample at offset, the file is only corrupt if they're all
+// zero-filled too. Windows that don't fit after the sample are taken before it
+func (v *verifier) confirmZeroed(offset int64) error {
+	confirmed := 0
+	for i := int64(1); i <= zeroConfirmSamples; i++ {
+		at := offset + i*zeroConfirmStride
+		if at+sampleSize > v.file.Size {
+			at = offset - i*zeroConfirmStride
+		}
+		if at < sampleSize {
+			// The file is too small to tell zero padding from a missing piece
+			return nil
+		}
+		data, err := v.read(at, sampleSize)
+		if err != nil {
+			return err
+		}
+		if !isZeroed(data) {
+			return nil
+		}
+		confirmed++
+	}
+	return corruptf("zero-filled data at offset %d and %d windows around it", offset, confirmed)
+}
+
+// read returns length bytes at offset, a short read means the file is truncated
+func (v *verifier) read(offset, length int64) ([]byte, error) {
+	length = min(length, v.file.Size-offset)
+	if length <= 0 {
+		return nil, corruptf("offset %d is past the end of the file", offset)
+	}
+	end := offset + length - 1
+
+	var body io.ReadCloser
+	if len(v.file.Parts) > 0 {
+		// Every part touched is a request of its own
+		for range partsIn(v.file.Parts, offset, end) {
+			v.take()
+		}
+		body = v.cache.StreamParts(v.ctx, v.torrentName, v.file.Parts, offset, end)
+	} else {
+		base := int64(0)
+		if v.file.ByteRange != nil {
+			base = v.file.ByteRange[0]
+		}
+		v.take()
+		resp, err := v.cache.Stream(v.ctx, base+offset, base+end, v.downloadLink)
+		if err != nil {
+			return nil, err
+		}
+		if resp.StatusCode != http.StatusPartialContent && base+offset > 0 {
+			_ = resp.Body.Close()
+			return nil, fmt.Errorf("range request ignored, got HTTP %d", resp.StatusCode)
+		}
+		if total := contentRangeTotal(resp.Header.Get("Content-Range")); v.file.ByteRange == nil && total > 0 && total != v.file.Size {
+			_ = resp.Body.Close()
+			return nil, corruptf("debrid file is %d bytes, expected %d", total, v.file.Size)
+		}
+		body = resp.Body
+	}
+	defer body.Close()
+
+	buf := make([]byte, length)
+	n, err := io.ReadFull(body, buf)
+	if errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, io.EOF) {
+		return nil, corruptf("truncated, got %d of %d bytes at offset %d", n, length, offset)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return buf, nil
+}
+
+// at returns length bytes at offset, from the head sample when it's in there
+func (v *verifier) at(head []byte, offset, length int64) ([]byte, error) {
+	if offset+length <= int64(len(head)) {
+		return head[offset : offset+length], nil
+	}
+	return v.read(offset, length)
+}
+
+func (v *verifier) take() {
+	if v.limiter != nil {
+		v.limiter.Take()
+	}
+}
+
+func (v *verifier) downloadLink() (types.DownloadLink, error) {
+	dl, err := v.cache.GetDownloadLink(v.torrentName, v.file.Name, v.file.Link)
+	if err != nil {
+		return dl, err
+	}
+	return dl, dl.Valid()
+}
+
+// verifyMatroska checks the EBML header, the segment size against the file size
+// and that the seek head points at the elements it lists
+func (v *verifier) verifyMatroska(head []byte) error {
+	id, n, err := ebmlID(head)
+	if err != nil || id != ebmlHeader {
+		return corruptf("missing EBML header")
+	}
+	size, m, err := ebmlSize(head[n:])
+	if err != nil || size < 0 || int64(n+m)+size > int64(len(head)) {
+		return corruptf("invalid EBML header")
+	}
+	header := head[n+m : int64(n+m)+size]
+	docType := ""
+	err = ebmlChildren(header, func(id uint64, data []byte) bool {
+		if id == ebmlDocType {
+			docType = strings.TrimRight(string(data), "\x00")
+			return false
+		}
+		return true
+	})
+	if err != nil {
+		return corruptf("invalid EBML header: %v", err)
+	}
+	if docType != "matroska" && docType != "webm" {
+		return corruptf("unknown EBML doctype %q", docType)
+	}
+
+	offset := int64(n+m) + size
+	id, n, err = ebmlID(head[offset:])
+	if err != nil || id != mkvSegment {
+		return corruptf("missing segment at offset %d", offset)
+	}
+	segmentSize, m, err := ebmlSize(head[offset+int64(n):])
+	if err != nil {
+		return corruptf("invalid segment size")
+	}
+	segmentStart := offset + int64(n+m)
+	// Unknown sizes are allowed, e.g for live recordings
+	if segmentSize >= 0 && segmentStart+segmentSize > v.file.Size {
+		return corruptf("truncated, segment needs %d bytes but the file has %d", segmentStart+segmentSize, v.file.Size)
+	}
+
+	if segmentStart >= int64(len(head)) {
+		return nil
+	}
+	id, n, err = ebmlID(head[segmentStart:])
+	if err != nil || !mkvTopLevel[id] {
+		return corruptf("unknown element %x at the start of the segment", id)
+	}
+	if id != mkvSeekHead {
+		return nil
+	}
+	seekSize, m, err := ebmlSize(head[segmentStart+int64(n):])
+	if err != nil || seekSize < 0 {
+		return corruptf("invalid seek head")
+	}
+	seekStart := segmentStart + int64(n+m)
+	seekHead := head[seekStart:min(seekStart+seekSize, int64(len(head)))]
+
+	entries := 0
+	var seekErr error
+	_ = ebmlChildren(seekHead, func(id uint64, data []byte) bool {
+		if id != mkvSeek {
+			return true
+		}
+		var target []byte
+		position := int64(-1)
+		_ = ebmlChildren(data, func(id uint64, data []byte) bool {
+			switch id {
+			case mkvSeekID:
+				target = data
+			case mkvSeekPosition:
+				position = int64(readUint(data))
+			}
+			return true
+		})
+		if len(target) == 0 || position < 0 {
+			return true
+		}
+		entries++
+		if entries > maxSeekEntries {
+			return false
+		}
+		seekErr = v.checkSeekEntry(head, segmentStart, readUint(target), position)
+		return seekErr == nil
+	})
+	return seekErr
+}
+
+func (v *verifier) checkSeekEntry(head []byte, segmentStart int64, target uint64, position int64) error {
+	offset := segmentStart + position
+	if offset >= v.file.Size {
+		return corruptf("truncated, element %x should be at offset %d but the file has %d bytes", target, offset, v.file.Size)
+	}
+	data, err := v.at(head, offset, min(8, v.file.Size-offset))
+	if err != nil {
+		return err
+	}
+	if id, _, err := ebmlID(data); err != nil || id != target {
+		return corruptf("element %x should be at offset %d, found %x", target, offset, id)
+	}
+	return nil
+}
+
+// verifyMP4 walks the top level boxes, checking that each fits in the file and that there's a moov with an mvhd
+func (v *verifier) verifyMP4(head []byte) error {
+	size := v.file.Size
+	offset := int64(0)
+	moov := false
+	for i := 0; offset < size; i++ {
+		if i == maxMP4Boxes {
+			// Too many boxes to walk, what was seen so far is fine
+			return nil
+		}
+		if size-offset < 8 {
+			return corruptf("truncated box header at offset %d", offset)
+		}
+		header, err := v.at(head, offset, min(16, size-offset))
+		if err != nil {
+			return err
+		}
+		boxSize := int64(binary.BigEndian.Uint32(header))
+		boxType := string(header[4:8])
+		if !isBoxType(boxType) {
+			return corruptf("invalid box type %q at offset %d", boxType, offset)
+		}
+		headerSize := int64(8)
+		switch boxSize {
+		case 0:
+			// Runs to the end of the file
+			boxSize = size - offset
+		case 1:
+			if len(header) < 16 {
+				return corruptf("truncated box header at offset %d", offset)
+			}
+			boxSize = int64(binary.BigEndian.Uint64(header[8:]))
+			headerSize = 16
+		}
+		if boxSize < headerSize {
+			return corruptf("invalid %s box size %d at offset %d", boxType, boxSize, offset)
+		}
+		if offset+boxSize > size {
+			return corruptf("truncated, %s box at offset %d needs %d bytes but the file has %d", boxType, offset, boxSize, size)
+		}
+		if boxType == "moov" {
+			if err := v.verifyMoov(head, offset+headerSize, boxSize-headerSize); err != nil {
+				return err
+			}
+			moov = true
+		}
+		offset += boxSize
+	}
+	if !moov {
+		return corruptf("no moov box")
+	}
+	return nil
+}
+
+func (v *verifier) verifyMoov(head []byte, offset, size int64) error {
+	data, err := v.at(head, offset, min(size, sampleSize))
+	if err != nil {
+		return err
+	}
+	for pos := int64(0); pos+8 <= int64(len(data)); {
+		boxSize := int64(binary.BigEndian.Uint32(data[pos:]))
+		boxType := string(data[pos+4 : pos+8])
+		if !isBoxType(boxType) || boxSize < 8 || pos+boxSize > size {
+			return corruptf("invalid %q box in moov at offset %d", boxType, offset+pos)
+		}
+		if boxType == "mvhd" {
+			return nil
+		}
+		pos += boxSize
+	}
+	if size <= sampleSize {
+		return corruptf("moov has no mvhd")
+	}
+	return nil
+}
+
+// ebmlID reads an element id, the length marker is kept like in the spec's ids
+func ebmlID(b []byte) (uint64, int, error) {
+	if len(b) == 0 || b[0] == 0 {
+		return 0, 0, errors.New("invalid element id")
+	}
+	n := vintLength(b[0])
+	if n > 4 || len(b) < n {
+		return 0, 0, errors.New("invalid element id")
+	}
+	return readUint(b[:n]), n, nil
+}
+
+// ebmlSize reads an element size, -1 is an unknown size
+func ebmlSize(b []byte) (int64, int, error) {
+	if len(b) == 0 || b[0] == 0 {
+		return 0, 0, errors.New("invalid element size")
+	}
+	n := vintLength(b[0])
+	if len(b) < n {
+		return 0, 0, errors.New("invalid element size")
+	}
+	value := uint64(b[0] & (0xFF >> n))
+	unknown := value == uint64(0xFF>>n)
+	for _, c := range b[1:n] {
+		value = value<<8 | uint64(c)
+		unknown = unknown && c == 0xFF
+	}
+	if unknown {
+		return -1, n, nil
+	}
+	if value > 1<<56 {
+		return 0, 0, errors.New("invalid element size")
+	}
+	return int64(value), n, nil
+}
+
+func vintLength(first byte) int {
+	n := 1
+	for mask := byte(0x80); first&mask == 0; mask >>= 1 {
+		n++
+	}
+	return n
+}
+
+// ebmlChildren calls fn with each child element of data, until fn returns false
+func ebmlChildren(data []byte, fn func(id uint64, data []byte) bool) error {
+	for pos := 0; pos < len(data); {
+		id, n, err := ebmlID(data[pos:])
+		if err != nil {
+			return err
+		}
+		size, m, err := ebmlSize(data[pos+n:])
+		if err != nil {
+			return err
+		}
+		start := pos + n + m
+		if size < 0 || int64(start)+size > int64(len(data)) {
+			// Cut off by the end of the sample
+			return nil
+		}
+		if !fn(id, data[start:start+int(size)]) {
+			return nil
+		}
+		pos = start + int(size)
+	}
+	return nil
+}
+
+func readUint(b []byte) uint64 {
+	var value uint64
+	for _, c := range b {
+		value = value<<8 | uint64(c)
+	}
+	return value
+}
+
+func isBoxType(t string) bool {
+	for i := 0; i < len(t); i++ {
+		if t[i] < 0x20 || t[i] > 0x7E {
+			return false
+		}
+	}
+	return true
+}
+
+func isZeroed(data []byte) bool {
+	for _, c := range data {
+		if c != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// partsIn returns the parts holding start-end(inclusive) of the file
+func partsIn(parts []types.FilePart, start, end int64) []types.FilePart {
+	in := make([]types.FilePart, 0, 1)
+	offset := int64(0)
+	for _, part := range parts {
+		if offset <= end && offset+part.Size() > start {
+			in = append(in, part)
+		}
+		offset += part.Size()
+	}
+	return in
+}
+
+// contentRangeTotal returns the full size from a Content-Range header, 0 if there's none
+func contentRangeTotal(header string) int64 {
+	_, total, ok := strings.Cut(header, "/")
+	if !ok {
+		return 0
+	}
+	n, err := strconv.ParseInt(total, 10, 64)
+	if err != nil {
+		return 0
+	}
+	return n
+}
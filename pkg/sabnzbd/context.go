@@ -0,0 +1,117 @@
+package sabnzbd
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/sirrobot01/decypharr/internal/config"
+	"github.com/sirrobot01/decypharr/internal/request"
+	"github.com/sirrobot01/decypharr/pkg/arr"
+	"github.com/sirrobot01/decypharr/pkg/wire"
+)
+
+type contextKey string
+
+const (
+	categoryKey contextKey = "category"
+	arrKey      contextKey = "arr"
+)
+
+func getCategory(ctx context.Context) string {
+	if category, ok := ctx.Value(categoryKey).(string); ok {
+		return category
+	}
+	return ""
+}
+
+func getArrFromContext(ctx context.Context) *arr.Arr {
+	if a, ok := ctx.Value(arrKey).(*arr.Arr); ok {
+		return a
+	}
+	return nil
+}
+
+func (s *SABnzbd) categoryContext(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// The arrs send "cat" for add/queue calls and "category" for history
+		category := r.FormValue("cat")
+		if category == "" {
+			category = r.FormValue("category")
+		}
+		if category == "*" {
+			category = ""
+		}
+		ctx := context.WithValue(r.Context(), categoryKey, strings.TrimSpace(category))
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// authContext authenticates the request using the SABnzbd apikey and ma_username/ma_password params.
+// The apikey is either decypharr's API token or the Arr's token, ma_username can carry the Arr host like the qbit username does
+func (s *SABnzbd) authContext(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.FormValue("mode") == "version" {
+			// SABnzbd does not require auth for the version
+			next.ServeHTTP(w, r)
+			return
+		}
+		apiKey := strings.TrimSpace(r.FormValue("apikey"))
+		username := strings.TrimSpace(r.FormValue("ma_username"))
+		password := strings.TrimSpace(r.FormValue("ma_password"))
+		a, err := s.authenticate(getCategory(r.Context()), apiKey, username, password)
+		if err != nil {
+			request.JSONResponse(w, StatusResponse{Status: false, Error: err.Error()}, http.StatusUnauthorized)
+			return
+		}
+		ctx := context.WithValue(r.Context(), arrKey, a)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+func (s *SABnzbd) authenticate(category, apiKey, username, password string) (*arr.Arr, error) {
+	cfg := config.Get()
+	arrs := wire.Get().Arr()
+	// Check if arr exists
+	a := arrs.Get(category)
+	if a == nil {
+		// Arr is not configured, create a new one
+		downloadUncached := false
+		a = arr.New(category, "", "", false, false, &downloadUncached, "", "auto")
+	}
+	if auth := cfg.GetAuth(); auth != nil && auth.APIToken != "" && apiKey == auth.APIToken {
+		// Decypharr's own token, it's not the arr token
+		return a, nil
+	}
+	if strings.HasPrefix(username, "http") {
+		a.Host = username
+	}
+	if apiKey != "" {
+		a.Token = apiKey
+	}
+	if !cfg.UseAuth {
+		s.addArr(a)
+		return a, nil
+	}
+
+	if a.Host != "" && a.Token != "" {
+		if err := a.Validate(); err == nil {
+			s.addArr(a)
+			return a, nil
+		}
+	}
+	if config.VerifyAuth(username, password) {
+		return a, nil
+	}
+	return nil, fmt.Errorf("API Key Incorrect")
+}
+
+// addArr stores the arr if it was sent along with a category
+func (s *SABnzbd) addArr(a *arr.Arr) {
+	if a.Name == "" {
+		return
+	}
+	a.Source = "auto"
+	wire.Get().Arr().AddOrUpdate(a)
+}
@@ -0,0 +1,203 @@
+package sabnzbd
+
+import (
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"strings"
+
+	"github.com/sirrobot01/decypharr/internal/request"
+	"github.com/sirrobot01/decypharr/pkg/arr"
+	"github.com/sirrobot01/decypharr/pkg/wire"
+)
+
+const version = "4.3.2"
+
+func (s *SABnzbd) handleAPI(w http.ResponseWriter, r *http.Request) {
+	mode := r.FormValue("mode")
+	switch mode {
+	case "version":
+		request.JSONResponse(w, VersionResponse{Version: version}, http.StatusOK)
+	case "get_config":
+		s.handleGetConfig(w, r)
+	case "fullstatus":
+		request.JSONResponse(w, FullStatusResponse{Status: FullStatus{CompleteDir: s.DownloadFolder}}, http.StatusOK)
+	case "get_cats":
+		s.handleGetCategories(w, r)
+	case "addfile":
+		s.handleAddFile(w, r)
+	case "addurl":
+		s.handleAddUrl(w, r)
+	case "queue":
+		s.handleQueue(w, r)
+	case "history":
+		s.handleHistory(w, r)
+	default:
+		request.JSONResponse(w, StatusResponse{Status: false, Error: fmt.Sprintf("not implemented: %s", mode)}, http.StatusBadRequest)
+	}
+}
+
+func (s *SABnzbd) handleGetConfig(w http.ResponseWriter, r *http.Request) {
+	categories := make([]Category, 0, len(s.Categories)+1)
+	categories = append(categories, Category{Name: "*", Dir: "", Priority: -100})
+	for i, cat := range s.Categories {
+		categories = append(categories, Category{
+			Name:     cat,
+			Order:    i + 1,
+			Dir:      cat,
+			Priority: -100,
+		})
+	}
+	res := ConfigResponse{
+		Config: Config{
+			Misc: Misc{
+				CompleteDir:     s.DownloadFolder,
+				DownloadDir:     filepath.Join(s.DownloadFolder, "incomplete"),
+				TvCategories:    []string{},
+				MovieCategories: []string{},
+				DateCategories:  []string{},
+			},
+			Categories: categories,
+			Sorters:    []any{},
+		},
+	}
+	request.JSONResponse(w, res, http.StatusOK)
+}
+
+func (s *SABnzbd) handleGetCategories(w http.ResponseWriter, r *http.Request) {
+	categories := append([]string{"*"}, s.Categories...)
+	request.JSONResponse(w, CategoriesResponse{Categories: categories}, http.StatusOK)
+}
+
+func (s *SABnzbd) getArr(r *http.Request) *arr.Arr {
+	_arr := getArrFromContext(r.Context())
+	if _arr == nil {
+		// Arr is not in context
+		_arr = arr.New(getCategory(r.Context()), "", "", false, false, nil, "", "")
+	}
+	return _arr
+}
+
+func (s *SABnzbd) handleAddFile(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseMultipartForm(32 << 20); err != nil {
+		s.logger.Error().Err(err).Msgf("Error parsing multipart form")
+		request.JSONResponse(w, StatusResponse{Status: false, Error: err.Error()}, http.StatusBadRequest)
+		return
+	}
+	var nzoIds []string
+	ctx := r.Context()
+	_arr := s.getArr(r)
+	// SABnzbd takes the file in "name", some clients send "nzbfile"
+	for _, key := range []string{"name", "nzbfile"} {
+		for _, fileHeader := range r.MultipartForm.File[key] {
+			nzoId, err := s.addNZBFile(ctx, fileHeader, r.FormValue("nzbname"), _arr, wire.ImportAction(_arr, ""))
+			if err != nil {
+				s.logger.Debug().Err(err).Msgf("Error adding nzb")
+				request.JSONResponse(w, StatusResponse{Status: false, Error: err.Error()}, http.StatusBadRequest)
+				return
+			}
+			nzoIds = append(nzoIds, nzoId)
+		}
+	}
+	if len(nzoIds) == 0 {
+		request.JSONResponse(w, StatusResponse{Status: false, Error: "No NZB provided"}, http.StatusBadRequest)
+		return
+	}
+	request.JSONResponse(w, StatusResponse{Status: true, NzoIds: nzoIds}, http.StatusOK)
+}
+
+func (s *SABnzbd) handleAddUrl(w http.ResponseWriter, r *http.Request) {
+	url := strings.TrimSpace(r.FormValue("name"))
+	if url == "" {
+		request.JSONResponse(w, StatusResponse{Status: false, Error: "No URL provided"}, http.StatusBadRequest)
+		return
+	}
+	_arr := s.getArr(r)
+	nzoId, err := s.addNZBUrl(r.Context(), url, r.FormValue("nzbname"), _arr, wire.ImportAction(_arr, ""))
+	if err != nil {
+		s.logger.Debug().Err(err).Msgf("Error adding nzb url")
+		request.JSONResponse(w, StatusResponse{Status: false, Error: err.Error()}, http.StatusBadRequest)
+		return
+	}
+	request.JSONResponse(w, StatusResponse{Status: true, NzoIds: []string{nzoId}}, http.StatusOK)
+}
+
+func (s *SABnzbd) handleQueue(w http.ResponseWriter, r *http.Request) {
+	category := getCategory(r.Context())
+	if r.FormValue("name") == "delete" {
+		s.deleteNZBs(category, r.FormValue("value"))
+		request.JSONResponse(w, StatusResponse{Status: true}, http.StatusOK)
+		return
+	}
+
+	slots := make([]QueueSlot, 0)
+	for _, t := range s.getAll(category) {
+		if isCompleted(t) {
+			continue
+		}
+		status := "Downloading"
+		if t.State == "queued" {
+			status = "Queued"
+		}
+		slots = append(slots, QueueSlot{
+			Status:     status,
+			Index:      len(slots),
+			TimeLeft:   formatTimeLeft(t.Eta),
+			MB:         toMB(t.Size),
+			MBLeft:     toMB(t.AmountLeft),
+			Filename:   t.Name,
+			Priority:   "Normal",
+			Category:   t.Category,
+			Percentage: fmt.Sprintf("%d", int(t.Progress*100)),
+			NzoId:      t.Hash,
+		})
+	}
+	res := QueueResponse{
+		Queue: Queue{
+			Status:    "Downloading",
+			NoOfSlots: len(slots),
+			Slots:     slots,
+		},
+	}
+	request.JSONResponse(w, res, http.StatusOK)
+}
+
+func (s *SABnzbd) handleHistory(w http.ResponseWriter, r *http.Request) {
+	category := getCategory(r.Context())
+	if r.FormValue("name") == "delete" {
+		s.deleteNZBs(category, r.FormValue("value"))
+		request.JSONResponse(w, StatusResponse{Status: true}, http.StatusOK)
+		return
+	}
+
+	slots := make([]HistorySlot, 0)
+	for _, t := range s.getAll(category) {
+		if !isCompleted(t) {
+			continue
+		}
+		slot := HistorySlot{
+			Bytes:    t.Size,
+			Category: t.Category,
+			NzbName:  t.Name + ".nzb",
+			Storage:  t.ContentPath,
+			Status:   "Completed",
+			NzoId:    t.Hash,
+			Name:     t.Name,
+		}
+		if slot.Storage == "" {
+			slot.Storage = filepath.Join(t.SavePath, t.Name)
+		}
+		if t.State == "error" {
+			slot.Status = "Failed"
+			slot.FailMessage = "Failed to process NZB on debrid"
+		}
+		slots = append(slots, slot)
+	}
+	res := HistoryResponse{
+		History: History{
+			NoOfSlots: len(slots),
+			Slots:     slots,
+		},
+	}
+	request.JSONResponse(w, res, http.StatusOK)
+}
@@ -0,0 +1,92 @@
+package sabnzbd
+
+import (
+	"context"
+	"fmt"
+	"mime/multipart"
+	"strings"
+
+	"github.com/sirrobot01/decypharr/internal/utils"
+	"github.com/sirrobot01/decypharr/pkg/arr"
+	"github.com/sirrobot01/decypharr/pkg/wire"
+)
+
+// All nzb-related helpers goes here
+func (s *SABnzbd) addNZBUrl(ctx context.Context, url, name string, arr *arr.Arr, action string) (string, error) {
+	magnet, err := utils.GetNZBFromUrl(url)
+	if err != nil {
+		return "", fmt.Errorf("error fetching nzb: %w", err)
+	}
+	return s.addNZB(ctx, magnet, name, arr, action)
+}
+
+func (s *SABnzbd) addNZBFile(ctx context.Context, fileHeader *multipart.FileHeader, name string, arr *arr.Arr, action string) (string, error) {
+	file, err := fileHeader.Open()
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+	magnet, err := utils.GetNZBFromFile(file, fileHeader.Filename)
+	if err != nil {
+		return "", fmt.Errorf("error reading file: %s \n %w", fileHeader.Filename, err)
+	}
+	return s.addNZB(ctx, magnet, name, arr, action)
+}
+
+func (s *SABnzbd) addNZB(ctx context.Context, magnet *utils.Magnet, name string, arr *arr.Arr, action string) (string, error) {
+	if name = strings.TrimSpace(name); name != "" {
+		magnet.Name = strings.TrimSuffix(name, ".nzb")
+	}
+	importReq := wire.NewImportRequest("", s.DownloadFolder, magnet, arr, action, true, "", wire.ImportTypeSABnzbd, false)
+	if err := wire.Get().AddTorrent(ctx, importReq); err != nil {
+		return "", fmt.Errorf("failed to process nzb: %w", err)
+	}
+	// The nzo_id is the hash the torrent is stored under
+	return strings.ToLower(magnet.InfoHash), nil
+}
+
+// getAll returns the NZBs added through this API
+func (s *SABnzbd) getAll(category string) []*wire.Torrent {
+	torrents := s.storage.GetAllSorted(category, "", nil, "added_on", true)
+	nzbs := make([]*wire.Torrent, 0, len(torrents))
+	for _, t := range torrents {
+		if t.Source == string(wire.ImportTypeSABnzbd) {
+			nzbs = append(nzbs, t)
+		}
+	}
+	return nzbs
+}
+
+func (s *SABnzbd) deleteNZBs(category, value string) {
+	var hashes []string
+	if value == "all" {
+		for _, t := range s.getAll(category) {
+			hashes = append(hashes, t.Hash)
+		}
+	} else {
+		for _, id := range strings.Split(value, ",") {
+			if id = strings.TrimSpace(id); id != "" {
+				hashes = append(hashes, strings.ToLower(id))
+			}
+		}
+	}
+	// Arrs delete history entries after every import, the debrid's job still backs the imported files
+	for _, hash := range hashes {
+		s.storage.Delete(hash, category, false)
+	}
+}
+
+func isCompleted(t *wire.Torrent) bool {
+	return t.State == "pausedUP" || t.State == "error"
+}
+
+func formatTimeLeft(seconds int) string {
+	if seconds <= 0 {
+		return "0:00:00"
+	}
+	return fmt.Sprintf("%d:%02d:%02d", seconds/3600, (seconds%3600)/60, seconds%60)
+}
+
+func toMB(size int64) string {
+	return fmt.Sprintf("%.2f", float64(size)/(1024*1024))
+}
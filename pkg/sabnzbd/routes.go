@@ -0,0 +1,18 @@
+package sabnzbd
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func (s *SABnzbd) Routes() http.Handler {
+	r := chi.NewRouter()
+	r.Use(s.categoryContext)
+	r.Group(func(r chi.Router) {
+		r.Use(s.authContext)
+		r.Get("/api", s.handleAPI)
+		r.Post("/api", s.handleAPI)
+	})
+	return r
+}
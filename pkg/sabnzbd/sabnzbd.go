@@ -0,0 +1,28 @@
+package sabnzbd
+
+import (
+	"github.com/rs/zerolog"
+	"github.com/sirrobot01/decypharr/internal/config"
+	"github.com/sirrobot01/decypharr/internal/logger"
+	"github.com/sirrobot01/decypharr/pkg/wire"
+)
+
+// SABnzbd emulates enough of the SABnzbd API for the arrs to use decypharr as a usenet download client.
+// It shares the download folder, categories and torrent storage with the qbittorrent API
+type SABnzbd struct {
+	DownloadFolder string
+	Categories     []string
+	storage        *wire.TorrentStorage
+	logger         zerolog.Logger
+}
+
+func New() *SABnzbd {
+	_cfg := config.Get()
+	cfg := _cfg.QBitTorrent
+	return &SABnzbd{
+		DownloadFolder: cfg.DownloadFolder,
+		Categories:     cfg.Categories,
+		storage:        wire.Get().Torrents(),
+		logger:         logger.New("sabnzbd"),
+	}
+}
@@ -0,0 +1,102 @@
+package sabnzbd
+
+type StatusResponse struct {
+	Status bool     `json:"status"`
+	Error  string   `json:"error,omitempty"`
+	NzoIds []string `json:"nzo_ids,omitempty"`
+}
+
+type VersionResponse struct {
+	Version string `json:"version"`
+}
+
+type CategoriesResponse struct {
+	Categories []string `json:"categories"`
+}
+
+type Category struct {
+	Name     string `json:"name"`
+	Order    int    `json:"order"`
+	PP       string `json:"pp"`
+	Script   string `json:"script"`
+	Dir      string `json:"dir"`
+	Priority int    `json:"priority"`
+}
+
+type Misc struct {
+	CompleteDir            string   `json:"complete_dir"`
+	DownloadDir            string   `json:"download_dir"`
+	PreCheck               bool     `json:"pre_check"`
+	TvCategories           []string `json:"tv_categories"`
+	EnableTvSorting        bool     `json:"enable_tv_sorting"`
+	MovieCategories        []string `json:"movie_categories"`
+	EnableMovieSorting     bool     `json:"enable_movie_sorting"`
+	DateCategories         []string `json:"date_categories"`
+	EnableDateSorting      bool     `json:"enable_date_sorting"`
+	HistoryRetention       string   `json:"history_retention"`
+	HistoryRetentionOption string   `json:"history_retention_option"`
+	HistoryRetentionNumber int      `json:"history_retention_number"`
+}
+
+type Config struct {
+	Misc       Misc       `json:"misc"`
+	Categories []Category `json:"categories"`
+	Sorters    []any      `json:"sorters"`
+}
+
+type ConfigResponse struct {
+	Config Config `json:"config"`
+}
+
+type FullStatus struct {
+	CompleteDir string `json:"completedir"`
+}
+
+type FullStatusResponse struct {
+	Status FullStatus `json:"status"`
+}
+
+type QueueSlot struct {
+	Status     string `json:"status"`
+	Index      int    `json:"index"`
+	TimeLeft   string `json:"timeleft"`
+	MB         string `json:"mb"`
+	MBLeft     string `json:"mbleft"`
+	Filename   string `json:"filename"`
+	Priority   string `json:"priority"`
+	Category   string `json:"cat"`
+	Percentage string `json:"percentage"`
+	NzoId      string `json:"nzo_id"`
+}
+
+type Queue struct {
+	Status    string      `json:"status"`
+	Paused    bool        `json:"paused"`
+	NoOfSlots int         `json:"noofslots"`
+	Slots     []QueueSlot `json:"slots"`
+}
+
+type QueueResponse struct {
+	Queue Queue `json:"queue"`
+}
+
+type HistorySlot struct {
+	FailMessage  string `json:"fail_message"`
+	Bytes        int64  `json:"bytes"`
+	Category     string `json:"category"`
+	NzbName      string `json:"nzb_name"`
+	DownloadTime int64  `json:"download_time"`
+	Storage      string `json:"storage"`
+	Status       string `json:"status"`
+	NzoId        string `json:"nzo_id"`
+	Name         string `json:"name"`
+}
+
+type History struct {
+	NoOfSlots int           `json:"noofslots"`
+	Slots     []HistorySlot `json:"slots"`
+}
+
+type HistoryResponse struct {
+	History History `json:"history"`
+}
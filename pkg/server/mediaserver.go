@@ -0,0 +1,349 @@
+package server
+
+import (
+	"cmp"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/sirrobot01/decypharr/internal/config"
+	"github.com/sirrobot01/decypharr/internal/request"
+	"github.com/sirrobot01/decypharr/pkg/arr"
+	"github.com/sirrobot01/decypharr/pkg/repair"
+	"github.com/sirrobot01/decypharr/pkg/wire"
+)
+
+// mediaItem is what a media server tells us about the item that was played or added
+type mediaItem struct {
+	Source  string
+	Event   string
+	Type    string // movie, episode, season or series
+	Title   string
+	Series  string
+	Season  int
+	Episode int
+	Tmdb    string
+	Tvdb    string
+	Imdb    string
+	Path    string // File path on the media server, if sent
+}
+
+// Plays and library additions only trigger a repair with Webhook On Playback on, most of them are of healthy files.
+// Events with "error" in the name always do, for custom Jellyfin templates and Tautulli style notifiers
+var playbackEvents = map[string][]string{
+	"plex":     {"media.play", "library.new"},
+	"jellyfin": {"PlaybackStart", "ItemAdded"},
+	"emby":     {"playback.start", "library.new"},
+}
+
+// flexInt decodes a number sent as either a JSON number or a string, Jellyfin templates usually quote everything
+type flexInt int
+
+func (f *flexInt) UnmarshalJSON(data []byte) error {
+	s := strings.Trim(string(data), `"`)
+	if s == "" || s == "null" {
+		*f = 0
+		return nil
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		return err
+	}
+	*f = flexInt(n)
+	return nil
+}
+
+// plexPayload is the "payload" field of a Plex webhook, see https://support.plex.tv/articles/115002267687-webhooks/
+type plexPayload struct {
+	Event    string `json:"event"`
+	Metadata struct {
+		Type             string `json:"type"`
+		Title            string `json:"title"`
+		GrandparentTitle string `json:"grandparentTitle"`
+		ParentTitle      string `json:"parentTitle"`
+		ParentIndex      int    `json:"parentIndex"`
+		Index            int    `json:"index"`
+		Guid             []struct {
+			ID string `json:"id"`
+		} `json:"Guid"`
+	} `json:"Metadata"`
+}
+
+func (p plexPayload) item() mediaItem {
+	item := mediaItem{
+		Source: "plex",
+		Event:  p.Event,
+		Type:   p.Metadata.Type,
+		Title:  p.Metadata.Title,
+	}
+	switch p.Metadata.Type {
+	case "episode":
+		item.Series = p.Metadata.GrandparentTitle
+		item.Season = p.Metadata.ParentIndex
+		item.Episode = p.Metadata.Index
+	case "season":
+		item.Series = p.Metadata.ParentTitle
+		item.Season = p.Metadata.Index
+	case "show":
+		item.Type = "series"
+		item.Series = p.Metadata.Title
+	}
+	for _, g := range p.Metadata.Guid {
+		provider, id, ok := strings.Cut(g.ID, "://")
+		if !ok {
+			continue
+		}
+		switch provider {
+		case "tmdb":
+			item.Tmdb = id
+		case "tvdb":
+			item.Tvdb = id
+		case "imdb":
+			item.Imdb = id
+		}
+	}
+	return item
+}
+
+// jellyfinPayload is the body sent by the Jellyfin webhook plugin with the template from the docs
+type jellyfinPayload struct {
+	NotificationType string  `json:"NotificationType"`
+	ItemType         string  `json:"ItemType"`
+	Name             string  `json:"Name"`
+	SeriesName       string  `json:"SeriesName"`
+	SeasonNumber     flexInt `json:"SeasonNumber"`
+	EpisodeNumber    flexInt `json:"EpisodeNumber"`
+	ProviderTmdb     string  `json:"Provider_tmdb"`
+	ProviderTvdb     string  `json:"Provider_tvdb"`
+	ProviderImdb     string  `json:"Provider_imdb"`
+}
+
+func (p jellyfinPayload) item() mediaItem {
+	return mediaItem{
+		Source:  "jellyfin",
+		Event:   p.NotificationType,
+		Type:    strings.ToLower(p.ItemType),
+		Title:   p.Name,
+		Series:  cmp.Or(p.SeriesName, p.Name),
+		Season:  int(p.SeasonNumber),
+		Episode: int(p.EpisodeNumber),
+		Tmdb:    p.ProviderTmdb,
+		Tvdb:    p.ProviderTvdb,
+		Imdb:    p.ProviderImdb,
+	}
+}
+
+// embyPayload is the body of an Emby webhook, sent as JSON or as the "data" field of a form
+type embyPayload struct {
+	Event string `json:"Event"`
+	Item  struct {
+		Name              string            `json:"Name"`
+		Type              string            `json:"Type"`
+		SeriesName        string            `json:"SeriesName"`
+		IndexNumber       int               `json:"IndexNumber"`
+		ParentIndexNumber int               `json:"ParentIndexNumber"`
+		Path              string            `json:"Path"`
+		ProviderIds       map[string]string `json:"ProviderIds"`
+	} `json:"Item"`
+}
+
+func (p embyPayload) item() mediaItem {
+	item := mediaItem{
+		Source: "emby",
+		Event:  p.Event,
+		Type:   strings.ToLower(p.Item.Type),
+		Title:  p.Item.Name,
+		Series: cmp.Or(p.Item.SeriesName, p.Item.Name),
+		Path:   p.Item.Path,
+	}
+	switch item.Type {
+	case "episode":
+		item.Season = p.Item.ParentIndexNumber
+		item.Episode = p.Item.IndexNumber
+	case "season":
+		item.Season = p.Item.IndexNumber
+	}
+	for provider, id := range p.Item.ProviderIds {
+		switch strings.ToLower(provider) {
+		case "tmdb":
+			item.Tmdb = id
+		case "tvdb":
+			item.Tvdb = id
+		case "imdb":
+			item.Imdb = id
+		}
+	}
+	return item
+}
+
+func (s *Server) handlePlex(w http.ResponseWriter, r *http.Request) {
+	var payload plexPayload
+	if err := decodeWebhook(r, "payload", &payload); err != nil {
+		s.logger.Error().Err(err).Msg("Failed to parse Plex webhook body")
+		http.Error(w, "Failed to parse webhook body: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	s.handleMediaServer(w, r, payload.item())
+}
+
+func (s *Server) handleJellyfin(w http.ResponseWriter, r *http.Request) {
+	var payload jellyfinPayload
+	if err := decodeWebhook(r, "", &payload); err != nil {
+		s.logger.Error().Err(err).Msg("Failed to parse Jellyfin webhook body")
+		http.Error(w, "Failed to parse webhook body: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	s.handleMediaServer(w, r, payload.item())
+}
+
+func (s *Server) handleEmby(w http.ResponseWriter, r *http.Request) {
+	var payload embyPayload
+	if err := decodeWebhook(r, "data", &payload); err != nil {
+		s.logger.Error().Err(err).Msg("Failed to parse Emby webhook body")
+		http.Error(w, "Failed to parse webhook body: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	s.handleMediaServer(w, r, payload.item())
+}
+
+func (s *Server) handleMediaServer(w http.ResponseWriter, r *http.Request, item mediaItem) {
+	if !isRepairEvent(item, config.Get().Repair.WebhookOnPlayback) {
+		request.JSONResponse(w, map[string]string{"status": "ignored", "event": item.Event}, http.StatusOK)
+		return
+	}
+	_repair := wire.Get().Repair()
+	if _repair == nil {
+		http.Error(w, "Repair service is not enabled", http.StatusInternalServerError)
+		return
+	}
+
+	jobs, err := resolveMedia(wire.Get().Arr(), item)
+	if err != nil {
+		s.logger.Debug().Err(err).Str("source", item.Source).Str("event", item.Event).Msgf("Can't map %s to arr media", item.Title)
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	autoProcess := config.Get().Repair.AutoProcess
+	if v := r.URL.Query().Get("autoProcess"); v != "" {
+		autoProcess, _ = strconv.ParseBool(v)
+	}
+
+	started := make([]string, 0, len(jobs))
+	var jobErr error
+	for arrName, mediaID := range jobs {
+		if err := _repair.AddJob([]string{arrName}, []string{mediaID}, autoProcess, false); err != nil {
+			if !errors.Is(err, repair.ErrJobRunning) {
+				s.logger.Error().Err(err).Msgf("Failed to add repair job of %s in %s", mediaID, arrName)
+				jobErr = err
+			}
+			continue
+		}
+		s.logger.Info().Str("source", item.Source).Str("event", item.Event).Msgf("Started repair of %s in %s for %s", mediaID, arrName, item.Title)
+		started = append(started, arrName+"/"+mediaID)
+	}
+	if len(started) == 0 && jobErr != nil {
+		http.Error(w, "Failed to add job: "+jobErr.Error(), http.StatusInternalServerError)
+		return
+	}
+	request.JSONResponse(w, map[string]any{"status": "started", "jobs": started}, http.StatusAccepted)
+}
+
+// verifyWebhookSecret checks the X-Webhook-Secret header or the secret query param, Plex and Emby can't set headers
+func verifyWebhookSecret(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		secret := config.Get().Repair.WebhookSecret
+		if secret != "" {
+			got := cmp.Or(r.Header.Get("X-Webhook-Secret"), r.URL.Query().Get("secret"))
+			if subtle.ConstantTimeCompare([]byte(got), []byte(secret)) != 1 {
+				http.Error(w, "Invalid webhook secret", http.StatusUnauthorized)
+				return
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// decodeWebhook reads a JSON body, or the JSON in formField of a multipart/urlencoded form
+func decodeWebhook(r *http.Request, formField string, v any) error {
+	contentType := r.Header.Get("Content-Type")
+	if formField != "" && (strings.HasPrefix(contentType, "multipart/form-data") || strings.HasPrefix(contentType, "application/x-www-form-urlencoded")) {
+		if err := r.ParseMultipartForm(10 << 20); err != nil && !errors.Is(err, http.ErrNotMultipart) {
+			return err
+		}
+		data := r.FormValue(formField)
+		if data == "" {
+			return fmt.Errorf("missing %s field", formField)
+		}
+		return json.Unmarshal([]byte(data), v)
+	}
+	return json.NewDecoder(io.LimitReader(r.Body, 10<<20)).Decode(v)
+}
+
+func isRepairEvent(item mediaItem, onPlayback bool) bool {
+	if strings.Contains(strings.ToLower(item.Event), "error") {
+		return true
+	}
+	if !onPlayback {
+		return false
+	}
+	for _, e := range playbackEvents[item.Source] {
+		if strings.EqualFold(e, item.Event) {
+			return true
+		}
+	}
+	return false
+}
+
+// resolveMedia maps the item to the arrs that have it, returning arr name => repair media ID.
+// Movies are looked up by tmdb id in Radarr. Series are looked up by tvdb id, episode ids are useless to Sonarr so
+// their series is found by Sonarr's parser
+func resolveMedia(arrs *arr.Storage, item mediaItem) (map[string]string, error) {
+	jobs := make(map[string]string)
+	switch item.Type {
+	case "movie":
+		if item.Tmdb == "" {
+			return nil, fmt.Errorf("movie %s has no tmdb id", item.Title)
+		}
+		for _, a := range arrs.GetAll() {
+			if a.Type != arr.Radarr || a.SkipRepair {
+				continue
+			}
+			if ok, err := a.HasMovie(item.Tmdb); err != nil || !ok {
+				continue
+			}
+			jobs[a.Name] = item.Tmdb
+		}
+	case "episode", "season", "series":
+		for _, a := range arrs.GetAll() {
+			if a.Type != arr.Sonarr || a.SkipRepair {
+				continue
+			}
+			tvdbId := ""
+			if item.Type == "series" && item.Tvdb != "" {
+				if ok, err := a.HasSeries(item.Tvdb); err != nil || !ok {
+					continue
+				}
+				tvdbId = item.Tvdb
+			} else {
+				id, err := a.FindSeries(item.Series, item.Path, item.Season, item.Episode)
+				if err != nil {
+					continue
+				}
+				tvdbId = id
+			}
+			jobs[a.Name] = repair.ScopedMediaID(tvdbId, item.Season, item.Episode)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported item type: %s", item.Type)
+	}
+	if len(jobs) == 0 {
+		return nil, fmt.Errorf("%s not found in any arr", cmp.Or(item.Series, item.Title))
+	}
+	return jobs, nil
+}
@@ -0,0 +1,101 @@
+package server
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/sirrobot01/decypharr/internal/metrics"
+	"github.com/sirrobot01/decypharr/pkg/wire"
+)
+
+// storeCollector reads gauges from the live store on every scrape.
+// The store can be reset on config changes, so nothing is cached here
+type storeCollector struct {
+	importQueueSize     *prometheus.Desc
+	torrents            *prometheus.Desc
+	cachedTorrents      *prometheus.Desc
+	activeDownloadLinks *prometheus.Desc
+	accountTraffic      *prometheus.Desc
+	accountDisabled     *prometheus.Desc
+	accountLinks        *prometheus.Desc
+}
+
+func newStoreCollector() *storeCollector {
+	accountLabels := []string{"debrid", "account", "username"}
+	return &storeCollector{
+		importQueueSize:     prometheus.NewDesc("decypharr_import_queue_size", "Import requests waiting for a free debrid slot.", nil, nil),
+		torrents:            prometheus.NewDesc("decypharr_torrents", "Torrents tracked by the qbittorrent store, by debrid.", []string{"debrid"}, nil),
+		cachedTorrents:      prometheus.NewDesc("decypharr_debrid_cached_torrents", "Torrents in the debrid WebDAV cache.", []string{"debrid"}, nil),
+		activeDownloadLinks: prometheus.NewDesc("decypharr_debrid_active_download_links", "Active download links in the debrid cache.", []string{"debrid"}, nil),
+		accountTraffic:      prometheus.NewDesc("decypharr_account_traffic_used_bytes", "Traffic used by a debrid account.", accountLabels, nil),
+		accountDisabled:     prometheus.NewDesc("decypharr_account_disabled", "Whether a debrid account is disabled(1) or not(0).", accountLabels, nil),
+		accountLinks:        prometheus.NewDesc("decypharr_account_download_links", "Download links generated by a debrid account.", accountLabels, nil),
+	}
+}
+
+func (c *storeCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.importQueueSize
+	ch <- c.torrents
+	ch <- c.cachedTorrents
+	ch <- c.activeDownloadLinks
+	ch <- c.accountTraffic
+	ch <- c.accountDisabled
+	ch <- c.accountLinks
+}
+
+func (c *storeCollector) Collect(ch chan<- prometheus.Metric) {
+	_store := wire.Get()
+	if _store == nil {
+		return
+	}
+
+	if queue := _store.ImportQueue(); queue != nil {
+		ch <- prometheus.MustNewConstMetric(c.importQueueSize, prometheus.GaugeValue, float64(queue.Size()))
+	}
+
+	if torrents := _store.Torrents(); torrents != nil {
+		counts := make(map[string]int)
+		for _, t := range torrents.GetAll("", "", nil) {
+			counts[t.Debrid]++
+		}
+		for name, count := range counts {
+			ch <- prometheus.MustNewConstMetric(c.torrents, prometheus.GaugeValue, float64(count), name)
+		}
+	}
+
+	debrids := _store.Debrid()
+	if debrids == nil {
+		return
+	}
+	for name, cache := range debrids.Caches() {
+		ch <- prometheus.MustNewConstMetric(c.cachedTorrents, prometheus.GaugeValue, float64(cache.TotalTorrents()), name)
+		ch <- prometheus.MustNewConstMetric(c.activeDownloadLinks, prometheus.GaugeValue, float64(cache.GetTotalActiveDownloadLinks()), name)
+	}
+	for name, client := range debrids.Clients() {
+		accountManager := client.AccountManager()
+		if accountManager == nil {
+			continue
+		}
+		for _, acc := range accountManager.All() {
+			labels := []string{name, strconv.Itoa(acc.Index), acc.Username}
+			disabled := 0.0
+			if acc.Disabled.Load() {
+				disabled = 1
+			}
+			ch <- prometheus.MustNewConstMetric(c.accountTraffic, prometheus.GaugeValue, float64(acc.TrafficUsed.Load()), labels...)
+			ch <- prometheus.MustNewConstMetric(c.accountDisabled, prometheus.GaugeValue, disabled, labels...)
+			ch <- prometheus.MustNewConstMetric(c.accountLinks, prometheus.GaugeValue, float64(acc.DownloadLinksCount()), labels...)
+		}
+	}
+}
+
+func registerStoreCollector() error {
+	err := metrics.Register(newStoreCollector())
+	var are prometheus.AlreadyRegisteredError
+	if errors.As(err, &are) {
+		// The server is re-created on restarts, the collector only needs to be registered once
+		return nil
+	}
+	return err
+}
@@ -14,6 +14,7 @@ import (
 	"github.com/rs/zerolog"
 	"github.com/sirrobot01/decypharr/internal/config"
 	"github.com/sirrobot01/decypharr/internal/logger"
+	"github.com/sirrobot01/decypharr/internal/metrics"
 )
 
 type Server struct {
@@ -34,6 +35,10 @@ func New(handlers map[string]http.Handler) *Server {
 		logger: l,
 	}
 
+	if err := registerStoreCollector(); err != nil {
+		l.Error().Err(err).Msg("Failed to register metrics collector")
+	}
+
 	r.Route(cfg.URLBase, func(r chi.Router) {
 		for pattern, handler := range handlers {
 			r.Mount(pattern, handler)
@@ -50,8 +55,16 @@ func New(handlers map[string]http.Handler) *Server {
 			r.Get("/ingests/{debrid}", s.handleIngestsByDebrid)
 		})
 
+		r.Handle("/metrics", metrics.Handler())
+
 		//webhooks
-		r.Post("/webhooks/tautulli", s.handleTautulli)
+		r.Route("/webhooks", func(r chi.Router) {
+			r.Use(verifyWebhookSecret)
+			r.Post("/tautulli", s.handleTautulli)
+			r.Post("/plex", s.handlePlex)
+			r.Post("/jellyfin", s.handleJellyfin)
+			r.Post("/emby", s.handleEmby)
+		})
 
 	})
 	s.router = r
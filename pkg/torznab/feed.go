@@ -0,0 +1,210 @@
+package torznab
+
+import (
+	"bytes"
+	"cmp"
+	"encoding/xml"
+	"io"
+	"strings"
+)
+
+const (
+	TorznabNamespace = "http://torznab.com/schemas/2015/feed"
+	NewznabNamespace = "http://www.newznab.com/DTD/2010/feeds/attributes/"
+	AtomNamespace    = "http://www.w3.org/2005/Atom"
+)
+
+// Prefixes the namespaces are written with, everything else keeps its local name
+var prefixes = map[string]string{
+	TorznabNamespace: "torznab",
+	NewznabNamespace: "newznab",
+	AtomNamespace:    "atom",
+}
+
+// Feed is a Torznab/Newznab RSS search result
+type Feed struct {
+	XMLName xml.Name `xml:"rss"`
+	Version string   `xml:"version,attr"`
+	Channel Channel  `xml:"channel"`
+}
+
+// Channel keeps the elements it doesn't care about as they are, so they survive the rewrite
+type Channel struct {
+	Extra []Element `xml:",any"`
+	Items []Item    `xml:"item"`
+}
+
+type Item struct {
+	Title        string     `xml:"title"`
+	Link         string     `xml:"link"`
+	Enclosure    *Enclosure `xml:"enclosure"`
+	TorznabAttrs []Attr     `xml:"http://torznab.com/schemas/2015/feed attr"`
+	NewznabAttrs []Attr     `xml:"http://www.newznab.com/DTD/2010/feeds/attributes/ attr"`
+	Extra        []Element  `xml:",any"`
+}
+
+type Enclosure struct {
+	URL    string `xml:"url,attr"`
+	Length string `xml:"length,attr,omitempty"`
+	Type   string `xml:"type,attr,omitempty"`
+}
+
+type Attr struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value,attr"`
+}
+
+// Element is any element, kept verbatim
+type Element struct {
+	XMLName xml.Name
+	Attrs   []xml.Attr `xml:",any,attr"`
+	Inner   []byte     `xml:",innerxml"`
+}
+
+// Parse parses a Torznab/Newznab RSS feed. Anything that isn't an RSS feed, like an <error>, fails
+func Parse(data []byte) (*Feed, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	// Indexers are expected to send UTF-8, take others as they are rather than failing the search
+	decoder.CharsetReader = func(_ string, input io.Reader) (io.Reader, error) {
+		return input, nil
+	}
+	var feed Feed
+	if err := decoder.Decode(&feed); err != nil {
+		return nil, err
+	}
+	return &feed, nil
+}
+
+// Write writes the feed as XML, with the usual torznab/newznab/atom prefixes
+func (f *Feed) Write(w io.Writer) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	if err := encoder.Encode(f); err != nil {
+		return err
+	}
+	return encoder.Close()
+}
+
+func (f *Feed) MarshalXML(e *xml.Encoder, _ xml.StartElement) error {
+	start := xml.StartElement{
+		Name: xml.Name{Local: "rss"},
+		Attr: []xml.Attr{
+			{Name: xml.Name{Local: "version"}, Value: cmp.Or(f.Version, "2.0")},
+			{Name: xml.Name{Local: "xmlns:atom"}, Value: AtomNamespace},
+			{Name: xml.Name{Local: "xmlns:torznab"}, Value: TorznabNamespace},
+			{Name: xml.Name{Local: "xmlns:newznab"}, Value: NewznabNamespace},
+		},
+	}
+	if err := e.EncodeToken(start); err != nil {
+		return err
+	}
+	if err := e.EncodeElement(f.Channel, xml.StartElement{Name: xml.Name{Local: "channel"}}); err != nil {
+		return err
+	}
+	return e.EncodeToken(start.End())
+}
+
+func (i Item) MarshalXML(e *xml.Encoder, _ xml.StartElement) error {
+	start := xml.StartElement{Name: xml.Name{Local: "item"}}
+	if err := e.EncodeToken(start); err != nil {
+		return err
+	}
+	if err := e.EncodeElement(i.Title, localName("title")); err != nil {
+		return err
+	}
+	if i.Link != "" {
+		if err := e.EncodeElement(i.Link, localName("link")); err != nil {
+			return err
+		}
+	}
+	for _, el := range i.Extra {
+		if err := e.Encode(el); err != nil {
+			return err
+		}
+	}
+	if i.Enclosure != nil {
+		if err := e.EncodeElement(i.Enclosure, localName("enclosure")); err != nil {
+			return err
+		}
+	}
+	for _, a := range i.TorznabAttrs {
+		if err := e.EncodeElement(a, localName("torznab:attr")); err != nil {
+			return err
+		}
+	}
+	for _, a := range i.NewznabAttrs {
+		if err := e.EncodeElement(a, localName("newznab:attr")); err != nil {
+			return err
+		}
+	}
+	return e.EncodeToken(start.End())
+}
+
+func (el Element) MarshalXML(e *xml.Encoder, _ xml.StartElement) error {
+	attrs := make([]xml.Attr, 0, len(el.Attrs))
+	for _, a := range el.Attrs {
+		if a.Name.Space == "xmlns" || a.Name.Local == "xmlns" {
+			// Namespaces are declared on the root
+			continue
+		}
+		attrs = append(attrs, xml.Attr{Name: prefixed(a.Name), Value: a.Value})
+	}
+	return e.Encode(struct {
+		XMLName xml.Name
+		Attrs   []xml.Attr `xml:",any,attr"`
+		Inner   []byte     `xml:",innerxml"`
+	}{prefixed(el.XMLName), attrs, el.Inner})
+}
+
+// Attr returns the value of the first torznab/newznab attribute with the name
+func (i *Item) Attr(name string) string {
+	for _, a := range i.TorznabAttrs {
+		if strings.EqualFold(a.Name, name) {
+			return a.Value
+		}
+	}
+	for _, a := range i.NewznabAttrs {
+		if strings.EqualFold(a.Name, name) {
+			return a.Value
+		}
+	}
+	return ""
+}
+
+// SetAttr replaces the value of the attribute, adding it if it's missing
+func (i *Item) SetAttr(name, value string) {
+	for _, attrs := range [][]Attr{i.TorznabAttrs, i.NewznabAttrs} {
+		for j := range attrs {
+			if strings.EqualFold(attrs[j].Name, name) {
+				attrs[j].Value = value
+				return
+			}
+		}
+	}
+	i.AddAttr(name, value)
+}
+
+// AddAttr adds an attribute, in the newznab namespace only if the item already uses it
+func (i *Item) AddAttr(name, value string) {
+	if len(i.TorznabAttrs) == 0 && len(i.NewznabAttrs) > 0 {
+		i.NewznabAttrs = append(i.NewznabAttrs, Attr{Name: name, Value: value})
+		return
+	}
+	i.TorznabAttrs = append(i.TorznabAttrs, Attr{Name: name, Value: value})
+}
+
+func localName(name string) xml.StartElement {
+	return xml.StartElement{Name: xml.Name{Local: name}}
+}
+
+func prefixed(name xml.Name) xml.Name {
+	if name.Space == "" {
+		return name
+	}
+	if prefix, ok := prefixes[name.Space]; ok {
+		return xml.Name{Local: prefix + ":" + name.Local}
+	}
+	return name
+}
@@ -0,0 +1,58 @@
+package torznab
+
+import (
+	"context"
+	"crypto/subtle"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/sirrobot01/decypharr/internal/config"
+)
+
+type contextKey string
+
+const indexerKey contextKey = "indexer"
+
+func getIndexer(ctx context.Context) *config.Indexer {
+	if indexer, ok := ctx.Value(indexerKey).(*config.Indexer); ok {
+		return indexer
+	}
+	return nil
+}
+
+func (p *Proxy) Routes() http.Handler {
+	r := chi.NewRouter()
+	r.Route("/{indexer}", func(r chi.Router) {
+		r.Use(p.authContext)
+		r.Use(p.indexerContext)
+		r.Get("/api", p.handleAPI)
+		r.Get("/download", p.handleDownload)
+	})
+	return r
+}
+
+// authContext checks the apikey against decypharr's API token, the arrs send it like they would to any indexer
+func (p *Proxy) authContext(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if auth := config.Get().GetAuth(); auth != nil {
+			apiKey := r.URL.Query().Get("apikey")
+			if apiKey == "" || auth.APIToken == "" || subtle.ConstantTimeCompare([]byte(apiKey), []byte(auth.APIToken)) != 1 {
+				writeError(w, 100, "Incorrect user credentials", http.StatusUnauthorized)
+				return
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (p *Proxy) indexerContext(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		indexer := config.Get().GetIndexer(chi.URLParam(r, "indexer"))
+		if indexer == nil {
+			writeError(w, 201, "Indexer not found", http.StatusNotFound)
+			return
+		}
+		ctx := context.WithValue(r.Context(), indexerKey, indexer)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
@@ -0,0 +1,319 @@
+package torznab
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/sirrobot01/decypharr/internal/config"
+	"github.com/sirrobot01/decypharr/internal/logger"
+	"github.com/sirrobot01/decypharr/internal/request"
+	"github.com/sirrobot01/decypharr/internal/utils"
+	"github.com/sirrobot01/decypharr/pkg/debrid/common"
+	"github.com/sirrobot01/decypharr/pkg/wire"
+)
+
+// Largest upstream response read into memory
+const maxFeedSize = 32 << 20
+
+// Stands in for the upstream apikey in the download links handed to the arrs
+const apiKeyPlaceholder = "decypharr-indexer-key"
+
+// Proxy sits between the arrs and their Torznab/Newznab indexers.
+// Search results are tagged with their availability on the debrids and their links resolve through decypharr
+type Proxy struct {
+	client *request.Client
+	logger zerolog.Logger
+}
+
+func New() *Proxy {
+	_log := logger.New("indexers")
+	return &Proxy{
+		client: request.New(
+			request.WithLogger(_log),
+			request.WithTimeout(90*time.Second),
+			request.WithMaxRetries(1),
+			request.WithSkipTLSVerify(false),
+			// Download redirects, usually to a magnet, are handed back to the arr
+			request.WithRedirectPolicy(func(req *http.Request, via []*http.Request) error {
+				return http.ErrUseLastResponse
+			}),
+		),
+		logger: _log,
+	}
+}
+
+// writeError writes a Torznab error, see https://torznab.github.io/spec-1.3-draft/torznab/Specification-v1.3.html
+func writeError(w http.ResponseWriter, code int, description string, status int) {
+	w.Header().Set("Content-Type", "application/xml; charset=utf-8")
+	w.WriteHeader(status)
+	_, _ = fmt.Fprintf(w, "%s<error code=\"%d\" description=\"%s\"/>", xml.Header, code, xmlEscape(description))
+}
+
+func xmlEscape(s string) string {
+	var b bytes.Buffer
+	_ = xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
+
+// upstreamURL builds the upstream api url for the query, with the upstream apikey
+func upstreamURL(indexer *config.Indexer, query url.Values) (string, error) {
+	u, err := url.Parse(indexer.URL)
+	if err != nil {
+		return "", err
+	}
+	q := u.Query()
+	for key, values := range query {
+		q[key] = values
+	}
+	q.Del("apikey")
+	if indexer.APIKey != "" {
+		q.Set("apikey", indexer.APIKey)
+	}
+	u.RawQuery = q.Encode()
+	return u.String(), nil
+}
+
+func (p *Proxy) handleAPI(w http.ResponseWriter, r *http.Request) {
+	indexer := getIndexer(r.Context())
+	query := r.URL.Query()
+	upstream, err := upstreamURL(indexer, query)
+	if err != nil {
+		writeError(w, 900, "Invalid indexer url", http.StatusInternalServerError)
+		return
+	}
+
+	req, err := http.NewRequestWithContext(r.Context(), http.MethodGet, upstream, nil)
+	if err != nil {
+		writeError(w, 900, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	resp, err := p.client.Do(req)
+	if err != nil {
+		p.logger.Error().Err(err).Str("indexer", indexer.Name).Msg("Failed to reach indexer")
+		writeError(w, 900, "Indexer unreachable", http.StatusBadGateway)
+		return
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxFeedSize))
+	if err != nil {
+		writeError(w, 900, "Failed to read indexer response", http.StatusBadGateway)
+		return
+	}
+
+	// Caps, errors and anything else that isn't a search result pass through untouched
+	if strings.EqualFold(query.Get("t"), "caps") || resp.StatusCode != http.StatusOK {
+		passthrough(w, resp, body)
+		return
+	}
+	feed, err := Parse(body)
+	if err != nil {
+		passthrough(w, resp, body)
+		return
+	}
+
+	p.rewrite(r, indexer, feed)
+
+	var buf bytes.Buffer
+	if err := feed.Write(&buf); err != nil {
+		p.logger.Error().Err(err).Str("indexer", indexer.Name).Msg("Failed to write feed")
+		writeError(w, 900, "Failed to write feed", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/rss+xml; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(buf.Bytes())
+}
+
+func passthrough(w http.ResponseWriter, resp *http.Response, body []byte) {
+	if contentType := resp.Header.Get("Content-Type"); contentType != "" {
+		w.Header().Set("Content-Type", contentType)
+	}
+	w.WriteHeader(resp.StatusCode)
+	_, _ = w.Write(body)
+}
+
+// rewrite tags the items with their availability on the debrids, sorts the cached ones first
+// and points the links at decypharr
+func (p *Proxy) rewrite(r *http.Request, indexer *config.Indexer, feed *Feed) {
+	items := feed.Channel.Items
+	hashes := make([]string, len(items))
+	for i := range items {
+		hashes[i] = itemInfoHash(&items[i])
+	}
+
+	var available map[string]map[string]bool
+	if slices.ContainsFunc(hashes, func(h string) bool { return h != "" }) {
+		debridStorage := wire.Get().Debrid()
+		available = debridStorage.Availability(hashes, indexerClients(indexer, debridStorage.Clients()))
+	}
+
+	cachedItems := make([]Item, 0, len(items))
+	uncachedItems := make([]Item, 0, len(items))
+	for i := range items {
+		item := items[i]
+		cachedOn := make([]string, 0)
+		for name, ok := range available[hashes[i]] {
+			if ok {
+				cachedOn = append(cachedOn, name)
+			}
+		}
+		slices.Sort(cachedOn)
+
+		p.rewriteLinks(r, indexer, &item)
+		if len(cachedOn) == 0 {
+			if !indexer.CachedOnly {
+				uncachedItems = append(uncachedItems, item)
+			}
+			continue
+		}
+		item.AddAttr("tag", "cached")
+		for _, name := range cachedOn {
+			item.AddAttr("tag", "cached:"+name)
+		}
+		if indexer.MarkFreeleech {
+			item.AddAttr("tag", "freeleech")
+			item.SetAttr("downloadvolumefactor", "0")
+		}
+		cachedItems = append(cachedItems, item)
+	}
+	feed.Channel.Items = append(cachedItems, uncachedItems...)
+
+	p.logger.Debug().
+		Str("indexer", indexer.Name).
+		Int("results", len(items)).
+		Int("cached", len(cachedItems)).
+		Msg("Rewrote search results")
+}
+
+// indexerClients returns the debrid clients the indexer checks availability on
+func indexerClients(indexer *config.Indexer, clients map[string]common.Client) map[string]common.Client {
+	if len(indexer.Debrids) == 0 {
+		return clients
+	}
+	selected := make(map[string]common.Client, len(indexer.Debrids))
+	for _, name := range indexer.Debrids {
+		if client, ok := clients[name]; ok {
+			selected[name] = client
+		}
+	}
+	return selected
+}
+
+// itemInfoHash finds the infohash of a result, from the infohash attribute or a magnet link
+func itemInfoHash(item *Item) string {
+	if h, err := utils.ParseInfoHash(item.Attr("infohash")); err == nil {
+		return h
+	}
+	links := []string{item.Attr("magneturl"), item.Link}
+	if item.Enclosure != nil {
+		links = append(links, item.Enclosure.URL)
+	}
+	for _, link := range links {
+		if strings.HasPrefix(link, "magnet:") {
+			if h := utils.ExtractInfoHash(link); h != "" {
+				return strings.ToLower(h)
+			}
+		}
+	}
+	return ""
+}
+
+func (p *Proxy) rewriteLinks(r *http.Request, indexer *config.Indexer, item *Item) {
+	item.Link = p.downloadURL(r, indexer, item.Link)
+	if item.Enclosure != nil {
+		item.Enclosure.URL = p.downloadURL(r, indexer, item.Enclosure.URL)
+	}
+	if magnet := item.Attr("magneturl"); magnet != "" {
+		item.SetAttr("magneturl", p.downloadURL(r, indexer, magnet))
+	}
+}
+
+// downloadURL points a magnet or an indexer download link at decypharr, links elsewhere are left alone.
+// The upstream apikey is swapped out so it never reaches the arr
+func (p *Proxy) downloadURL(r *http.Request, indexer *config.Indexer, link string) string {
+	if !strings.HasPrefix(link, "magnet:") {
+		if !isIndexerLink(indexer, link) {
+			return link
+		}
+		if indexer.APIKey != "" {
+			link = strings.ReplaceAll(link, url.QueryEscape(indexer.APIKey), apiKeyPlaceholder)
+		}
+	}
+
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	if proto := r.Header.Get("X-Forwarded-Proto"); proto != "" {
+		scheme = proto
+	}
+	q := url.Values{}
+	q.Set("link", link)
+	if apiKey := r.URL.Query().Get("apikey"); apiKey != "" {
+		q.Set("apikey", apiKey)
+	}
+	return fmt.Sprintf("%s://%s%sindexers/%s/download?%s", scheme, r.Host, config.Get().URLBase, url.PathEscape(indexer.Name), q.Encode())
+}
+
+// isIndexerLink reports whether the link is on the indexer's host
+func isIndexerLink(indexer *config.Indexer, link string) bool {
+	u, err := url.Parse(link)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return false
+	}
+	upstream, err := url.Parse(indexer.URL)
+	if err != nil {
+		return false
+	}
+	return strings.EqualFold(u.Host, upstream.Host)
+}
+
+// handleDownload resolves a rewritten link. Magnets are redirected to, torrents and nzbs are fetched from the indexer
+func (p *Proxy) handleDownload(w http.ResponseWriter, r *http.Request) {
+	indexer := getIndexer(r.Context())
+	link := r.URL.Query().Get("link")
+	if strings.HasPrefix(link, "magnet:") {
+		http.Redirect(w, r, link, http.StatusFound)
+		return
+	}
+	// Only the indexer's own links are fetched, decypharr is not an open proxy
+	if !isIndexerLink(indexer, link) {
+		http.Error(w, "Invalid link", http.StatusBadRequest)
+		return
+	}
+	link = strings.ReplaceAll(link, apiKeyPlaceholder, url.QueryEscape(indexer.APIKey))
+
+	req, err := http.NewRequestWithContext(r.Context(), http.MethodGet, link, nil)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	resp, err := p.client.Do(req)
+	if err != nil {
+		p.logger.Error().Err(err).Str("indexer", indexer.Name).Msg("Failed to download from indexer")
+		http.Error(w, "Indexer unreachable", http.StatusBadGateway)
+		return
+	}
+	defer resp.Body.Close()
+
+	if location := resp.Header.Get("Location"); location != "" && resp.StatusCode >= 300 && resp.StatusCode < 400 {
+		http.Redirect(w, r, location, http.StatusFound)
+		return
+	}
+	for _, header := range []string{"Content-Type", "Content-Disposition", "Content-Length"} {
+		if value := resp.Header.Get(header); value != "" {
+			w.Header().Set(header, value)
+		}
+	}
+	w.WriteHeader(resp.StatusCode)
+	_, _ = io.Copy(w, resp.Body)
+}
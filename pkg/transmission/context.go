@@ -0,0 +1,101 @@
+package transmission
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/sirrobot01/decypharr/internal/config"
+	"github.com/sirrobot01/decypharr/pkg/arr"
+	"github.com/sirrobot01/decypharr/pkg/wire"
+)
+
+type contextKey string
+
+const credentialsKey contextKey = "credentials"
+
+type credentials struct {
+	username string
+	password string
+}
+
+func getCredentials(ctx context.Context) credentials {
+	if c, ok := ctx.Value(credentialsKey).(credentials); ok {
+		return c
+	}
+	return credentials{}
+}
+
+// authContext checks the basic auth of the request. The username can be the Arr host and the password its token,
+// like the qbit username and password, or decypharr's own username/password or API token
+func (t *Transmission) authContext(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		username, password, _ := r.BasicAuth()
+		username, password = strings.TrimSpace(username), strings.TrimSpace(password)
+		if err := t.authenticate(username, password); err != nil {
+			w.Header().Set("WWW-Authenticate", `Basic realm="Transmission"`)
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+		ctx := context.WithValue(r.Context(), credentialsKey, credentials{username: username, password: password})
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// sessionContext asks for the session id first, like Transmission does to protect against CSRF.
+// Clients retry with the id from the 409 response
+func (t *Transmission) sessionContext(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Transmission-Session-Id", t.sessionId)
+		if r.Header.Get("X-Transmission-Session-Id") != t.sessionId {
+			http.Error(w, "Invalid session id", http.StatusConflict)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (t *Transmission) authenticate(username, password string) error {
+	cfg := config.Get()
+	if !cfg.UseAuth {
+		return nil
+	}
+	if auth := cfg.GetAuth(); auth != nil && auth.APIToken != "" && password == auth.APIToken {
+		return nil
+	}
+	if strings.HasPrefix(username, "http") && password != "" {
+		for _, a := range wire.Get().Arr().GetAll() {
+			if a.Host == username && a.Token == password {
+				return nil
+			}
+		}
+		if err := arr.New("", username, password, false, false, nil, "", "").Validate(); err == nil {
+			return nil
+		}
+	}
+	if config.VerifyAuth(username, password) {
+		return nil
+	}
+	return fmt.Errorf("unauthorized: invalid credentials")
+}
+
+// getArr returns the arr for the category, the Arr host and token from the credentials are stored along with it
+func (t *Transmission) getArr(ctx context.Context, category string) *arr.Arr {
+	arrs := wire.Get().Arr()
+	a := arrs.Get(category)
+	if a == nil {
+		// Arr is not configured, create a new one
+		downloadUncached := false
+		a = arr.New(category, "", "", false, false, &downloadUncached, "", "auto")
+	}
+	creds := getCredentials(ctx)
+	if category == "" || !strings.HasPrefix(creds.username, "http") || creds.password == "" {
+		return a
+	}
+	a.Host = creds.username
+	a.Token = creds.password
+	a.Source = "auto"
+	arrs.AddOrUpdate(a)
+	return a
+}
@@ -0,0 +1,159 @@
+package transmission
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/sirrobot01/decypharr/internal/request"
+)
+
+const (
+	version    = "4.0.5 (a6fe2a64aa)"
+	rpcVersion = 17
+)
+
+func (t *Transmission) handleRPC(w http.ResponseWriter, r *http.Request) {
+	var req Request
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	var (
+		args any
+		err  error
+	)
+	switch req.Method {
+	case "session-get":
+		args = t.session()
+	case "session-stats":
+		args = t.sessionStats()
+	case "torrent-add":
+		args, err = t.handleTorrentAdd(r.Context(), req.Arguments)
+	case "torrent-get":
+		args, err = t.handleTorrentGet(req.Arguments)
+	case "torrent-remove":
+		args, err = t.handleTorrentRemove(req.Arguments)
+	case "session-set", "torrent-set", "torrent-start", "torrent-start-now", "torrent-stop", "torrent-verify", "torrent-reannounce",
+		"queue-move-top", "queue-move-up", "queue-move-down", "queue-move-bottom":
+		// Nothing to do for torrents on a debrid
+		args = struct{}{}
+	default:
+		err = fmt.Errorf("method name not recognized")
+	}
+
+	res := Response{Result: "success", Arguments: args, Tag: req.Tag}
+	if err != nil {
+		t.logger.Debug().Err(err).Str("method", req.Method).Msg("RPC call failed")
+		res.Result = err.Error()
+		res.Arguments = struct{}{}
+	}
+	request.JSONResponse(w, res, http.StatusOK)
+}
+
+func (t *Transmission) session() Session {
+	return Session{
+		Version:            version,
+		RPCVersion:         rpcVersion,
+		RPCVersionMinimum:  14,
+		RPCVersionSemver:   "5.3.0",
+		DownloadDir:        t.DownloadFolder,
+		StartAddedTorrents: true,
+		SeedRatioLimit:     1,
+		SeedRatioLimited:   true,
+		SessionId:          t.sessionId,
+	}
+}
+
+func (t *Transmission) sessionStats() SessionStats {
+	stats := SessionStats{}
+	for _, tor := range t.storage.GetTorrents("", "", nil, "added_on", true) {
+		stats.TorrentCount++
+		if tor.State == "pausedUP" || tor.State == "error" {
+			stats.PausedTorrentCount++
+		} else {
+			stats.ActiveTorrentCount++
+		}
+		stats.DownloadSpeed += tor.Dlspeed
+		stats.UploadSpeed += tor.Upspeed
+	}
+	return stats
+}
+
+func (t *Transmission) handleTorrentAdd(ctx context.Context, raw json.RawMessage) (map[string]AddedTorrent, error) {
+	var args TorrentAddArguments
+	if err := json.Unmarshal(raw, &args); err != nil {
+		return nil, fmt.Errorf("invalid arguments: %w", err)
+	}
+	magnet, err := getMagnet(args)
+	if err != nil {
+		return nil, err
+	}
+	hash := strings.ToLower(magnet.InfoHash)
+	if existing := t.storage.Get(hash, ""); existing != nil {
+		return map[string]AddedTorrent{
+			"torrent-duplicate": {Id: torrentId(existing.Hash), HashString: existing.Hash, Name: existing.Name},
+		}, nil
+	}
+	if err := t.addTorrent(ctx, magnet, t.category(args)); err != nil {
+		return nil, err
+	}
+	return map[string]AddedTorrent{
+		"torrent-added": {Id: torrentId(hash), HashString: hash, Name: magnet.Name},
+	}, nil
+}
+
+func (t *Transmission) handleTorrentGet(raw json.RawMessage) (map[string]any, error) {
+	var args TorrentGetArguments
+	if len(raw) > 0 {
+		if err := json.Unmarshal(raw, &args); err != nil {
+			return nil, fmt.Errorf("invalid arguments: %w", err)
+		}
+	}
+	match, err := matchIds(args.Ids)
+	if err != nil {
+		return nil, err
+	}
+	torrents := make([]map[string]any, 0)
+	for _, tor := range t.storage.GetTorrents("", "", nil, "added_on", true) {
+		if !match(tor) {
+			continue
+		}
+		fields := torrentFields(tor)
+		if len(args.Fields) > 0 {
+			selected := make(map[string]any, len(args.Fields))
+			for _, field := range args.Fields {
+				if value, ok := fields[field]; ok {
+					selected[field] = value
+				}
+			}
+			fields = selected
+		}
+		torrents = append(torrents, fields)
+	}
+	return map[string]any{"torrents": torrents}, nil
+}
+
+func (t *Transmission) handleTorrentRemove(raw json.RawMessage) (struct{}, error) {
+	var args TorrentRemoveArguments
+	if err := json.Unmarshal(raw, &args); err != nil {
+		return struct{}{}, fmt.Errorf("invalid arguments: %w", err)
+	}
+	if len(args.Ids) == 0 || string(args.Ids) == "null" {
+		// Transmission removes nothing without ids, not everything
+		return struct{}{}, nil
+	}
+	match, err := matchIds(args.Ids)
+	if err != nil {
+		return struct{}{}, err
+	}
+	for _, tor := range t.storage.GetTorrents("", "", nil, "added_on", true) {
+		if match(tor) {
+			t.storage.Delete(tor.Hash, tor.Category, false)
+		}
+	}
+	return struct{}{}, nil
+}
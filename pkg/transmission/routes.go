@@ -0,0 +1,18 @@
+package transmission
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func (t *Transmission) Routes() http.Handler {
+	r := chi.NewRouter()
+	r.Group(func(r chi.Router) {
+		r.Use(t.authContext)
+		r.Use(t.sessionContext)
+		r.Get("/rpc", t.handleRPC)
+		r.Post("/rpc", t.handleRPC)
+	})
+	return r
+}
@@ -0,0 +1,207 @@
+package transmission
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/sirrobot01/decypharr/internal/utils"
+	"github.com/sirrobot01/decypharr/pkg/wire"
+)
+
+// All torrent-related helpers goes here
+func (t *Transmission) addTorrent(ctx context.Context, magnet *utils.Magnet, category string) error {
+	_arr := t.getArr(ctx, category)
+	importReq := wire.NewImportRequest("", t.DownloadFolder, magnet, _arr, wire.ImportAction(_arr, ""), false, "", wire.ImportTypeTransmission, false)
+	if err := wire.Get().AddTorrent(ctx, importReq); err != nil {
+		return fmt.Errorf("failed to process torrent: %w", err)
+	}
+	return nil
+}
+
+// getMagnet reads the torrent-add arguments, metainfo is a base64 .torrent and filename a magnet or a .torrent url
+func getMagnet(args TorrentAddArguments) (*utils.Magnet, error) {
+	if args.Metainfo != "" {
+		data, err := base64.StdEncoding.DecodeString(args.Metainfo)
+		if err != nil {
+			return nil, fmt.Errorf("invalid metainfo: %w", err)
+		}
+		return utils.GetMagnetFromBytes(data)
+	}
+	if args.Filename != "" {
+		return utils.GetMagnetFromUrl(strings.TrimSpace(args.Filename))
+	}
+	return nil, fmt.Errorf("no filename or metainfo given")
+}
+
+// category finds the category of an added torrent, from its labels or its download dir.
+// The arrs point the download dir at a subfolder of the session download dir named after their category
+func (t *Transmission) category(args TorrentAddArguments) string {
+	for _, label := range args.Labels {
+		if label = strings.TrimSpace(label); label != "" {
+			return label
+		}
+	}
+	if args.DownloadDir == "" {
+		return ""
+	}
+	dir := filepath.Clean(args.DownloadDir)
+	rel, err := filepath.Rel(filepath.Clean(t.DownloadFolder), dir)
+	if err == nil && rel == "." {
+		return ""
+	}
+	if err == nil && filepath.IsLocal(rel) {
+		return strings.Split(filepath.ToSlash(rel), "/")[0]
+	}
+	return filepath.Base(dir)
+}
+
+// torrentId derives the numeric id from the hash, so it stays the same across restarts
+func torrentId(hash string) int {
+	if len(hash) < 7 {
+		return 0
+	}
+	id, err := strconv.ParseInt(hash[:7], 16, 64)
+	if err != nil {
+		return 0
+	}
+	return int(id) + 1
+}
+
+// matchIds parses the ids argument: an id, a list of ids and hashes, or "recently-active".
+// Without ids all torrents match
+func matchIds(raw json.RawMessage) (func(tor *wire.Torrent) bool, error) {
+	all := func(tor *wire.Torrent) bool { return true }
+	if len(raw) == 0 || string(raw) == "null" {
+		return all, nil
+	}
+	var value any
+	if err := json.Unmarshal(raw, &value); err != nil {
+		return nil, fmt.Errorf("invalid ids: %w", err)
+	}
+	var list []any
+	switch v := value.(type) {
+	case string:
+		if v == "recently-active" {
+			return all, nil
+		}
+		list = []any{v}
+	case float64:
+		list = []any{v}
+	case []any:
+		list = v
+	default:
+		return nil, fmt.Errorf("invalid ids")
+	}
+	hashes := make(map[string]bool)
+	ids := make(map[int]bool)
+	for _, item := range list {
+		switch v := item.(type) {
+		case string:
+			hashes[strings.ToLower(v)] = true
+		case float64:
+			ids[int(v)] = true
+		}
+	}
+	return func(tor *wire.Torrent) bool {
+		return hashes[tor.Hash] || ids[torrentId(tor.Hash)]
+	}, nil
+}
+
+// torrentFields returns all the supported fields of a torrent, keyed by their RPC name
+func torrentFields(tor *wire.Torrent) map[string]any {
+	finished := tor.State == "pausedUP"
+	status := statusDownload
+	switch tor.State {
+	case "pausedUP", "error":
+		status = statusStopped
+	case "queued":
+		status = statusDownloadWait
+	}
+	errorCode, errorString := 0, ""
+	if tor.State == "error" {
+		errorCode, errorString = 3, "Failed to process torrent on debrid"
+	}
+	leftUntilDone, eta, ratio := tor.AmountLeft, tor.Eta, 0.0
+	if finished {
+		leftUntilDone, eta, ratio = 0, -1, 1
+	}
+	if eta <= 0 {
+		eta = -1
+	}
+
+	// The content path is the symlink folder, its name can differ from the torrent name
+	name := tor.Name
+	downloadDir := strings.TrimSuffix(tor.SavePath, string(os.PathSeparator))
+	if tor.ContentPath != "" {
+		name = filepath.Base(tor.ContentPath)
+		downloadDir = filepath.Dir(tor.ContentPath)
+	}
+	labels := make([]string, 0, 1)
+	if tor.Category != "" {
+		labels = append(labels, tor.Category)
+	}
+
+	skipped := tor.SkippedFiles()
+	files := make([]File, 0, len(tor.Files))
+	fileStats := make([]FileStat, 0, len(tor.Files))
+	for _, f := range tor.Files {
+		completed := int64(float64(f.Size) * tor.Progress)
+		if finished {
+			completed = f.Size
+		}
+		files = append(files, File{Name: path.Join(name, f.Name), Length: f.Size, BytesCompleted: completed})
+		fileStats = append(fileStats, FileStat{BytesCompleted: completed, Wanted: !slices.Contains(skipped, f.Name)})
+	}
+
+	return map[string]any{
+		"id":                      torrentId(tor.Hash),
+		"hashString":              tor.Hash,
+		"name":                    name,
+		"downloadDir":             downloadDir,
+		"status":                  status,
+		"error":                   errorCode,
+		"errorString":             errorString,
+		"isFinished":              finished,
+		"isStalled":               false,
+		"percentDone":             tor.Progress,
+		"totalSize":               tor.Size,
+		"sizeWhenDone":            tor.Size,
+		"leftUntilDone":           leftUntilDone,
+		"haveValid":               tor.Completed,
+		"eta":                     eta,
+		"rateDownload":            tor.Dlspeed,
+		"rateUpload":              tor.Upspeed,
+		"downloadedEver":          tor.Downloaded,
+		"uploadedEver":            tor.Uploaded,
+		"uploadRatio":             ratio,
+		"seedRatioLimit":          1,
+		"seedRatioMode":           1,
+		"seedIdleLimit":           0,
+		"seedIdleMode":            0,
+		"addedDate":               tor.AddedOn,
+		"activityDate":            tor.AddedOn,
+		"doneDate":                tor.CompletionOn,
+		"secondsSeeding":          0,
+		"queuePosition":           0,
+		"peersConnected":          0,
+		"labels":                  labels,
+		"magnetLink":              tor.MagnetUri,
+		"fileCount":               len(files),
+		"files":                   files,
+		"fileStats":               fileStats,
+		"trackers":                []any{},
+		"trackerStats":            []any{},
+		"downloadLimited":         false,
+		"uploadLimited":           false,
+		"isPrivate":               false,
+		"metadataPercentComplete": 1,
+	}
+}
@@ -0,0 +1,32 @@
+package transmission
+
+import (
+	"crypto/rand"
+
+	"github.com/rs/zerolog"
+	"github.com/sirrobot01/decypharr/internal/config"
+	"github.com/sirrobot01/decypharr/internal/logger"
+	"github.com/sirrobot01/decypharr/pkg/wire"
+)
+
+// Transmission emulates enough of the Transmission RPC for the arrs and other tools to use decypharr as a Transmission client.
+// It shares the download folder, categories and torrent storage with the qbittorrent API, categories map to labels and download dirs
+type Transmission struct {
+	DownloadFolder string
+	Categories     []string
+	storage        *wire.TorrentStorage
+	logger         zerolog.Logger
+	sessionId      string // Sent back in X-Transmission-Session-Id, see the RPC spec on CSRF protection
+}
+
+func New() *Transmission {
+	_cfg := config.Get()
+	cfg := _cfg.QBitTorrent
+	return &Transmission{
+		DownloadFolder: cfg.DownloadFolder,
+		Categories:     cfg.Categories,
+		storage:        wire.Get().Torrents(),
+		logger:         logger.New("transmission"),
+		sessionId:      rand.Text(),
+	}
+}
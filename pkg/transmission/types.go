@@ -0,0 +1,92 @@
+package transmission
+
+import "encoding/json"
+
+// Torrent statuses, see tr_torrent_activity
+const (
+	statusStopped      = 0
+	statusCheckWait    = 1
+	statusCheck        = 2
+	statusDownloadWait = 3
+	statusDownload     = 4
+	statusSeedWait     = 5
+	statusSeed         = 6
+)
+
+type Request struct {
+	Method    string          `json:"method"`
+	Arguments json.RawMessage `json:"arguments,omitempty"`
+	Tag       any             `json:"tag,omitempty"`
+}
+
+type Response struct {
+	Result    string `json:"result"`
+	Arguments any    `json:"arguments"`
+	Tag       any    `json:"tag,omitempty"`
+}
+
+type TorrentAddArguments struct {
+	Filename    string   `json:"filename"`
+	Metainfo    string   `json:"metainfo"`
+	DownloadDir string   `json:"download-dir"`
+	Paused      bool     `json:"paused"`
+	Labels      []string `json:"labels"`
+}
+
+type TorrentGetArguments struct {
+	Fields []string        `json:"fields"`
+	Ids    json.RawMessage `json:"ids"`
+}
+
+type TorrentRemoveArguments struct {
+	Ids             json.RawMessage `json:"ids"`
+	DeleteLocalData bool            `json:"delete-local-data"`
+}
+
+type AddedTorrent struct {
+	Id         int    `json:"id"`
+	HashString string `json:"hashString"`
+	Name       string `json:"name"`
+}
+
+type File struct {
+	Name           string `json:"name"`
+	Length         int64  `json:"length"`
+	BytesCompleted int64  `json:"bytesCompleted"`
+}
+
+type FileStat struct {
+	BytesCompleted int64 `json:"bytesCompleted"`
+	Wanted         bool  `json:"wanted"`
+	Priority       int   `json:"priority"`
+}
+
+type Session struct {
+	Version                 string `json:"version"`
+	RPCVersion              int    `json:"rpc-version"`
+	RPCVersionMinimum       int    `json:"rpc-version-minimum"`
+	RPCVersionSemver        string `json:"rpc-version-semver"`
+	DownloadDir             string `json:"download-dir"`
+	IncompleteDir           string `json:"incomplete-dir"`
+	IncompleteDirEnabled    bool   `json:"incomplete-dir-enabled"`
+	StartAddedTorrents      bool   `json:"start-added-torrents"`
+	SeedRatioLimit          int    `json:"seedRatioLimit"`
+	SeedRatioLimited        bool   `json:"seedRatioLimited"`
+	IdleSeedingLimit        int    `json:"idle-seeding-limit"`
+	IdleSeedingLimitEnabled bool   `json:"idle-seeding-limit-enabled"`
+	DownloadQueueEnabled    bool   `json:"download-queue-enabled"`
+	DownloadQueueSize       int    `json:"download-queue-size"`
+	SpeedLimitDown          int    `json:"speed-limit-down"`
+	SpeedLimitDownEnabled   bool   `json:"speed-limit-down-enabled"`
+	SpeedLimitUp            int    `json:"speed-limit-up"`
+	SpeedLimitUpEnabled     bool   `json:"speed-limit-up-enabled"`
+	SessionId               string `json:"session-id"`
+}
+
+type SessionStats struct {
+	ActiveTorrentCount int   `json:"activeTorrentCount"`
+	PausedTorrentCount int   `json:"pausedTorrentCount"`
+	TorrentCount       int   `json:"torrentCount"`
+	DownloadSpeed      int64 `json:"downloadSpeed"`
+	UploadSpeed        int64 `json:"uploadSpeed"`
+}
@@ -1,8 +1,10 @@
 package web
 
 import (
+	"cmp"
 	"fmt"
 	"net/http"
+	"slices"
 	"strings"
 	"time"
 
@@ -13,9 +15,11 @@ import (
 
 	"github.com/go-chi/chi/v5"
 	"github.com/sirrobot01/decypharr/internal/config"
+	"github.com/sirrobot01/decypharr/internal/notify"
 	"github.com/sirrobot01/decypharr/internal/request"
 	"github.com/sirrobot01/decypharr/internal/utils"
 	"github.com/sirrobot01/decypharr/pkg/arr"
+	"github.com/sirrobot01/decypharr/pkg/debrid/common"
 	"github.com/sirrobot01/decypharr/pkg/version"
 )
 
@@ -24,6 +28,69 @@ func (wb *Web) handleGetArrs(w http.ResponseWriter, r *http.Request) {
 	request.JSONResponse(w, arrStorage.GetAll(), http.StatusOK)
 }
 
+// handleAutoConfigureArr adds an arr from its url and api key, registering Decypharr as its download client
+func (wb *Web) handleAutoConfigureArr(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Name         string `json:"name"`
+		Host         string `json:"host"`
+		Token        string `json:"token"`
+		DecypharrURL string `json:"decypharr_url"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if request.ValidateURL(req.Host) != nil || req.Token == "" {
+		http.Error(w, "A valid arr url and api key are required", http.StatusBadRequest)
+		return
+	}
+	if request.ValidateURL(req.DecypharrURL) != nil {
+		http.Error(w, "A valid Decypharr url is required", http.StatusBadRequest)
+		return
+	}
+
+	arrStorage := wire.Get().Arr()
+	a := arr.New(req.Name, req.Host, req.Token, false, false, nil, "", "auto")
+	if a.Type == arr.Others {
+		if err := a.DetectType(); err != nil {
+			http.Error(w, err.Error(), http.StatusBadGateway)
+			return
+		}
+	}
+	// The name is the category the arr downloads with
+	a.Name = strings.ToLower(cmp.Or(strings.TrimSpace(req.Name), string(a.Type)))
+	if existing := arrStorage.Get(a.Name); existing != nil {
+		a.Cleanup = existing.Cleanup
+		a.SkipRepair = existing.SkipRepair
+		a.DownloadUncached = existing.DownloadUncached
+		a.SelectedDebrid = existing.SelectedDebrid
+	}
+
+	status, err := arrStorage.AutoConfigure(a, req.DecypharrURL)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to configure %s: %v", a.Name, err), http.StatusBadGateway)
+		return
+	}
+
+	cfg := config.Get()
+	cfg.Arrs = arrStorage.SyncToConfig()
+	if err := cfg.Save(); err != nil {
+		http.Error(w, "Error saving config: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	request.JSONResponse(w, status, http.StatusOK)
+}
+
+func (wb *Web) handleGetDownloadClients(w http.ResponseWriter, r *http.Request) {
+	request.JSONResponse(w, wire.Get().Arr().DownloadClientStatuses(), http.StatusOK)
+}
+
+func (wb *Web) handleSyncDownloadClients(w http.ResponseWriter, r *http.Request) {
+	arrStorage := wire.Get().Arr()
+	arrStorage.SyncDownloadClients()
+	request.JSONResponse(w, arrStorage.DownloadClientStatuses(), http.StatusOK)
+}
+
 func (wb *Web) handleAddContent(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	if err := r.ParseMultipartForm(32 << 20); err != nil {
@@ -134,9 +201,14 @@ func (wb *Web) handleRepairMedia(w http.ResponseWriter, r *http.Request) {
 		arrs = append(arrs, req.ArrName)
 	}
 
+	addJob := _store.Repair().AddJob
+	if req.Deep {
+		addJob = _store.Repair().AddDeepJob
+	}
+
 	if req.Async {
 		go func() {
-			if err := _store.Repair().AddJob(arrs, req.MediaIds, req.AutoProcess, false); err != nil {
+			if err := addJob(arrs, req.MediaIds, req.AutoProcess, false); err != nil {
 				wb.logger.Error().Err(err).Msg("Failed to repair media")
 			}
 		}()
@@ -144,7 +216,7 @@ func (wb *Web) handleRepairMedia(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := _store.Repair().AddJob([]string{req.ArrName}, req.MediaIds, req.AutoProcess, false); err != nil {
+	if err := addJob([]string{req.ArrName}, req.MediaIds, req.AutoProcess, false); err != nil {
 		http.Error(w, fmt.Sprintf("Failed to repair: %v", err), http.StatusInternalServerError)
 		return
 	}
@@ -173,6 +245,118 @@ func (wb *Web) handleDeleteTorrent(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusOK)
 }
 
+func (wb *Web) handleGetTorrentFiles(w http.ResponseWriter, r *http.Request) {
+	torrent := wb.torrents.Get(chi.URLParam(r, "hash"), chi.URLParam(r, "category"))
+	if torrent == nil {
+		http.Error(w, "Torrent not found", http.StatusNotFound)
+		return
+	}
+	type torrentFile struct {
+		Index    int    `json:"index"`
+		Name     string `json:"name"`
+		Size     int64  `json:"size"`
+		Priority int    `json:"priority"`
+		Skipped  bool   `json:"skipped"`
+	}
+	skipped := torrent.SkippedFiles()
+	files := make([]torrentFile, 0, len(torrent.Files))
+	for _, f := range torrent.Files {
+		files = append(files, torrentFile{
+			Index:    f.Index,
+			Name:     f.Name,
+			Size:     f.Size,
+			Priority: f.Priority,
+			Skipped:  slices.Contains(skipped, f.Name),
+		})
+	}
+	request.JSONResponse(w, files, http.StatusOK)
+}
+
+// handleSetTorrentFiles skips the files with the given indexes and selects the others
+func (wb *Web) handleSetTorrentFiles(w http.ResponseWriter, r *http.Request) {
+	torrent := wb.torrents.Get(chi.URLParam(r, "hash"), chi.URLParam(r, "category"))
+	if torrent == nil {
+		http.Error(w, "Torrent not found", http.StatusNotFound)
+		return
+	}
+	var req struct {
+		Skipped []int `json:"skipped"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if err := wire.Get().SetSkippedFiles(torrent, req.Skipped); err != nil {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// handleGetQueue lists the import requests waiting for a debrid slot, in the order they'll be processed
+func (wb *Web) handleGetQueue(w http.ResponseWriter, r *http.Request) {
+	type queuedItem struct {
+		Position         int    `json:"position"`
+		ID               string `json:"id"`
+		Name             string `json:"name"`
+		InfoHash         string `json:"info_hash"`
+		Size             int64  `json:"size"`
+		Arr              string `json:"arr"`
+		Debrid           string `json:"debrid"`
+		Action           string `json:"action"`
+		DownloadUncached bool   `json:"download_uncached"`
+		Type             string `json:"type"`
+	}
+	requests := wire.Get().ImportQueue().List()
+	items := make([]queuedItem, 0, len(requests))
+	for i, req := range requests {
+		items = append(items, queuedItem{
+			Position:         i,
+			ID:               req.Id,
+			Name:             req.Magnet.Name,
+			InfoHash:         req.Magnet.InfoHash,
+			Size:             req.Magnet.Size,
+			Arr:              req.Arr.Name,
+			Debrid:           req.SelectedDebrid,
+			Action:           req.Action,
+			DownloadUncached: req.DownloadUncached,
+			Type:             string(req.Type),
+		})
+	}
+	request.JSONResponse(w, items, http.StatusOK)
+}
+
+func (wb *Web) handleMoveQueued(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Position int `json:"position"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if err := wire.Get().ImportQueue().Move(chi.URLParam(r, "id"), req.Position); err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+func (wb *Web) handleRetryQueued(w http.ResponseWriter, r *http.Request) {
+	if err := wire.Get().RetryQueued(r.Context(), chi.URLParam(r, "id")); err != nil {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+func (wb *Web) handleCancelQueued(w http.ResponseWriter, r *http.Request) {
+	if err := wire.Get().CancelQueued(chi.URLParam(r, "id")); err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
 func (wb *Web) handleDeleteTorrents(w http.ResponseWriter, r *http.Request) {
 	hashesStr := r.URL.Query().Get("hashes")
 	removeFromDebrid := r.URL.Query().Get("removeFromDebrid") == "true"
@@ -230,7 +414,9 @@ func (wb *Web) handleUpdateConfig(w http.ResponseWriter, r *http.Request) {
 	currentConfig.RemoveStalledAfter = updatedConfig.RemoveStalledAfter
 	currentConfig.AllowedExt = updatedConfig.AllowedExt
 	currentConfig.DiscordWebhook = updatedConfig.DiscordWebhook
+	currentConfig.Notifications = updatedConfig.Notifications
 	currentConfig.CallbackURL = updatedConfig.CallbackURL
+	currentConfig.DebridSelection = updatedConfig.DebridSelection
 
 	// Should this be added?
 	currentConfig.URLBase = updatedConfig.URLBase
@@ -333,6 +519,164 @@ func (wb *Web) handleStopRepairJob(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusOK)
 }
 
+func (wb *Web) handleGetRepairAudit(w http.ResponseWriter, r *http.Request) {
+	// Without a job ID, the whole repair history is returned
+	id := chi.URLParam(r, "id")
+	_store := wire.Get()
+	entries, err := _store.Repair().GetAudit(id)
+	if err != nil {
+		wb.logger.Error().Err(err).Msg("Failed to get repair audit log")
+		http.Error(w, "Failed to get audit log: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	request.JSONResponse(w, entries, http.StatusOK)
+}
+
+func (wb *Web) handleUndoRepair(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		IDs []string `json:"ids"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if len(req.IDs) == 0 {
+		http.Error(w, "No audit entry IDs provided", http.StatusBadRequest)
+		return
+	}
+	_store := wire.Get()
+	entries, err := _store.Repair().Undo(req.IDs)
+	if err != nil {
+		wb.logger.Error().Err(err).Msg("Failed to undo repair")
+		http.Error(w, "Failed to undo: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	request.JSONResponse(w, entries, http.StatusOK)
+}
+
+func (wb *Web) handleTestNotification(w http.ResponseWriter, r *http.Request) {
+	var target config.Notification
+	if err := json.NewDecoder(r.Body).Decode(&target); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if target.URL == "" {
+		http.Error(w, "Notification URL is required", http.StatusBadRequest)
+		return
+	}
+	if err := notify.Test(r.Context(), target); err != nil {
+		wb.logger.Error().Err(err).Msg("Failed to send test notification")
+		http.Error(w, "Failed to send test notification: "+err.Error(), http.StatusBadGateway)
+		return
+	}
+	request.JSONResponse(w, map[string]string{"status": "success"}, http.StatusOK)
+}
+
+func (wb *Web) handleSignStream(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Debrid  string `json:"debrid"`
+		Torrent string `json:"torrent"`
+		File    string `json:"file"`
+		TTL     string `json:"ttl"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	cache, ok := wire.Get().Debrid().Caches()[req.Debrid]
+	if !ok {
+		http.Error(w, "Debrid not found or WebDAV is disabled: "+req.Debrid, http.StatusNotFound)
+		return
+	}
+	var ttl time.Duration
+	if req.TTL != "" {
+		var err error
+		if ttl, err = time.ParseDuration(req.TTL); err != nil {
+			http.Error(w, "Invalid ttl: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+	ct := cache.GetTorrentByName(req.Torrent)
+	if ct == nil {
+		http.Error(w, "Torrent not found: "+req.Torrent, http.StatusNotFound)
+		return
+	}
+	if _, ok := ct.GetFile(req.File); !ok {
+		http.Error(w, "File not found: "+req.File, http.StatusNotFound)
+		return
+	}
+	u, expires := cache.StreamURL(req.Torrent, req.File, ttl)
+	request.JSONResponse(w, map[string]any{"url": u, "expires": expires}, http.StatusOK)
+}
+
+// handleCheckCached checks which debrids have the hashes cached, from ?hashes=a,b or a JSON body.
+// Both take an optional debrid filter
+func (wb *Web) handleCheckCached(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Hashes  []string `json:"hashes"`
+		Debrids []string `json:"debrids"`
+	}
+	if r.Method == http.MethodPost {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	} else {
+		q := r.URL.Query()
+		if v := q.Get("hashes"); v != "" {
+			req.Hashes = strings.Split(v, ",")
+		}
+		if v := q.Get("debrid"); v != "" {
+			req.Debrids = strings.Split(v, ",")
+		}
+	}
+	if len(req.Hashes) == 0 {
+		http.Error(w, "No hashes provided", http.StatusBadRequest)
+		return
+	}
+	if len(req.Hashes) > 500 {
+		http.Error(w, "Too many hashes, max 500 per request", http.StatusBadRequest)
+		return
+	}
+	for i, h := range req.Hashes {
+		hash, err := utils.ParseInfoHash(h)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		req.Hashes[i] = hash
+	}
+
+	debridStorage := wire.Get().Debrid()
+	clients := debridStorage.Clients()
+	if len(req.Debrids) > 0 {
+		selected := make(map[string]common.Client, len(req.Debrids))
+		for _, name := range req.Debrids {
+			client, ok := clients[strings.TrimSpace(name)]
+			if !ok {
+				http.Error(w, "Debrid not found: "+name, http.StatusNotFound)
+				return
+			}
+			selected[client.Name()] = client
+		}
+		clients = selected
+	}
+
+	type cachedResult struct {
+		Cached  bool            `json:"cached"`
+		Debrids map[string]bool `json:"debrids"`
+	}
+	results := make(map[string]cachedResult)
+	for hash, debrids := range debridStorage.Availability(req.Hashes, clients) {
+		result := cachedResult{Debrids: debrids}
+		for _, ok := range debrids {
+			result.Cached = result.Cached || ok
+		}
+		results[hash] = result
+	}
+	request.JSONResponse(w, results, http.StatusOK)
+}
+
 func (wb *Web) handleRefreshAPIToken(w http.ResponseWriter, _ *http.Request) {
 	token, err := wb.refreshAPIToken()
 	if err != nil {
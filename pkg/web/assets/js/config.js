@@ -3,6 +3,7 @@ class ConfigManager {
     constructor() {
         this.debridCount = 0;
         this.arrCount = 0;
+        this.notificationCount = 0;
         this.debridDirectoryCounts = {};
         this.directoryFilterCounts = {};
 
@@ -12,7 +13,9 @@ class ConfigManager {
             debridConfigs: document.getElementById('debridConfigs'),
             arrConfigs: document.getElementById('arrConfigs'),
             addDebridBtn: document.getElementById('addDebridBtn'),
-            addArrBtn: document.getElementById('addArrBtn')
+            addArrBtn: document.getElementById('addArrBtn'),
+            notificationConfigs: document.getElementById('notificationConfigs'),
+            addNotificationBtn: document.getElementById('addNotificationBtn')
         };
 
         this.init();
@@ -40,6 +43,7 @@ class ConfigManager {
         // Add buttons
         this.refs.addDebridBtn.addEventListener('click', () => this.addDebridConfig());
         this.refs.addArrBtn.addEventListener('click', () => this.addArrConfig());
+        this.refs.addNotificationBtn.addEventListener('click', () => this.addNotificationConfig());
 
         // WebDAV toggle handlers
         document.addEventListener('change', (e) => {
@@ -88,6 +92,11 @@ class ConfigManager {
         // Load rclone config
         this.populateRcloneSettings(config.rclone);
 
+        // Load notification targets
+        if (config.notifications && Array.isArray(config.notifications)) {
+            config.notifications.forEach(notification => this.addNotificationConfig(notification));
+        }
+
         // Load API token info
         this.populateAPIToken(config);
     }
@@ -95,7 +104,8 @@ class ConfigManager {
     populateGeneralSettings(config) {
         const fields = [
             'log_level', 'url_base', 'bind_address', 'port',
-            'discord_webhook_url', 'min_file_size', 'max_file_size', 'remove_stalled_after'
+            'discord_webhook_url', 'min_file_size', 'max_file_size', 'remove_stalled_after',
+            'debrid_selection'
         ];
 
         fields.forEach(field => {
@@ -131,7 +141,7 @@ class ConfigManager {
     populateRepairSettings(repairConfig) {
         if (!repairConfig) return;
 
-        const fields = ['enabled', 'interval', 'workers', 'zurg_url', 'strategy', 'use_webdav', 'auto_process'];
+        const fields = ['enabled', 'interval', 'deep_verify_interval', 'workers', 'zurg_url', 'webhook_secret', 'strategy', 'use_webdav', 'auto_process', 'webhook_on_playback'];
 
         fields.forEach(field => {
             const element = document.querySelector(`[name="repair.${field}"]`);
@@ -249,6 +259,7 @@ class ConfigManager {
                                 <option value="alldebrid">AllDebrid</option>
                                 <option value="debridlink">Debrid Link</option>
                                 <option value="torbox">Torbox</option>
+                                <option value="premiumize">Premiumize</option>
                             </select>
                         </div>
 
@@ -350,6 +361,17 @@ class ConfigManager {
                                 <span class="label-text-alt">Minimum free slot for this debrid</span>
                             </div>
                         </div>
+                        <div class="form-control">
+                            <label class="label" for="debrid[${index}].priority">
+                                <span class="label-text font-medium">Priority</span>
+                            </label>
+                            <input type="number" class="input input-bordered" 
+                                   name="debrid[${index}].priority" id="debrid[${index}].priority" 
+                                   placeholder="0" value="0">
+                            <div class="label">
+                                <span class="label-text-alt">Lower is tried first with the priority selection</span>
+                            </div>
+                        </div>
                     </div>
                         
                     </div>
@@ -396,7 +418,7 @@ class ConfigManager {
                             <span class="label-text font-medium">Unpack RAR</span>
                         </label>
                         <div class="label">
-                            <span class="label-text-alt">Preprocess RAR files</span>
+                            <span class="label-text-alt">Serve stored files inside RARs, including split volumes</span>
                         </div>
                     </div>
                 </div>
@@ -476,6 +498,41 @@ class ConfigManager {
                                 </div>
                             </div>
 
+                            <div class="form-control">
+                                <label class="label" for="debrid[${index}].strm_base_url">
+                                    <span class="label-text font-medium">Strm Base URL</span>
+                                </label>
+                                <input type="url" class="input input-bordered webdav-field" 
+                                       name="debrid[${index}].strm_base_url" id="debrid[${index}].strm_base_url" 
+                                       placeholder="http://decypharr:8282">
+                                <div class="label">
+                                    <span class="label-text-alt">Address players reach Decypharr at, for .strm files</span>
+                                </div>
+                            </div>
+
+                            <div class="form-control">
+                                <label class="label" for="debrid[${index}].stream_token_ttl">
+                                    <span class="label-text font-medium">Stream URL Lifetime</span>
+                                </label>
+                                <input type="text" class="input input-bordered webdav-field" 
+                                       name="debrid[${index}].stream_token_ttl" id="debrid[${index}].stream_token_ttl" 
+                                       placeholder="168h">
+                                <div class="label">
+                                    <span class="label-text-alt">How long signed /stream urls stay valid</span>
+                                </div>
+                            </div>
+
+                            <div class="form-control">
+                                <label class="label cursor-pointer justify-start gap-2">
+                                    <input type="checkbox" class="checkbox webdav-field" 
+                                           name="debrid[${index}].strm_use_stream" id="debrid[${index}].strm_use_stream">
+                                    <span class="label-text font-medium">Signed Strm URLs</span>
+                                </label>
+                                <div class="label">
+                                    <span class="label-text-alt">Point .strm files at signed /stream urls instead of the WebDAV</span>
+                                </div>
+                            </div>
+
                             <div class="form-control">
                                 <label class="label" for="debrid[${index}].rc_url">
                                     <span class="label-text font-medium">Rclone RC URL</span>
@@ -897,6 +954,7 @@ class ConfigManager {
                     </div>
 
                     <input type="hidden" name="arr[${index}].source" value="${data.source || ''}">
+                    <input type="hidden" name="arr[${index}].decypharr_url" value="${data.decypharr_url || ''}">
 
                     <div class="grid grid-cols-1 lg:grid-cols-2 gap-4">
                         <div class="form-control">
@@ -944,11 +1002,27 @@ class ConfigManager {
                                 <option value="alldebrid">AllDebrid</option>
                                 <option value="debridlink">Debrid Link</option>
                                 <option value="torbox">Torbox</option>
+                                <option value="premiumize">Premiumize</option>
                             </select>
                             <div class="label">
                                 <span class="label-text-alt">Which debrid service this Arr should prefer</span>
                             </div>
                         </div>
+
+                        <div class="form-control">
+                            <label class="label" for="arr[${index}].action">
+                                <span class="label-text font-medium">Import Action</span>
+                            </label>
+                            <select class="select select-bordered" name="arr[${index}].action" id="arr[${index}].action">
+                                <option value="" selected>Symlink (default)</option>
+                                <option value="download">Download</option>
+                                <option value="strm">STRM</option>
+                                <option value="none">None</option>
+                            </select>
+                            <div class="label">
+                                <span class="label-text-alt">What's done with this Arr's downloads, whichever client API they come through. qBittorrent's sequential download still downloads</span>
+                            </div>
+                        </div>
                     </div>
 
                     <div class="grid grid-cols-3 gap-4">
@@ -981,6 +1055,197 @@ class ConfigManager {
         `;
     }
 
+    addNotificationConfig(data = {}) {
+        const index = this.notificationCount;
+        this.refs.notificationConfigs.insertAdjacentHTML('beforeend', this.getNotificationTemplate(index));
+
+        if (Object.keys(data).length > 0) {
+            ['name', 'type', 'url', 'token', 'secret', 'template'].forEach(key => {
+                const input = document.querySelector(`[name="notification[${index}].${key}"]`);
+                if (input && data[key] !== undefined) input.value = data[key];
+            });
+            const events = data.events || [];
+            document.querySelectorAll(`[name="notification[${index}].events"]`).forEach(cb => {
+                cb.checked = events.length === 0 || events.includes(cb.value);
+            });
+        }
+
+        document.querySelector(`[data-notification-test="${index}"]`)
+            .addEventListener('click', (e) => this.testNotification(index, e.currentTarget));
+
+        this.notificationCount++;
+    }
+
+    getNotificationTemplate(index) {
+        const events = ['download_complete', 'download_failed', 'repair_pending', 'repair_complete', 'repair_failed', 'repair_cancelled'];
+
+        return `
+            <div class="card bg-base-100 border border-base-300 shadow-sm notification-config" data-index="${index}">
+                <div class="card-body">
+                    <div class="flex justify-between items-start mb-4">
+                        <h3 class="card-title text-lg">
+                            <i class="bi bi-bell mr-2 text-accent"></i>
+                            Notification #${index + 1}
+                        </h3>
+                        <div class="flex gap-2">
+                            <button type="button" class="btn btn-outline btn-sm" data-notification-test="${index}">
+                                <i class="bi bi-send mr-1"></i>Test
+                            </button>
+                            <button type="button" class="btn btn-error btn-sm" onclick="this.closest('.notification-config').remove();">
+                                <i class="bi bi-trash"></i>
+                            </button>
+                        </div>
+                    </div>
+
+                    <div class="grid grid-cols-1 lg:grid-cols-2 gap-4">
+                        <div class="form-control">
+                            <label class="label" for="notification[${index}].name">
+                                <span class="label-text font-medium">Name</span>
+                            </label>
+                            <input type="text" class="input input-bordered" name="notification[${index}].name" id="notification[${index}].name" placeholder="my-discord">
+                        </div>
+
+                        <div class="form-control">
+                            <label class="label" for="notification[${index}].type">
+                                <span class="label-text font-medium">Type</span>
+                            </label>
+                            <select class="select select-bordered" name="notification[${index}].type" id="notification[${index}].type">
+                                <option value="discord" selected>Discord</option>
+                                <option value="webhook">Webhook (JSON)</option>
+                                <option value="apprise">Apprise</option>
+                                <option value="gotify">Gotify</option>
+                                <option value="ntfy">ntfy</option>
+                            </select>
+                        </div>
+
+                        <div class="form-control">
+                            <label class="label" for="notification[${index}].url">
+                                <span class="label-text font-medium">URL</span>
+                            </label>
+                            <input type="url" class="input input-bordered" name="notification[${index}].url" id="notification[${index}].url" placeholder="https://ntfy.sh/decypharr">
+                            <div class="label">
+                                <span class="label-text-alt">Webhook URL, Apprise notify URL, Gotify server or ntfy topic URL</span>
+                            </div>
+                        </div>
+
+                        <div class="form-control">
+                            <label class="label" for="notification[${index}].token">
+                                <span class="label-text font-medium">Token</span>
+                            </label>
+                            <div class="password-toggle-container">
+                                <input type="password" class="input input-bordered input-has-toggle" name="notification[${index}].token" id="notification[${index}].token">
+                                <button type="button" class="password-toggle-btn">
+                                    <i class="bi bi-eye" id="notification[${index}].token_icon"></i>
+                                </button>
+                            </div>
+                            <div class="label">
+                                <span class="label-text-alt">Gotify app token or ntfy access token</span>
+                            </div>
+                        </div>
+
+                        <div class="form-control">
+                            <label class="label" for="notification[${index}].secret">
+                                <span class="label-text font-medium">Signing Secret</span>
+                            </label>
+                            <div class="password-toggle-container">
+                                <input type="password" class="input input-bordered input-has-toggle" name="notification[${index}].secret" id="notification[${index}].secret">
+                                <button type="button" class="password-toggle-btn">
+                                    <i class="bi bi-eye" id="notification[${index}].secret_icon"></i>
+                                </button>
+                            </div>
+                            <div class="label">
+                                <span class="label-text-alt">Webhook only, signs the body with HMAC-SHA256 in X-Decypharr-Signature</span>
+                            </div>
+                        </div>
+
+                        <div class="form-control">
+                            <label class="label" for="notification[${index}].template">
+                                <span class="label-text font-medium">Message Template</span>
+                            </label>
+                            <textarea class="textarea textarea-bordered font-mono text-sm" name="notification[${index}].template" id="notification[${index}].template" placeholder="{{.Title}}: {{.Data.name}}"></textarea>
+                            <div class="label">
+                                <span class="label-text-alt">Optional Go template, leave empty for the default message</span>
+                            </div>
+                        </div>
+                    </div>
+
+                    <div class="form-control">
+                        <label class="label">
+                            <span class="label-text font-medium">Events</span>
+                        </label>
+                        <div class="grid grid-cols-2 lg:grid-cols-3 gap-2">
+                            ${events.map(event => `
+                                <label class="label cursor-pointer justify-start gap-2">
+                                    <input type="checkbox" class="checkbox checkbox-sm" name="notification[${index}].events" value="${event}" checked>
+                                    <span class="label-text text-sm">${event}</span>
+                                </label>
+                            `).join('')}
+                        </div>
+                    </div>
+                </div>
+            </div>
+        `;
+    }
+
+    collectNotification(index) {
+        const urlEl = document.querySelector(`[name="notification[${index}].url"]`);
+        if (!urlEl || !urlEl.closest('.notification-config')) return null;
+
+        const boxes = Array.from(document.querySelectorAll(`[name="notification[${index}].events"]`));
+        const events = boxes.filter(cb => cb.checked).map(cb => cb.value);
+
+        return {
+            name: document.querySelector(`[name="notification[${index}].name"]`).value,
+            type: document.querySelector(`[name="notification[${index}].type"]`).value,
+            url: urlEl.value,
+            token: document.querySelector(`[name="notification[${index}].token"]`).value,
+            secret: document.querySelector(`[name="notification[${index}].secret"]`).value,
+            template: document.querySelector(`[name="notification[${index}].template"]`).value,
+            // All events checked means all events, including ones added later
+            events: events.length === boxes.length ? [] : events
+        };
+    }
+
+    collectNotificationConfigs() {
+        const notifications = [];
+        for (let i = 0; i < this.notificationCount; i++) {
+            const notification = this.collectNotification(i);
+            if (notification && notification.url) {
+                notifications.push(notification);
+            }
+        }
+        return notifications;
+    }
+
+    async testNotification(index, button) {
+        const notification = this.collectNotification(index);
+        if (!notification || !notification.url) {
+            window.decypharrUtils.createToast('Notification URL is required', 'warning');
+            return;
+        }
+
+        window.decypharrUtils.setButtonLoading(button, true);
+        try {
+            const response = await window.decypharrUtils.fetcher('/api/notifications/test', {
+                method: 'POST',
+                headers: {'Content-Type': 'application/json'},
+                body: JSON.stringify(notification)
+            });
+
+            if (!response.ok) {
+                const errorText = await response.text();
+                throw new Error(errorText || 'Failed to send test notification');
+            }
+
+            window.decypharrUtils.createToast('Test notification sent', 'success');
+        } catch (error) {
+            console.error('Error sending test notification:', error);
+            window.decypharrUtils.createToast(error.message, 'error');
+        } finally {
+            window.decypharrUtils.setButtonLoading(button, false);
+        }
+    }
+
     async saveConfiguration(e) {
         e.preventDefault();
 
@@ -1082,6 +1347,7 @@ class ConfigManager {
             max_file_size: document.getElementById('maxFileSize').value,
             remove_stalled_after: document.getElementById('removeStalledAfter').value,
             callback_url: document.getElementById('callbackUrl').value,
+            debrid_selection: document.getElementById('debridSelection').value,
 
             // Debrid configurations
             debrids: this.collectDebridConfigs(),
@@ -1096,7 +1362,10 @@ class ConfigManager {
             repair: this.collectRepairConfig(),
 
             // Rclone configuration
-            rclone: this.collectRcloneConfig()
+            rclone: this.collectRcloneConfig(),
+
+            // Notification targets
+            notifications: this.collectNotificationConfigs()
         };
     }
 
@@ -1113,6 +1382,7 @@ class ConfigManager {
                 folder: document.querySelector(`[name="debrid[${i}].folder"]`).value,
                 rate_limit: document.querySelector(`[name="debrid[${i}].rate_limit"]`).value,
                 minimum_free_slot: parseInt(document.querySelector(`[name="debrid[${i}].minimum_free_slot"]`).value) || 0,
+                priority: parseInt(document.querySelector(`[name="debrid[${i}].priority"]`).value) || 0,
                 rclone_mount_path: document.querySelector(`[name="debrid[${i}].rclone_mount_path"]`).value,
                 proxy: document.querySelector(`[name="debrid[${i}].proxy"]`).value,
                 download_uncached: document.querySelector(`[name="debrid[${i}].download_uncached"]`).checked,
@@ -1137,6 +1407,9 @@ class ConfigManager {
                 debrid.auto_expire_links_after = document.querySelector(`[name="debrid[${i}].auto_expire_links_after"]`).value;
                 debrid.folder_naming = document.querySelector(`[name="debrid[${i}].folder_naming"]`).value;
                 debrid.workers = parseInt(document.querySelector(`[name="debrid[${i}].workers"]`).value);
+                debrid.strm_base_url = document.querySelector(`[name="debrid[${i}].strm_base_url"]`).value;
+                debrid.strm_use_stream = document.querySelector(`[name="debrid[${i}].strm_use_stream"]`).checked;
+                debrid.stream_token_ttl = document.querySelector(`[name="debrid[${i}].stream_token_ttl"]`).value;
                 debrid.rc_url = document.querySelector(`[name="debrid[${i}].rc_url"]`).value;
                 debrid.rc_user = document.querySelector(`[name="debrid[${i}].rc_user"]`).value;
                 debrid.rc_pass = document.querySelector(`[name="debrid[${i}].rc_pass"]`).value;
@@ -1202,7 +1475,9 @@ class ConfigManager {
                 skip_repair: document.querySelector(`[name="arr[${i}].skip_repair"]`).checked,
                 download_uncached: document.querySelector(`[name="arr[${i}].download_uncached"]`).checked,
                 selected_debrid: document.querySelector(`[name="arr[${i}].selected_debrid"]`).value,
-                source: document.querySelector(`[name="arr[${i}].source"]`).value
+                source: document.querySelector(`[name="arr[${i}].source"]`).value,
+                decypharr_url: document.querySelector(`[name="arr[${i}].decypharr_url"]`).value,
+                action: document.querySelector(`[name="arr[${i}].action"]`).value
             };
 
             if (arr.name && arr.host) {
@@ -1217,11 +1492,14 @@ class ConfigManager {
         return {
             enabled: document.querySelector('[name="repair.enabled"]').checked,
             interval: document.querySelector('[name="repair.interval"]').value,
+            deep_verify_interval: document.querySelector('[name="repair.deep_verify_interval"]').value,
             zurg_url: document.querySelector('[name="repair.zurg_url"]').value,
+            webhook_secret: document.querySelector('[name="repair.webhook_secret"]').value,
             strategy: document.querySelector('[name="repair.strategy"]').value,
             workers: parseInt(document.querySelector('[name="repair.workers"]').value) || 1,
             use_webdav: document.querySelector('[name="repair.use_webdav"]').checked,
-            auto_process: document.querySelector('[name="repair.auto_process"]').checked
+            auto_process: document.querySelector('[name="repair.auto_process"]').checked,
+            webhook_on_playback: document.querySelector('[name="repair.webhook_on_playback"]').checked
         };
     }
 
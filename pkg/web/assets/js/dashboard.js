@@ -11,7 +11,8 @@ class TorrentDashboard {
             sortBy: 'added_on',
             itemsPerPage: 20,
             currentPage: 1,
-            selectedTorrentContextMenu: null
+            selectedTorrentContextMenu: null,
+            filesTorrent: null
         };
 
         this.refs = {
@@ -26,7 +27,11 @@ class TorrentDashboard {
             torrentContextMenu: document.getElementById('torrentContextMenu'),
             paginationControls: document.getElementById('paginationControls'),
             paginationInfo: document.getElementById('paginationInfo'),
-            emptyState: document.getElementById('emptyState')
+            emptyState: document.getElementById('emptyState'),
+            torrentFilesModal: document.getElementById('torrentFilesModal'),
+            torrentFilesName: document.getElementById('torrentFilesName'),
+            torrentFilesList: document.getElementById('torrentFilesList'),
+            saveTorrentFilesBtn: document.getElementById('saveTorrentFilesBtn')
         };
 
         this.init();
@@ -57,6 +62,9 @@ class TorrentDashboard {
         // Context menu
         this.bindContextMenu();
 
+        // File selection
+        this.refs.saveTorrentFilesBtn.addEventListener('click', () => this.saveTorrentFiles());
+
         // Torrent selection
         this.refs.torrentsList.addEventListener('change', (e) => {
             if (e.target.classList.contains('torrent-select')) {
@@ -139,6 +147,9 @@ class TorrentDashboard {
                     window.decypharrUtils.createToast('Failed to copy torrent name', 'error');
                 }
             },
+            'select-files': async () => {
+                await this.showTorrentFiles(torrent);
+            },
             'delete': async () => {
                 await this.deleteTorrent(torrent.hash, torrent.category, false);
             }
@@ -504,6 +515,57 @@ class TorrentDashboard {
         }
     }
 
+    async showTorrentFiles(torrent) {
+        try {
+            const endpoint = `/api/torrents/${encodeURIComponent(torrent.category)}/${torrent.hash}/files`;
+            const response = await window.decypharrUtils.fetcher(endpoint);
+            if (!response.ok) throw new Error(await response.text());
+
+            const files = await response.json();
+            this.state.filesTorrent = { ...torrent };
+            this.refs.torrentFilesName.textContent = torrent.name;
+            this.refs.torrentFilesList.innerHTML = files.length === 0 ?
+                '<p class="text-center text-base-content/70 py-4">No files yet, the torrent is still being added</p>' :
+                files.map(file => `
+                    <label class="flex items-center gap-3 p-2 rounded hover:bg-base-200 cursor-pointer">
+                        <input type="checkbox" class="checkbox checkbox-sm" data-index="${file.index}" ${file.skipped ? '' : 'checked'}>
+                        <span class="flex-1 truncate text-sm" title="${this.escapeHtml(file.name)}">${this.escapeHtml(file.name)}</span>
+                        <span class="text-xs text-base-content/70">${window.decypharrUtils.formatBytes(file.size)}</span>
+                    </label>
+                `).join('');
+            this.refs.saveTorrentFilesBtn.disabled = files.length === 0;
+            this.refs.torrentFilesModal.showModal();
+        } catch (error) {
+            console.error('Error loading torrent files:', error);
+            window.decypharrUtils.createToast(`Failed to load files: ${error.message}`, 'error');
+        }
+    }
+
+    async saveTorrentFiles() {
+        const torrent = this.state.filesTorrent;
+        if (!torrent) return;
+
+        const skipped = Array.from(this.refs.torrentFilesList.querySelectorAll('input[type="checkbox"]:not(:checked)'))
+            .map(checkbox => parseInt(checkbox.dataset.index, 10));
+
+        try {
+            const endpoint = `/api/torrents/${encodeURIComponent(torrent.category)}/${torrent.hash}/files`;
+            const response = await window.decypharrUtils.fetcher(endpoint, {
+                method: 'POST',
+                headers: { 'Content-Type': 'application/json' },
+                body: JSON.stringify({ skipped })
+            });
+            if (!response.ok) throw new Error(await response.text());
+
+            window.decypharrUtils.createToast('File selection saved');
+            this.refs.torrentFilesModal.close();
+            this.state.filesTorrent = null;
+        } catch (error) {
+            console.error('Error saving file selection:', error);
+            window.decypharrUtils.createToast(`Failed to save file selection: ${error.message}`, 'error');
+        }
+    }
+
     async deleteSelectedTorrents(removeFromDebrid = false) {
         const count = this.state.selectedTorrents.size;
         if (count === 0) {
@@ -24,6 +24,7 @@ class RepairManager {
             mediaIds: document.getElementById('mediaIds'),
             isAsync: document.getElementById('isAsync'),
             autoProcess: document.getElementById('autoProcess'),
+            deepVerify: document.getElementById('deepVerify'),
             submitBtn: document.getElementById('submitRepair'),
 
             // Jobs table
@@ -44,6 +45,7 @@ class RepairManager {
             modalJobArrs: document.getElementById('modalJobArrs'),
             modalJobMediaIds: document.getElementById('modalJobMediaIds'),
             modalJobAutoProcess: document.getElementById('modalJobAutoProcess'),
+            modalJobDeep: document.getElementById('modalJobDeep'),
             modalJobError: document.getElementById('modalJobError'),
             errorContainer: document.getElementById('errorContainer'),
 
@@ -55,6 +57,11 @@ class RepairManager {
             totalItemsCount: document.getElementById('totalItemsCount'),
             modalFooterStats: document.getElementById('modalFooterStats'),
 
+            // Audit log
+            auditTableBody: document.getElementById('auditTableBody'),
+            auditCount: document.getElementById('auditCount'),
+            noAuditMessage: document.getElementById('noAuditMessage'),
+
             // Filters
             itemSearchInput: document.getElementById('itemSearchInput'),
             arrFilterSelect: document.getElementById('arrFilterSelect'),
@@ -99,6 +106,7 @@ class RepairManager {
         // Table row events (using event delegation)
         this.refs.jobsTableBody.addEventListener('click', (e) => this.handleJobTableClick(e));
         this.refs.brokenItemsTableBody.addEventListener('click', (e) => this.handleItemTableClick(e));
+        this.refs.auditTableBody.addEventListener('click', (e) => this.handleAuditTableClick(e));
     }
 
     async loadArrInstances() {
@@ -144,7 +152,8 @@ class RepairManager {
                     arr: arr,
                     mediaIds: mediaIds.length > 0 ? mediaIds : null,
                     async: this.refs.isAsync.checked,
-                    autoProcess: this.refs.autoProcess.checked
+                    autoProcess: this.refs.autoProcess.checked,
+                    deep: this.refs.deepVerify.checked
                 })
             });
 
@@ -423,6 +432,100 @@ class RepairManager {
         this.state.currentJob = job;
         this.populateJobModal(job);
         this.refs.jobDetailsModal.showModal();
+        await this.loadJobAudit(jobId);
+    }
+
+    async loadJobAudit(jobId) {
+        this.refs.auditTableBody.innerHTML = '';
+        try {
+            const response = await window.decypharrUtils.fetcher(`/api/repair/jobs/${jobId}/audit`);
+            if (!response.ok) throw new Error('Failed to load audit log');
+            this.renderAuditTable(await response.json());
+        } catch (error) {
+            console.error('Error loading audit log:', error);
+            this.renderAuditTable([]);
+        }
+    }
+
+    renderAuditTable(entries) {
+        this.refs.auditTableBody.innerHTML = '';
+        this.refs.auditCount.textContent = entries.length;
+        this.refs.noAuditMessage.classList.toggle('hidden', entries.length > 0);
+
+        const utils = window.decypharrUtils;
+        const actionColor = {
+            'reported': 'badge-ghost',
+            'deleted': 'badge-warning',
+            'searched': 'badge-success',
+            'delete_failed': 'badge-error'
+        };
+
+        entries.forEach(entry => {
+            const row = document.createElement('tr');
+            const processed = entry.processed_at && !entry.processed_at.startsWith('0001') ?
+                new Date(entry.processed_at).toLocaleString() : '-';
+            const undone = entry.undone_at && !entry.undone_at.startsWith('0001');
+            let undoCell = '';
+            if (undone) {
+                undoCell = '<div class="badge badge-info badge-xs">undone</div>';
+            } else if (entry.action !== 'reported') {
+                undoCell = `<button class="btn btn-ghost btn-xs" data-action="undo" data-entry-id="${entry.id}" title="${utils.escapeHtml(entry.undo_error || 'Re-insert the torrent and re-import the file')}">
+                    <i class="bi bi-arrow-counterclockwise"></i>
+                </button>`;
+            }
+
+            row.innerHTML = `
+                <td>
+                    <div class="text-sm max-w-xs truncate" title="${utils.escapeHtml(entry.file.path)}">
+                        ${utils.escapeHtml(entry.file.path)}
+                    </div>
+                </td>
+                <td>
+                    <div class="badge badge-outline badge-xs">${utils.escapeHtml(entry.detection)}</div>
+                    <div class="text-xs text-base-content/70 max-w-xs truncate" title="${utils.escapeHtml(entry.reason)}">${utils.escapeHtml(entry.reason)}</div>
+                </td>
+                <td>
+                    <div class="badge ${actionColor[entry.action] || 'badge-ghost'} badge-xs">${utils.escapeHtml(entry.action)}</div>
+                </td>
+                <td>
+                    <div class="text-xs max-w-xs truncate" title="${utils.escapeHtml(entry.arr_response)}">${utils.escapeHtml(entry.arr_response || '-')}</div>
+                </td>
+                <td><span class="text-xs">${processed}</span></td>
+                <td>${undoCell}</td>
+            `;
+            this.refs.auditTableBody.appendChild(row);
+        });
+    }
+
+    handleAuditTableClick(e) {
+        const button = e.target.closest('button[data-action="undo"]');
+        if (!button) return;
+        this.undoAuditEntry(button.dataset.entryId);
+    }
+
+    async undoAuditEntry(entryId) {
+        if (!confirm('Re-insert the torrent and re-import this file?')) return;
+
+        try {
+            const response = await window.decypharrUtils.fetcher('/api/repair/audit/undo', {
+                method: 'POST',
+                headers: {'Content-Type': 'application/json'},
+                body: JSON.stringify({ids: [entryId]})
+            });
+
+            if (!response.ok) {
+                const errorText = await response.text();
+                throw new Error(errorText || 'Failed to undo');
+            }
+
+            window.decypharrUtils.createToast('Repair undone', 'success');
+        } catch (error) {
+            console.error('Error undoing repair:', error);
+            window.decypharrUtils.createToast(`Error undoing repair: ${error.message}`, 'error');
+        }
+        if (this.state.currentJob) {
+            await this.loadJobAudit(this.state.currentJob.id);
+        }
     }
 
     populateJobModal(job) {
@@ -432,6 +535,7 @@ class RepairManager {
         this.refs.modalJobMediaIds.textContent = job.media_ids && job.media_ids.length > 0 ?
             job.media_ids.join(', ') : 'All media';
         this.refs.modalJobAutoProcess.textContent = job.auto_process ? 'Yes' : 'No';
+        this.refs.modalJobDeep.textContent = job.deep ? 'Yes' : 'No';
 
         // Dates
         this.refs.modalJobStarted.textContent = new Date(job.created_at).toLocaleString();
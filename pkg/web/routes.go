@@ -38,6 +38,9 @@ func (wb *Web) Routes() http.Handler {
 		r.Route("/api", func(r chi.Router) {
 			// Arr management
 			r.Get("/arrs", wb.handleGetArrs)
+			r.Post("/arrs/auto-configure", wb.handleAutoConfigureArr)
+			r.Get("/arrs/download-clients", wb.handleGetDownloadClients)
+			r.Post("/arrs/download-clients/sync", wb.handleSyncDownloadClients)
 			r.Post("/add", wb.handleAddContent)
 
 			// Repair operations
@@ -46,16 +49,31 @@ func (wb *Web) Routes() http.Handler {
 			r.Post("/repair/jobs/{id}/process", wb.handleProcessRepairJob)
 			r.Post("/repair/jobs/{id}/stop", wb.handleStopRepairJob)
 			r.Delete("/repair/jobs", wb.handleDeleteRepairJob)
+			r.Get("/repair/jobs/{id}/audit", wb.handleGetRepairAudit)
+			r.Get("/repair/audit", wb.handleGetRepairAudit)
+			r.Post("/repair/audit/undo", wb.handleUndoRepair)
 
 			// Torrent management
+			r.Get("/cached", wb.handleCheckCached)
+			r.Post("/cached", wb.handleCheckCached)
 			r.Get("/torrents", wb.handleGetTorrents)
 			r.Delete("/torrents/{category}/{hash}", wb.handleDeleteTorrent)
+			r.Get("/torrents/{category}/{hash}/files", wb.handleGetTorrentFiles)
+			r.Post("/torrents/{category}/{hash}/files", wb.handleSetTorrentFiles)
 			r.Delete("/torrents", wb.handleDeleteTorrents) // Fixed trailing slash
 
+			// Import queue
+			r.Get("/queue", wb.handleGetQueue)
+			r.Post("/queue/{id}/move", wb.handleMoveQueued)
+			r.Post("/queue/{id}/retry", wb.handleRetryQueued)
+			r.Delete("/queue/{id}", wb.handleCancelQueued)
+
 			// Config/Auth
 			r.Get("/config", wb.handleGetConfig)
 			r.Post("/config", wb.handleUpdateConfig)
 			r.Post("/refresh-token", wb.handleRefreshAPIToken)
+			r.Post("/notifications/test", wb.handleTestNotification)
+			r.Post("/stream/sign", wb.handleSignStream)
 			r.Post("/update-auth", wb.handleUpdateAuth)
 		})
 	})
@@ -45,6 +45,7 @@ type RepairRequest struct {
 	MediaIds    []string `json:"mediaIds"`
 	Async       bool     `json:"async"`
 	AutoProcess bool     `json:"autoProcess"`
+	Deep        bool     `json:"deep"` // Also sample the files and check their container headers
 }
 
 //go:embed templates/*
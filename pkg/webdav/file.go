@@ -7,6 +7,7 @@ import (
 	"os"
 	"time"
 
+	"github.com/sirrobot01/decypharr/internal/metrics"
 	"github.com/sirrobot01/decypharr/pkg/debrid/store"
 	"github.com/sirrobot01/decypharr/pkg/debrid/types"
 )
@@ -38,6 +39,7 @@ type File struct {
 	isDir        bool
 	fileId       string
 	isRar        bool
+	parts        []types.FilePart // Set when the file is split across the volumes of a RAR
 	metadataOnly bool
 	content      []byte
 	children     []os.FileInfo // For directories
@@ -120,6 +122,9 @@ func (f *File) StreamResponse(w http.ResponseWriter, r *http.Request) error {
 	if f.content != nil {
 		return f.servePreloadedContent(w, r)
 	}
+	if len(f.parts) > 0 || f.cache.ChunkCacheEnabled() {
+		return f.streamFile(w, r)
+	}
 	_logger := f.cache.Logger()
 
 	start, end := f.getRange(r)
@@ -135,6 +140,36 @@ func (f *File) StreamResponse(w http.ResponseWriter, r *http.Request) error {
 	return f.handleSuccessfulResponse(w, resp, start, end)
 }
 
+// streamFile serves the requested range through the cache's StreamFile, for files split across several debrid files
+// and when chunks are cached on disk
+func (f *File) streamFile(w http.ResponseWriter, r *http.Request) error {
+	start, end := int64(0), f.size-1
+	statusCode := http.StatusOK
+	if rangeHeader := r.Header.Get("Range"); rangeHeader != "" {
+		ranges, err := parseRange(rangeHeader, f.size)
+		if err != nil || len(ranges) != 1 {
+			w.Header().Set("Content-Range", fmt.Sprintf("bytes */%d", f.size))
+			return &streamError{Err: fmt.Errorf("invalid range"), StatusCode: http.StatusRequestedRangeNotSatisfiable}
+		}
+		start, end = ranges[0].start, ranges[0].end
+		statusCode = http.StatusPartialContent
+		w.Header().Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", start, end, f.size))
+	}
+	w.Header().Set("Content-Length", fmt.Sprintf("%d", end-start+1))
+
+	file := types.File{Id: f.fileId, Name: f.name, Size: f.size, Link: f.link, IsRar: f.isRar, Parts: f.parts}
+	body, err := f.cache.StreamFile(r.Context(), f.torrentName, file, start, end)
+	if err != nil {
+		_logger := f.cache.Logger()
+		_logger.Error().Err(err).Str("file", f.name).Msg("Failed to stream file")
+		return &streamError{Err: err, StatusCode: http.StatusRequestedRangeNotSatisfiable}
+	}
+	defer func(Body io.ReadCloser) {
+		_ = Body.Close()
+	}(body)
+	return f.streamBuffer(w, body, statusCode)
+}
+
 func (f *File) handleSuccessfulResponse(w http.ResponseWriter, resp *http.Response, start, end int64) error {
 	statusCode := http.StatusOK
 	if start > 0 || end > 0 {
@@ -164,12 +199,16 @@ func (f *File) streamBuffer(w http.ResponseWriter, src io.Reader, statusCode int
 		return fmt.Errorf("response does not support flushing")
 	}
 
+	debridName := f.cache.GetConfig().Name
+
 	smallBuf := make([]byte, 64*1024) // 64 KB
 	if n, err := src.Read(smallBuf); n > 0 {
 		// Write status code just before first successful write
 		w.WriteHeader(statusCode)
 
-		if _, werr := w.Write(smallBuf[:n]); werr != nil {
+		written, werr := w.Write(smallBuf[:n])
+		metrics.AddWebDavBytes(debridName, written)
+		if werr != nil {
 			if isClientDisconnection(werr) {
 				return &streamError{Err: werr, StatusCode: 0, IsClientDisconnection: true}
 			}
@@ -185,7 +224,9 @@ func (f *File) streamBuffer(w http.ResponseWriter, src io.Reader, statusCode int
 	for {
 		n, readErr := src.Read(buf)
 		if n > 0 {
-			if _, writeErr := w.Write(buf[:n]); writeErr != nil {
+			written, writeErr := w.Write(buf[:n])
+			metrics.AddWebDavBytes(debridName, written)
+			if writeErr != nil {
 				if isClientDisconnection(writeErr) {
 					return &streamError{Err: writeErr, StatusCode: 0, IsClientDisconnection: true}
 				}
@@ -228,25 +228,10 @@ func (h *Handler) OpenFile(ctx context.Context, name string, flag int, perm os.F
 	rel := strings.TrimPrefix(name, rootDir+"/")
 	parts := strings.Split(rel, "/")
 	if len(parts) >= 2 {
-		if utils.Contains(h.getParentItems(), parts[0]) {
-			torrentName := parts[1]
-			cached := h.cache.GetTorrentByName(torrentName)
-			if cached != nil && len(parts) >= 3 {
-				filename := filepath.Clean(path.Join(parts[2:]...))
-				if file, ok := cached.GetFile(filename); ok && !file.Deleted {
-					return &File{
-						cache:        h.cache,
-						torrentName:  torrentName,
-						fileId:       file.Id,
-						isDir:        false,
-						name:         file.Name,
-						size:         file.Size,
-						link:         file.Link,
-						metadataOnly: metadataOnly,
-						isRar:        file.IsRar,
-						modTime:      cached.AddedOn,
-					}, nil
-				}
+		if utils.Contains(h.getParentItems(), parts[0]) && len(parts) >= 3 {
+			filename := filepath.Clean(path.Join(parts[2:]...))
+			if file := h.getFile(parts[1], filename, metadataOnly); file != nil {
+				return file, nil
 			}
 		}
 	}
@@ -255,6 +240,31 @@ func (h *Handler) OpenFile(ctx context.Context, name string, flag int, perm os.F
 	return nil, os.ErrNotExist
 }
 
+// getFile returns a file of a torrent folder, or nil if there's no such file
+func (h *Handler) getFile(torrentName, filename string, metadataOnly bool) *File {
+	cached := h.cache.GetTorrentByName(torrentName)
+	if cached == nil {
+		return nil
+	}
+	file, ok := cached.GetFile(filename)
+	if !ok || file.Deleted {
+		return nil
+	}
+	return &File{
+		cache:        h.cache,
+		torrentName:  torrentName,
+		fileId:       file.Id,
+		isDir:        false,
+		name:         file.Name,
+		size:         file.Size,
+		link:         file.Link,
+		metadataOnly: metadataOnly,
+		isRar:        file.IsRar,
+		parts:        file.Parts,
+		modTime:      cached.AddedOn,
+	}
+}
+
 // Stat implements webdav.FileSystem
 func (h *Handler) Stat(ctx context.Context, name string) (os.FileInfo, error) {
 	f, err := h.OpenFile(ctx, name, os.O_RDONLY, 0)
@@ -460,32 +470,36 @@ func (h *Handler) handleGet(w http.ResponseWriter, r *http.Request) {
 		}
 
 		if err := file.StreamResponse(w, r); err != nil {
-			var streamErr *streamError
-			if errors.As(err, &streamErr) {
-				// Handle client disconnections silently (just debug log)
-				if errors.Is(streamErr.Err, context.Canceled) || errors.Is(streamErr.Err, context.DeadlineExceeded) || streamErr.IsClientDisconnection {
-					return
-				}
-				if streamErr.StatusCode > 0 {
-					h.logger.Trace().Err(err).Msgf("Error streaming %s", fi.Name())
-					http.Error(w, streamErr.Error(), streamErr.StatusCode)
-					return
-				} else {
-					// We've already written a status code, just log the error
-					h.logger.Error().Err(streamErr.Err).Msg("Streaming error")
-					return
-				}
-			} else {
-				// Generic error
-				h.logger.Error().Err(err).Msgf("Error streaming file %s", fi.Name())
-				http.Error(w, "Internal Server Error", http.StatusInternalServerError)
-				return
-			}
+			h.handleStreamError(w, fi.Name(), err)
 		}
 		return
 	}
 }
 
+func (h *Handler) handleStreamError(w http.ResponseWriter, name string, err error) {
+	var streamErr *streamError
+	if errors.As(err, &streamErr) {
+		// Handle client disconnections silently (just debug log)
+		if errors.Is(streamErr.Err, context.Canceled) || errors.Is(streamErr.Err, context.DeadlineExceeded) || streamErr.IsClientDisconnection {
+			return
+		}
+		if streamErr.StatusCode > 0 {
+			h.logger.Trace().Err(err).Msgf("Error streaming %s", name)
+			http.Error(w, streamErr.Error(), streamErr.StatusCode)
+			return
+		} else {
+			// We've already written a status code, just log the error
+			h.logger.Error().Err(streamErr.Err).Msg("Streaming error")
+			return
+		}
+	} else {
+		// Generic error
+		h.logger.Error().Err(err).Msgf("Error streaming file %s", name)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+}
+
 func (h *Handler) handleHead(w http.ResponseWriter, r *http.Request) {
 	f, err := h.OpenFile(r.Context(), r.URL.Path, os.O_RDONLY, 0)
 	if err != nil {
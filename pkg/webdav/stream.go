@@ -0,0 +1,102 @@
+package webdav
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/sirrobot01/decypharr/pkg/debrid/store"
+)
+
+// StreamRoutes serves single files over plain http at /stream/{debrid}/{torrent}/{file}, for players that don't speak webdav.
+// Urls are signed per file and expire, see store.Cache.StreamURL
+func (wd *WebDav) StreamRoutes() http.Handler {
+	r := chi.NewRouter()
+	r.Get("/{debrid}/{torrent}/*", wd.handleStream)
+	r.Head("/{debrid}/{torrent}/*", wd.handleStream)
+	return r
+}
+
+func (wd *WebDav) getHandler(name string) *Handler {
+	for _, h := range wd.Handlers {
+		if h.Name == name {
+			return h
+		}
+	}
+	return nil
+}
+
+func pathParam(r *http.Request, key string) string {
+	v := chi.URLParam(r, key)
+	if unescaped, err := url.PathUnescape(v); err == nil {
+		return unescaped
+	}
+	return v
+}
+
+func (wd *WebDav) handleStream(w http.ResponseWriter, r *http.Request) {
+	debridName := pathParam(r, "debrid")
+	torrentName := pathParam(r, "torrent")
+	fileName := path.Clean(pathParam(r, "*"))
+
+	q := r.URL.Query()
+	if err := store.VerifyStream(debridName, torrentName, fileName, q.Get("exp"), q.Get("sig")); err != nil {
+		status := http.StatusForbidden
+		if errors.Is(err, store.ErrStreamExpired) {
+			status = http.StatusGone
+		}
+		http.Error(w, err.Error(), status)
+		return
+	}
+
+	h := wd.getHandler(debridName)
+	if h == nil {
+		http.NotFound(w, r)
+		return
+	}
+	select {
+	case <-h.cache.IsReady():
+	default:
+		w.Header().Set("Retry-After", "5")
+		http.Error(w, "WebDAV service is initializing, please try again shortly", http.StatusServiceUnavailable)
+		return
+	}
+
+	file := h.getFile(torrentName, fileName, false)
+	if file == nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	w.Header().Set("Content-Type", getContentType(file.name))
+	w.Header().Set("Accept-Ranges", "bytes")
+	w.Header().Set("Last-Modified", file.modTime.UTC().Format(http.TimeFormat))
+	w.Header().Set("ETag", fmt.Sprintf("\"%x-%x\"", file.modTime.Unix(), file.size))
+
+	if r.Method == http.MethodHead {
+		w.Header().Set("Content-Length", fmt.Sprintf("%d", file.size))
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	// Redirect to the debrid link if asked, files inside a rar need the proxy for their byte range
+	if q.Get("mode") == "redirect" && !file.isRar {
+		if byteRange, _ := file.getDownloadByteRange(); byteRange == nil {
+			link, err := file.getDownloadLink()
+			if err != nil || link.Empty() {
+				http.Error(w, "Could not fetch download link", http.StatusPreconditionFailed)
+				return
+			}
+			w.Header().Set("Cache-Control", "no-store")
+			http.Redirect(w, r, link.DownloadLink, http.StatusFound)
+			return
+		}
+	}
+
+	if err := file.StreamResponse(w, r); err != nil {
+		h.handleStreamError(w, file.name, err)
+	}
+}
@@ -3,6 +3,7 @@ package wire
 import (
 	"crypto/md5"
 	"fmt"
+	"io"
 	"net/http"
 	"os"
 	"path/filepath"
@@ -301,6 +302,40 @@ Loop:
 	return resp.Err()
 }
 
+// grabParts downloads a file split across several files, e.g the volumes of a split RAR, one part after the other
+func grabParts(client *grab.Client, parts []types.FilePart, filename string, progressCallback func(int64, int64)) error {
+	out, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	for i, part := range parts {
+		partFile := fmt.Sprintf("%s.part%d", filename, i)
+		byteRange := part.ByteRange
+		if err := grabber(client, part.DownloadLink.DownloadLink, partFile, &byteRange, progressCallback); err != nil {
+			_ = os.Remove(partFile)
+			return fmt.Errorf("failed to download part %d: %w", i+1, err)
+		}
+		err := appendFile(out, partFile)
+		_ = os.Remove(partFile)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func appendFile(out *os.File, path string) error {
+	in, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	_, err = io.Copy(out, in)
+	return err
+}
+
 func (s *Store) processDownload(torrent *Torrent, debridTorrent *types.Torrent) (string, error) {
 	s.logger.Info().Msgf("Downloading %d files...", len(debridTorrent.Files))
 	torrentPath := filepath.Join(torrent.SavePath, utils.RemoveExtension(debridTorrent.OriginalFilename))
@@ -362,13 +397,18 @@ func (s *Store) downloadFiles(torrent *Torrent, debridTorrent *types.Torrent, pa
 			defer func() { <-s.downloadSemaphore }()
 			filename := file.Name
 
-			err := grabber(
-				client,
-				file.DownloadLink.DownloadLink,
-				filepath.Join(parent, filename),
-				file.ByteRange,
-				progressCallback,
-			)
+			var err error
+			if len(file.Parts) > 0 {
+				err = grabParts(client, file.Parts, filepath.Join(parent, filename), progressCallback)
+			} else {
+				err = grabber(
+					client,
+					file.DownloadLink.DownloadLink,
+					filepath.Join(parent, filename),
+					file.ByteRange,
+					progressCallback,
+				)
+			}
 
 			if err != nil {
 				s.logger.Error().Msgf("Failed to download %s: %v", filename, err)
@@ -512,9 +552,10 @@ func (s *Store) processMultiSeasonSymlinks(torrent *Torrent, debridTorrent *type
 		for index, file := range seasonInfo.Files {
 			seasonFiles[file.Name] = file
 			torrentFiles = append(torrentFiles, &File{
-				Index: index,
-				Name:  file.Path,
-				Size:  file.Size,
+				Index:    index,
+				Name:     file.Path,
+				Size:     file.Size,
+				Priority: 1,
 			})
 			size += file.Size
 		}
@@ -557,6 +598,7 @@ func (s *Store) processMultiSeasonSymlinks(torrent *Torrent, debridTorrent *type
 		seasonTorrent.TorrentPath = torrentSymlinkPath
 		seasonTorrent.ContentPath = torrentSymlinkPath
 		seasonTorrent.State = "pausedUP"
+		seasonTorrent.markCompleted()
 		// Add the season torrent to storage
 		s.torrents.AddOrUpdate(seasonTorrent)
 
@@ -612,6 +654,7 @@ func (s *Store) processMultiSeasonDownloads(torrent *Torrent, debridTorrent *typ
 		seasonTorrent.TorrentPath = seasonDownloadPath
 		torrent.ContentPath = seasonDownloadPath
 		seasonTorrent.State = "pausedUP"
+		seasonTorrent.markCompleted()
 
 		// Add the season torrent to storage
 		s.torrents.AddOrUpdate(seasonTorrent)
@@ -0,0 +1,199 @@
+package wire
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/sirrobot01/decypharr/pkg/debrid/common"
+)
+
+// Files with this priority are skipped, like qBittorrent's "Do not download"
+const priorityDoNotDownload = 0
+
+// SkippedFiles returns the debrid paths of the files set to "Do not download"
+func (t *Torrent) SkippedFiles() []string {
+	skipped := make([]string, 0)
+	for _, f := range t.Files {
+		if f.Priority == priorityDoNotDownload {
+			skipped = append(skipped, t.debridPath(f.Name))
+		}
+	}
+	return skipped
+}
+
+// selectedFiles returns the debrid paths of the files that aren't skipped
+func (t *Torrent) selectedFiles() []string {
+	selected := make([]string, 0, len(t.Files))
+	for _, f := range t.Files {
+		if f.Priority != priorityDoNotDownload {
+			selected = append(selected, t.debridPath(f.Name))
+		}
+	}
+	return selected
+}
+
+// normalizePriorities sets the files of torrents saved before there were priorities to normal, they have none at all
+func (t *Torrent) normalizePriorities() {
+	if slices.ContainsFunc(t.Files, func(f *File) bool { return f.Priority != priorityDoNotDownload }) {
+		return
+	}
+	for _, f := range t.Files {
+		f.Priority = 1
+	}
+}
+
+// debridPath returns the path on the debrid of the file with the name, see FileRenames
+func (t *Torrent) debridPath(name string) string {
+	for path, renamed := range t.FileRenames {
+		if renamed == name {
+			return path
+		}
+	}
+	return name
+}
+
+// RenameFile renames a file of the torrent, on disk too once it's been symlinked/downloaded.
+// The name is kept across refreshes from the debrid, see qBittorrent's /torrents/renameFile
+func (s *Store) RenameFile(t *Torrent, oldPath, newPath string) error {
+	if !filepath.IsLocal(newPath) {
+		return fmt.Errorf("invalid path: %s", newPath)
+	}
+	t.Lock()
+	defer t.Unlock()
+	var file *File
+	for _, f := range t.Files {
+		if f.Name == oldPath {
+			file = f
+		} else if f.Name == newPath {
+			return fmt.Errorf("file already exists: %s", newPath)
+		}
+	}
+	if file == nil {
+		return fmt.Errorf("file not found: %s", oldPath)
+	}
+	if t.ContentPath != "" {
+		oldFile := filepath.Join(t.ContentPath, oldPath)
+		if _, err := os.Lstat(oldFile); err == nil {
+			newFile := filepath.Join(t.ContentPath, newPath)
+			if err := os.MkdirAll(filepath.Dir(newFile), os.ModePerm); err != nil {
+				return err
+			}
+			if err := os.Rename(oldFile, newFile); err != nil {
+				return err
+			}
+		}
+	}
+	path := t.debridPath(oldPath)
+	if t.FileRenames == nil {
+		t.FileRenames = make(map[string]string)
+	}
+	if newPath == path {
+		delete(t.FileRenames, path)
+	} else {
+		t.FileRenames[path] = newPath
+	}
+	file.Name = newPath
+	s.torrents.Update(t)
+	return nil
+}
+
+// SetFilePriority sets the priority of the files with the indexes, see qBittorrent's /torrents/filePrio.
+// Files set to priority 0 are skipped
+func (s *Store) SetFilePriority(t *Torrent, ids []int, priority int) error {
+	t.Lock()
+	files := make(map[int]*File, len(t.Files))
+	for _, f := range t.Files {
+		files[f.Index] = f
+	}
+	for _, id := range ids {
+		if _, ok := files[id]; !ok {
+			t.Unlock()
+			return fmt.Errorf("file id %d not found", id)
+		}
+	}
+	if priority == priorityDoNotDownload && !slices.ContainsFunc(t.Files, func(f *File) bool {
+		return !slices.Contains(ids, f.Index) && f.Priority != priorityDoNotDownload
+	}) {
+		t.Unlock()
+		return fmt.Errorf("at least one file must be selected")
+	}
+	for _, id := range ids {
+		files[id].Priority = priority
+	}
+	t.Unlock()
+	return s.applySkippedFiles(t)
+}
+
+// SetSkippedFiles skips the files with the indexes and selects all the others
+func (s *Store) SetSkippedFiles(t *Torrent, ids []int) error {
+	t.Lock()
+	for _, id := range ids {
+		if !slices.ContainsFunc(t.Files, func(f *File) bool { return f.Index == id }) {
+			t.Unlock()
+			return fmt.Errorf("file id %d not found", id)
+		}
+	}
+	if len(t.Files) > 0 && !slices.ContainsFunc(t.Files, func(f *File) bool { return !slices.Contains(ids, f.Index) }) {
+		t.Unlock()
+		return fmt.Errorf("at least one file must be selected")
+	}
+	for _, f := range t.Files {
+		if slices.Contains(ids, f.Index) {
+			f.Priority = priorityDoNotDownload
+		} else if f.Priority == priorityDoNotDownload {
+			f.Priority = 1
+		}
+	}
+	t.Unlock()
+	return s.applySkippedFiles(t)
+}
+
+// applySkippedFiles selects the files on the debrid if it can, hides the skipped files from the WebDAV and removes
+// their symlinks or downloads. Torrents still downloading on the debrid skip them once they're processed
+func (s *Store) applySkippedFiles(t *Torrent) error {
+	s.torrents.Update(t)
+	if t.Debrid == "" || t.DebridID == "" {
+		return nil
+	}
+	deb := s.debrid.Debrid(t.Debrid)
+	if deb == nil {
+		return nil
+	}
+	if selector, ok := deb.Client().(common.FileSelector); ok {
+		if err := selector.SelectFiles(t.DebridID, t.selectedFiles()); err != nil {
+			s.logger.Warn().Err(err).Str("torrent", t.Name).Msgf("%s kept its selection, the skipped files are only hidden", t.Debrid)
+		}
+	}
+
+	paths := t.SkippedFiles()
+	names := make([]string, 0, len(paths))
+	for _, p := range paths {
+		names = append(names, filepath.Base(p))
+	}
+	if deb.Cache() != nil {
+		skipped, err := deb.Cache().SkipFiles(t.DebridID, paths)
+		if err != nil {
+			s.logger.Debug().Err(err).Str("torrent", t.Name).Msg("Torrent not in the webdav yet, skipping files on import")
+		} else {
+			names = skipped
+		}
+	}
+
+	if !t.IsReady() || t.ContentPath == "" {
+		return nil
+	}
+	// Renamed files are on disk under their new name
+	for _, f := range t.Files {
+		if f.Priority == priorityDoNotDownload && t.debridPath(f.Name) != f.Name {
+			names = append(names, f.Name)
+		}
+	}
+	for _, name := range names {
+		if err := os.Remove(filepath.Join(t.ContentPath, name)); err != nil && !os.IsNotExist(err) {
+			s.logger.Warn().Err(err).Str("torrent", t.Name).Msgf("Failed to remove skipped file %s", name)
+		}
+	}
+	return nil
+}
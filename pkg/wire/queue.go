@@ -3,9 +3,11 @@ package wire
 import (
 	"context"
 	"fmt"
+	"strings"
+	"time"
+
 	"github.com/go-co-op/gocron/v2"
 	"github.com/sirrobot01/decypharr/internal/utils"
-	"time"
 )
 
 func (s *Store) addToQueue(importReq *ImportRequest) error {
@@ -67,6 +69,32 @@ func (s *Store) StartQueueWorkers(ctx context.Context) error {
 		}
 	}
 
+	// Keep the download client of the arrs Decypharr registered itself in from drifting
+	if jd, err := utils.ConvertToJobDef("30m"); err != nil {
+		s.logger.Error().Err(err).Msg("Failed to convert download clients sync interval to job definition")
+	} else {
+		if _, err := s.scheduler.NewJob(jd, gocron.NewTask(func() {
+			s.arr.SyncDownloadClients()
+		}), gocron.WithContext(ctx), gocron.WithStartAt(gocron.WithStartImmediately())); err != nil {
+			s.logger.Error().Err(err).Msg("Failed to create download clients sync job")
+		} else {
+			s.logger.Trace().Msgf("Download clients sync job scheduled for every %s", "30m")
+		}
+	}
+
+	// Reconcile the arrs' queues with the torrents
+	if jd, err := utils.ConvertToJobDef("15m"); err != nil {
+		s.logger.Error().Err(err).Msg("Failed to convert queue reconciliation interval to job definition")
+	} else {
+		if _, err := s.scheduler.NewJob(jd, gocron.NewTask(func() {
+			s.reconcileArrQueues(ctx)
+		}), gocron.WithContext(ctx)); err != nil {
+			s.logger.Error().Err(err).Msg("Failed to create queue reconciliation job")
+		} else {
+			s.logger.Trace().Msgf("Queue reconciliation job scheduled for every %s", "15m")
+		}
+	}
+
 	// Start the scheduler
 	s.scheduler.Start()
 	s.logger.Debug().Msg("Store worker started")
@@ -122,7 +150,41 @@ func (s *Store) processFromQueue(ctx context.Context) error {
 	if importReq == nil {
 		return nil
 	}
-	return s.AddTorrent(ctx, importReq)
+	return s.AddTorrent(ctx, s.refreshQueued(importReq))
+}
+
+// refreshQueued points a request restored from the db at the arr as it's configured now
+func (s *Store) refreshQueued(importReq *ImportRequest) *ImportRequest {
+	if a := s.arr.Get(importReq.Arr.Name); a != nil {
+		importReq.Arr = a
+	}
+	return importReq
+}
+
+// RetryQueued submits a queued request right away. It's queued again at the end if the debrid is still full,
+// or put back where it was if it fails
+func (s *Store) RetryQueued(ctx context.Context, requestID string) error {
+	importReq, position := s.importsQueue.Remove(requestID)
+	if importReq == nil {
+		return fmt.Errorf("import request %s not found", requestID)
+	}
+	if err := s.AddTorrent(ctx, s.refreshQueued(importReq)); err != nil {
+		if insertErr := s.importsQueue.Insert(importReq, position); insertErr != nil {
+			s.logger.Error().Err(insertErr).Msgf("Failed to queue %s again", importReq.Magnet.Name)
+		}
+		return err
+	}
+	return nil
+}
+
+// CancelQueued drops a queued request, and its torrent
+func (s *Store) CancelQueued(requestID string) error {
+	importReq, _ := s.importsQueue.Remove(requestID)
+	if importReq == nil {
+		return fmt.Errorf("import request %s not found", requestID)
+	}
+	s.torrents.Delete(strings.ToLower(importReq.Magnet.InfoHash), importReq.Arr.Name, false)
+	return nil
 }
 
 func (s *Store) removeStalledTorrents(ctx context.Context) error {
@@ -0,0 +1,206 @@
+package wire
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/sirrobot01/decypharr/pkg/arr"
+)
+
+// How long after a torrent completed the reconciler leaves it alone, the arrs and the debrid caches need time to catch up
+const reconcileGracePeriod = time.Hour
+
+// Tracked download states of the items the arr downloaded but didn't import on its own
+var stuckImportStates = []string{"importPending", "importBlocked", "importFailed"}
+
+// reconcileArrQueues brings the queues of the arrs with Cleanup Queue on back in line with Decypharr's torrents
+func (s *Store) reconcileArrQueues(ctx context.Context) {
+	for _, a := range s.arr.GetAll() {
+		select {
+		case <-ctx.Done():
+			return
+		default:
+		}
+		if !a.Cleanup || a.Host == "" || a.Token == "" {
+			continue
+		}
+		if err := s.reconcileArrQueue(a); err != nil {
+			s.logger.Error().Err(err).Msgf("Failed to reconcile the queue of %s", a.Name)
+		}
+	}
+}
+
+// reconcileArrQueue compares the arr's queue with Decypharr's torrents and the debrid caches:
+//   - queue items whose torrent is gone from Decypharr or the debrid are removed from the queue
+//   - completed torrents the arr is stuck importing are imported manually, once
+//   - completed torrents the arr grabbed, never imported and forgot are removed from Decypharr, and from the debrid
+//     when none of Decypharr's other torrents use the same debrid torrent
+func (s *Store) reconcileArrQueue(a *arr.Arr) error {
+	clients, err := a.DecypharrClients()
+	if err != nil {
+		return fmt.Errorf("failed to get download clients: %w", err)
+	}
+	if len(clients) == 0 {
+		// The arr doesn't download through Decypharr
+		return nil
+	}
+	queue, err := a.FetchQueue()
+	if err != nil {
+		return fmt.Errorf("failed to get queue: %w", err)
+	}
+
+	torrents := make(map[string]*Torrent)
+	for _, t := range s.torrents.GetAll("", "", nil) {
+		torrents[strings.ToUpper(t.Hash)] = t
+	}
+
+	// Items of Decypharr's torrents are ours whatever client the arr lists them under, other items are
+	// only ours when they're from one of Decypharr's clients
+	items := make(map[string][]arr.QueueSchema)
+	for _, q := range queue {
+		if q.DownloadId == "" {
+			continue
+		}
+		hash := strings.ToUpper(q.DownloadId)
+		if _, ok := torrents[hash]; !ok && !slices.Contains(clients, q.DownloadClient) {
+			continue
+		}
+		items[hash] = append(items[hash], q)
+	}
+	s.pruneReconcileImports(a, items)
+
+	stale := make([]int, 0)
+	for hash, queued := range items {
+		t := torrents[hash]
+		switch {
+		case t == nil:
+			s.logger.Info().Msgf("Removing %s from the queue of %s, its torrent is gone from Decypharr", queued[0].Title, a.Name)
+			stale = append(stale, queueIds(queued)...)
+		case t.State != "pausedUP" || !s.pastGracePeriod(t):
+			continue
+		case s.debridTorrentGone(t):
+			s.logger.Info().Msgf("Removing %s from the queue of %s, its torrent is gone from %s", queued[0].Title, a.Name, t.Debrid)
+			stale = append(stale, queueIds(queued)...)
+			s.torrents.Delete(t.Hash, t.Category, false)
+		case isStuckImport(queued):
+			s.importStuck(a, t, queued[0])
+		}
+	}
+	if err := a.RemoveFromQueue(stale, false); err != nil {
+		return fmt.Errorf("failed to remove stale queue items: %w", err)
+	}
+
+	for hash, t := range torrents {
+		if _, ok := items[hash]; ok || t.Category != a.Name || t.State != "pausedUP" || !s.pastGracePeriod(t) {
+			continue
+		}
+		grabbed, imported, err := a.DownloadHistory(hash)
+		if err != nil {
+			s.logger.Debug().Err(err).Msgf("Failed to get the history of %s from %s", t.Name, a.Name)
+			continue
+		}
+		// Torrents the arr never grabbed were added by hand, they're left alone
+		if !grabbed || imported {
+			continue
+		}
+		s.logger.Info().Msgf("Removing %s, %s grabbed it but never imported it and it's no longer in its queue", t.Name, a.Name)
+		s.torrents.Delete(t.Hash, t.Category, !s.debridTorrentShared(t))
+	}
+	return nil
+}
+
+// debridTorrentShared reports whether another of Decypharr's torrents, in any category, uses the same debrid torrent as t
+func (s *Store) debridTorrentShared(t *Torrent) bool {
+	for _, other := range s.torrents.GetAll("", "", nil) {
+		if other.Hash == t.Hash && other.Category == t.Category {
+			continue
+		}
+		if other.Debrid == t.Debrid && other.DebridID == t.DebridID {
+			return true
+		}
+	}
+	return false
+}
+
+// importStuck asks the arr to import a completed torrent it's stuck on. It's only tried once per torrent,
+// what the arr still can't import is left to Cleanup Queue and the user
+func (s *Store) importStuck(a *arr.Arr, t *Torrent, q arr.QueueSchema) {
+	if a.Type != arr.Sonarr && a.Type != arr.Radarr {
+		return
+	}
+	key := a.Name + "|" + t.Hash
+	if _, tried := s.reconcileImports.LoadOrStore(key, struct{}{}); tried {
+		return
+	}
+	path := q.OutputPath
+	if path == "" {
+		path = t.ContentPath
+	}
+	mediaId := q.SeriesId
+	if a.Type == arr.Radarr {
+		mediaId = q.MovieId
+	}
+	s.logger.Info().Msgf("Importing %s in %s, it's stuck in %s", t.Name, a.Name, q.TrackedDownloadState)
+	if err := a.Import(path, strings.ToUpper(t.Hash), mediaId); err != nil {
+		s.logger.Error().Err(err).Msgf("Failed to import %s in %s", t.Name, a.Name)
+	}
+}
+
+// pruneReconcileImports forgets the imports tried for the arr's torrents that left its queue
+func (s *Store) pruneReconcileImports(a *arr.Arr, items map[string][]arr.QueueSchema) {
+	prefix := a.Name + "|"
+	s.reconcileImports.Range(func(key, _ any) bool {
+		hash, ok := strings.CutPrefix(key.(string), prefix)
+		if ok {
+			if _, queued := items[strings.ToUpper(hash)]; !queued {
+				s.reconcileImports.Delete(key)
+			}
+		}
+		return true
+	})
+}
+
+// pastGracePeriod reports whether the torrent completed long enough ago. Torrents completed before their completion
+// was recorded go by when they were added
+func (s *Store) pastGracePeriod(t *Torrent) bool {
+	completedAt := int64(t.CompletionOn)
+	if completedAt == 0 {
+		completedAt = t.AddedOn
+	}
+	return completedAt > 0 && time.Since(time.Unix(completedAt, 0)) > reconcileGracePeriod
+}
+
+// debridTorrentGone reports whether the torrent was removed from the debrid. It's only known with a loaded debrid cache
+func (s *Store) debridTorrentGone(t *Torrent) bool {
+	if t.DebridID == "" || t.Debrid == "" {
+		return false
+	}
+	deb := s.debrid.Debrid(t.Debrid)
+	if deb == nil || deb.Cache() == nil {
+		return false
+	}
+	cache := deb.Cache()
+	select {
+	case <-cache.IsReady():
+	default:
+		return false
+	}
+	return cache.GetTorrent(t.DebridID) == nil && cache.GetTorrentByName(t.Name) == nil
+}
+
+func isStuckImport(queued []arr.QueueSchema) bool {
+	return slices.ContainsFunc(queued, func(q arr.QueueSchema) bool {
+		return q.Status == "completed" && slices.Contains(stuckImportStates, q.TrackedDownloadState)
+	})
+}
+
+func queueIds(queued []arr.QueueSchema) []int {
+	ids := make([]int, 0, len(queued))
+	for _, q := range queued {
+		ids = append(ids, q.Id)
+	}
+	return ids
+}
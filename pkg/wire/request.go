@@ -8,12 +8,16 @@ import (
 	"fmt"
 	"net/http"
 	"net/url"
+	"slices"
 	"sync"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/rs/zerolog"
 	"github.com/sirrobot01/decypharr/internal/config"
+	"github.com/sirrobot01/decypharr/internal/logger"
 	"github.com/sirrobot01/decypharr/internal/request"
+	"github.com/sirrobot01/decypharr/internal/storage"
 	"github.com/sirrobot01/decypharr/internal/utils"
 	"github.com/sirrobot01/decypharr/pkg/arr"
 	debridTypes "github.com/sirrobot01/decypharr/pkg/debrid/types"
@@ -22,8 +26,12 @@ import (
 type ImportType string
 
 const (
-	ImportTypeQBitTorrent ImportType = "qbit"
-	ImportTypeAPI         ImportType = "api"
+	ImportTypeQBitTorrent  ImportType = "qbit"
+	ImportTypeAPI          ImportType = "api"
+	ImportTypeSABnzbd      ImportType = "sabnzbd"
+	ImportTypeTransmission ImportType = "transmission"
+	ImportTypeDeluge       ImportType = "deluge"
+	ImportTypeBlackhole    ImportType = "blackhole"
 )
 
 type ImportRequest struct {
@@ -45,6 +53,15 @@ type ImportRequest struct {
 	Async bool       `json:"async"`
 }
 
+// ImportAction resolves what's done with a download: the action the client asked for, e.g. download for qBittorrent's
+// sequential download, then the arr's action, then symlink
+func ImportAction(a *arr.Arr, requested string) string {
+	if a != nil {
+		return cmp.Or(requested, a.Action, "symlink")
+	}
+	return cmp.Or(requested, "symlink")
+}
+
 func NewImportRequest(debrid string, downloadFolder string, magnet *utils.Magnet, arr *arr.Arr, action string, downloadUncached bool, callBackUrl string, importType ImportType, skipMultiSeason bool) *ImportRequest {
 	cfg := config.Get()
 	callBackUrl = cmp.Or(callBackUrl, cfg.CallbackURL)
@@ -115,25 +132,158 @@ func (i *ImportRequest) markAsCompleted(torrent *Torrent, debridTorrent *debridT
 	i.sendCallback(torrent, debridTorrent)
 }
 
+const importQueueBucket = "import_queue"
+
+// queuedRequest is how a queued request is saved, one record per request. The magnet's torrent file and NZB aren't
+// in its JSON, Position keeps the queue order
+type queuedRequest struct {
+	*ImportRequest
+	File     []byte `json:"file,omitempty"`
+	NZB      []byte `json:"nzb,omitempty"`
+	Position int    `json:"position"`
+}
+
 type ImportQueue struct {
 	queue  []*ImportRequest
 	mu     sync.RWMutex
 	ctx    context.Context
 	cancel context.CancelFunc
 	cond   *sync.Cond // For blocking operations
+	db     storage.Store
+	logger zerolog.Logger
+
+	positions    map[string]int // Saved position of each request, by id
+	nextPosition int
 }
 
-func NewImportQueue(ctx context.Context, capacity int) *ImportQueue {
+// NewImportQueue restores the requests saved in db, which keeps them across restarts. db can be nil
+func NewImportQueue(ctx context.Context, capacity int, db storage.Store) *ImportQueue {
 	ctx, cancel := context.WithCancel(ctx)
 	iq := &ImportQueue{
-		queue:  make([]*ImportRequest, 0, capacity),
-		ctx:    ctx,
-		cancel: cancel,
+		queue:     make([]*ImportRequest, 0, capacity),
+		ctx:       ctx,
+		cancel:    cancel,
+		db:        db,
+		logger:    logger.Default(),
+		positions: make(map[string]int),
 	}
 	iq.cond = sync.NewCond(&iq.mu)
+	iq.load()
 	return iq
 }
 
+func (iq *ImportQueue) load() {
+	if iq.db == nil {
+		return
+	}
+	saved := make([]queuedRequest, 0)
+	err := iq.db.ForEach(importQueueBucket, func(key string, value []byte) error {
+		var q queuedRequest
+		if err := json.Unmarshal(value, &q); err != nil {
+			iq.logger.Warn().Err(err).Msgf("Skipping queued import request %s, it can't be read", key)
+			return nil
+		}
+		if q.ImportRequest == nil || q.Magnet == nil || q.Arr == nil {
+			iq.logger.Warn().Msgf("Skipping queued import request %s, it's incomplete", key)
+			return nil
+		}
+		q.Magnet.File = q.File
+		q.Magnet.NZB = q.NZB
+		saved = append(saved, q)
+		return nil
+	})
+	if err != nil {
+		iq.logger.Error().Err(err).Msg("Failed to load the import queue")
+	}
+	slices.SortStableFunc(saved, func(a, b queuedRequest) int { return cmp.Compare(a.Position, b.Position) })
+	for _, q := range saved {
+		if len(iq.queue) >= cap(iq.queue) {
+			break
+		}
+		iq.queue = append(iq.queue, q.ImportRequest)
+		iq.positions[q.Id] = q.Position
+		iq.nextPosition = max(iq.nextPosition, q.Position+1)
+	}
+	if len(iq.queue) > 0 {
+		iq.logger.Info().Msgf("Restored %d queued import requests", len(iq.queue))
+	}
+}
+
+// saving reports whether changes are written to the db. A closed queue has been replaced, its state is stale
+func (iq *ImportQueue) saving() bool {
+	return iq.db != nil && iq.ctx.Err() == nil
+}
+
+// save writes the requests at the end of the queue, the lock must be held
+func (iq *ImportQueue) save(reqs ...*ImportRequest) {
+	if !iq.saving() {
+		return
+	}
+	records := make(map[string][]byte, len(reqs))
+	for _, req := range reqs {
+		iq.positions[req.Id] = iq.nextPosition
+		iq.nextPosition++
+		if data, err := iq.record(req); err == nil {
+			records[req.Id] = data
+		}
+	}
+	iq.put(records)
+}
+
+// saveOrder rewrites the position of every request, after a request was put in the middle of the queue. The lock must
+// be held
+func (iq *ImportQueue) saveOrder() {
+	if !iq.saving() {
+		return
+	}
+	records := make(map[string][]byte, len(iq.queue))
+	for i, req := range iq.queue {
+		iq.positions[req.Id] = i
+		if data, err := iq.record(req); err == nil {
+			records[req.Id] = data
+		}
+	}
+	iq.nextPosition = len(iq.queue)
+	iq.put(records)
+}
+
+// forget removes the requests from the db, the lock must be held
+func (iq *ImportQueue) forget(reqs ...*ImportRequest) {
+	if !iq.saving() || len(reqs) == 0 {
+		return
+	}
+	ids := make([]string, 0, len(reqs))
+	for _, req := range reqs {
+		delete(iq.positions, req.Id)
+		ids = append(ids, req.Id)
+	}
+	if err := iq.db.Delete(importQueueBucket, ids...); err != nil {
+		iq.logger.Error().Err(err).Msg("Failed to remove requests from the saved import queue")
+	}
+}
+
+func (iq *ImportQueue) record(req *ImportRequest) ([]byte, error) {
+	data, err := json.Marshal(queuedRequest{
+		ImportRequest: req,
+		File:          req.Magnet.File,
+		NZB:           req.Magnet.NZB,
+		Position:      iq.positions[req.Id],
+	})
+	if err != nil {
+		iq.logger.Error().Err(err).Msgf("Failed to save queued import request %s", req.Id)
+	}
+	return data, err
+}
+
+func (iq *ImportQueue) put(records map[string][]byte) {
+	if len(records) == 0 {
+		return
+	}
+	if err := iq.db.PutMany(importQueueBucket, records); err != nil {
+		iq.logger.Error().Err(err).Msg("Failed to save the import queue")
+	}
+}
+
 func (iq *ImportQueue) Push(req *ImportRequest) error {
 	if req == nil {
 		return fmt.Errorf("import request cannot be nil")
@@ -153,6 +303,7 @@ func (iq *ImportQueue) Push(req *ImportRequest) error {
 	}
 
 	iq.queue = append(iq.queue, req)
+	iq.save(req)
 	iq.cond.Signal() // Wake up any waiting Pop()
 	return nil
 }
@@ -173,6 +324,7 @@ func (iq *ImportQueue) Pop() (*ImportRequest, error) {
 
 	req := iq.queue[0]
 	iq.queue = iq.queue[1:]
+	iq.forget(req)
 	return req, nil
 }
 
@@ -185,25 +337,85 @@ func (iq *ImportQueue) Delete(requestID string) bool {
 		if req.Id == requestID {
 			// Remove from slice
 			iq.queue = append(iq.queue[:i], iq.queue[i+1:]...)
+			iq.forget(req)
 			return true
 		}
 	}
 	return false
 }
 
+// Remove takes the request out of the queue, returning where it was
+func (iq *ImportQueue) Remove(requestID string) (*ImportRequest, int) {
+	iq.mu.Lock()
+	defer iq.mu.Unlock()
+
+	for i, req := range iq.queue {
+		if req.Id == requestID {
+			iq.queue = append(iq.queue[:i], iq.queue[i+1:]...)
+			iq.forget(req)
+			return req, i
+		}
+	}
+	return nil, -1
+}
+
+// Insert puts a request back at position, e.g after a failed retry
+func (iq *ImportQueue) Insert(req *ImportRequest, position int) error {
+	if req == nil {
+		return fmt.Errorf("import request cannot be nil")
+	}
+
+	iq.mu.Lock()
+	defer iq.mu.Unlock()
+
+	if iq.ctx.Err() != nil {
+		return fmt.Errorf("queue is shutting down")
+	}
+	if len(iq.queue) >= cap(iq.queue) {
+		return fmt.Errorf("queue is full")
+	}
+	position = max(0, min(position, len(iq.queue)))
+	iq.queue = slices.Insert(iq.queue, position, req)
+	if position == len(iq.queue)-1 {
+		iq.save(req)
+	} else {
+		iq.saveOrder()
+	}
+	iq.cond.Signal()
+	return nil
+}
+
+// Move moves the request to position, 0 is the next to be processed
+func (iq *ImportQueue) Move(requestID string, position int) error {
+	iq.mu.Lock()
+	defer iq.mu.Unlock()
+
+	i := slices.IndexFunc(iq.queue, func(req *ImportRequest) bool { return req.Id == requestID })
+	if i < 0 {
+		return fmt.Errorf("import request %s not found", requestID)
+	}
+	req := iq.queue[i]
+	iq.queue = slices.Delete(iq.queue, i, i+1)
+	position = max(0, min(position, len(iq.queue)))
+	iq.queue = slices.Insert(iq.queue, position, req)
+	iq.saveOrder()
+	return nil
+}
+
 // DeleteWhere requests matching a condition
 func (iq *ImportQueue) DeleteWhere(predicate func(*ImportRequest) bool) int {
 	iq.mu.Lock()
 	defer iq.mu.Unlock()
 
-	deleted := 0
+	deleted := make([]*ImportRequest, 0)
 	for i := len(iq.queue) - 1; i >= 0; i-- {
 		if predicate(iq.queue[i]) {
+			deleted = append(deleted, iq.queue[i])
 			iq.queue = append(iq.queue[:i], iq.queue[i+1:]...)
-			deleted++
 		}
 	}
-	return deleted
+	iq.forget(deleted...)
+	return len(deleted)
 }
 
 // Find request without removing it
@@ -10,6 +10,7 @@ import (
 	"github.com/rs/zerolog"
 	"github.com/sirrobot01/decypharr/internal/config"
 	"github.com/sirrobot01/decypharr/internal/logger"
+	"github.com/sirrobot01/decypharr/internal/storage"
 	"github.com/sirrobot01/decypharr/pkg/arr"
 	"github.com/sirrobot01/decypharr/pkg/debrid"
 	"github.com/sirrobot01/decypharr/pkg/rclone"
@@ -29,6 +30,7 @@ type Store struct {
 	downloadSemaphore  chan struct{}
 	removeStalledAfter time.Duration // Duration after which stalled torrents are removed
 	scheduler          gocron.Scheduler
+	reconcileImports   sync.Map // Torrents the reconciler asked an arr to import, by arr and hash
 }
 
 var (
@@ -62,12 +64,12 @@ func Get() *Store {
 			arr:               arrs,
 			debrid:            deb,
 			rcloneManager:     rcManager,
-			torrents:          newTorrentStorage(cfg.TorrentsFile()),
+			torrents:          newTorrentStorage(storage.Get(), cfg.TorrentsFile()),
 			logger:            logger.Default(), // Use default logger [decypharr]
 			refreshInterval:   time.Duration(cmp.Or(qbitCfg.RefreshInterval, 30)) * time.Second,
 			skipPreCache:      qbitCfg.SkipPreCache,
 			downloadSemaphore: make(chan struct{}, cmp.Or(qbitCfg.MaxDownloads, 5)),
-			importsQueue:      NewImportQueue(context.Background(), 1000),
+			importsQueue:      NewImportQueue(context.Background(), 1000, storage.Get()),
 			scheduler:         scheduler,
 		}
 		if cfg.RemoveStalledAfter != "" {
@@ -121,6 +123,9 @@ func (s *Store) Repair() *repair.Repair {
 func (s *Store) Torrents() *TorrentStorage {
 	return s.torrents
 }
+func (s *Store) ImportQueue() *ImportQueue {
+	return s.importsQueue
+}
 func (s *Store) RcloneManager() *rclone.Manager {
 	return s.rcloneManager
 }
@@ -7,9 +7,10 @@ import (
 	"fmt"
 	"math"
 	"path/filepath"
+	"slices"
 	"time"
 
-	"github.com/sirrobot01/decypharr/internal/request"
+	"github.com/sirrobot01/decypharr/internal/notify"
 	"github.com/sirrobot01/decypharr/internal/utils"
 	debridTypes "github.com/sirrobot01/decypharr/pkg/debrid"
 	"github.com/sirrobot01/decypharr/pkg/debrid/types"
@@ -98,6 +99,8 @@ func (s *Store) processFiles(torrent *Torrent, debridTorrent *types.Torrent, imp
 	}
 	var torrentSymlinkPath, torrentRclonePath string
 	debridTorrent.Arr = _arr
+	// Files set to "Do not download" are left out of the symlinks, downloads and the webdav
+	debridTorrent.SkipFiles(torrent.SkippedFiles())
 
 	// Check if debrid supports webdav by checking cache
 	timer := time.Now()
@@ -119,11 +122,7 @@ func (s *Store) processFiles(torrent *Torrent, debridTorrent *types.Torrent, imp
 		s.logger.Info().Msgf("Adding %s took %s", debridTorrent.Name, time.Since(timer))
 
 		go importReq.markAsCompleted(torrent, debridTorrent) // Mark the import request as completed, send callback if needed
-		go func() {
-			if err := request.SendDiscordMessage("download_complete", "success", torrent.discordContext()); err != nil {
-				s.logger.Error().Msgf("Error sending discord message: %v", err)
-			}
-		}()
+		notify.Send(notify.EventDownloadComplete, "success", torrent.notifyMessage(), torrent.notifyData())
 		go func() {
 			_arr.Refresh()
 		}()
@@ -167,11 +166,7 @@ func (s *Store) processFiles(torrent *Torrent, debridTorrent *types.Torrent, imp
 				s.logger.Info().Msgf("Adding %s took %s", debridTorrent.Name, time.Since(timer))
 
 				go importReq.markAsCompleted(torrent, debridTorrent) // Mark the import request as completed, send callback if needed
-				go func() {
-					if err := request.SendDiscordMessage("download_complete", "success", torrent.discordContext()); err != nil {
-						s.logger.Error().Msgf("Error sending discord message: %v", err)
-					}
-				}()
+				notify.Send(notify.EventDownloadComplete, "success", torrent.notifyMessage(), torrent.notifyData())
 				go func() {
 					_arr.Refresh()
 				}()
@@ -236,6 +231,25 @@ func (s *Store) processFiles(torrent *Torrent, debridTorrent *types.Torrent, imp
 			return
 		}
 		onSuccess(torrentSymlinkPath)
+	case "strm":
+		// Strm action, we will write .strm files pointing at the internal webdav
+		s.logger.Debug().Msgf("Post-Download Action: Strm")
+		cache := deb.Cache()
+		if cache == nil {
+			onFailed(fmt.Errorf("strm action requires webdav to be enabled for %s", debridTorrent.Debrid))
+			return
+		}
+		if err := cache.Add(debridTorrent); err != nil {
+			onFailed(err)
+			return
+		}
+		torrentStrmPath := filepath.Join(torrent.SavePath, utils.RemoveExtension(debridTorrent.Name)) // /mnt/symlinks/{category}/MyTVShow/
+		torrentStrmPath, err = cache.WriteStrms(debridTorrent, torrentStrmPath)
+		if err != nil {
+			onFailed(err)
+			return
+		}
+		onSuccess(torrentStrmPath)
 	case "none":
 		s.logger.Debug().Msgf("Post-Download Action: None")
 		// No action, just update the torrent and mark it as completed
@@ -248,11 +262,7 @@ func (s *Store) processFiles(torrent *Torrent, debridTorrent *types.Torrent, imp
 func (s *Store) markTorrentAsFailed(t *Torrent) *Torrent {
 	t.State = "error"
 	s.torrents.AddOrUpdate(t)
-	go func() {
-		if err := request.SendDiscordMessage("download_failed", "error", t.discordContext()); err != nil {
-			s.logger.Error().Msgf("Error sending discord message: %v", err)
-		}
-	}()
+	notify.Send(notify.EventDownloadFailed, "error", t.notifyMessage(), t.notifyData())
 	return t
 }
 
@@ -280,15 +290,41 @@ func (s *Store) partialTorrentUpdate(t *Torrent, debridTorrent *types.Torrent) *
 	if speed != 0 {
 		eta = int((totalSize - sizeCompleted) / speed)
 	}
-	files := make([]*File, 0, len(debridTorrent.Files))
-	for index, file := range debridTorrent.GetFiles() {
+	// Keep the priorities set through filePrio and the names set through renameFile.
+	// Files saved before there were priorities have none, they start as normal
+	priorities := make(map[string]int, len(t.Files))
+	if slices.ContainsFunc(t.Files, func(f *File) bool { return f.Priority != 0 }) {
+		for _, file := range t.Files {
+			priorities[file.Name] = file.Priority
+		}
+	}
+	// Skipped files are still listed, so they can be selected again
+	debridFiles := make([]types.File, 0, len(debridTorrent.Files))
+	for _, file := range debridTorrent.Files {
+		if !file.Deleted {
+			debridFiles = append(debridFiles, file)
+		}
+	}
+	slices.SortFunc(debridFiles, func(a, b types.File) int { return cmp.Compare(a.Path, b.Path) })
+	files := make([]*File, 0, len(debridFiles))
+	for index, file := range debridFiles {
+		name := cmp.Or(t.FileRenames[file.Path], file.Path)
+		priority, ok := priorities[name]
+		if !ok {
+			priority = 1
+		}
 		files = append(files, &File{
-			Index: index,
-			Name:  file.Path,
-			Size:  file.Size,
+			Index:    index,
+			Name:     name,
+			Size:     file.Size,
+			Priority: priority,
 		})
 	}
 	t.DebridID = debridTorrent.Id
+	if debridTorrent.SelectionPolicy != "" {
+		t.DebridPolicy = debridTorrent.SelectionPolicy
+		t.DebridReason = debridTorrent.SelectionReason
+	}
 	t.Name = debridTorrent.Name
 	t.AddedOn = addedOn.Unix()
 	t.Files = files
@@ -324,6 +360,7 @@ func (s *Store) updateTorrent(t *Torrent, debridTorrent *types.Torrent) *Torrent
 
 	if t.IsReady() {
 		t.State = "pausedUP"
+		t.markCompleted()
 		s.torrents.Update(t)
 		return t
 	}
@@ -336,6 +373,7 @@ func (s *Store) updateTorrent(t *Torrent, debridTorrent *types.Torrent) *Torrent
 		case <-ticker.C:
 			if t.IsReady() {
 				t.State = "pausedUP"
+				t.markCompleted()
 				s.torrents.Update(t)
 				return t
 			}
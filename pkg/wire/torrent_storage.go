@@ -7,6 +7,9 @@ import (
 	"sort"
 	"sync"
 	"time"
+
+	"github.com/sirrobot01/decypharr/internal/logger"
+	"github.com/sirrobot01/decypharr/internal/storage"
 )
 
 func keyPair(hash, category string) string {
@@ -15,10 +18,12 @@ func keyPair(hash, category string) string {
 
 type Torrents = map[string]*Torrent
 
+const torrentsBucket = "torrents"
+
 type TorrentStorage struct {
 	torrents Torrents
 	mu       sync.RWMutex
-	filename string // Added to store the filename for persistence
+	db       storage.Store
 }
 
 func loadTorrentsFromJSON(filename string) (Torrents, error) {
@@ -33,16 +38,78 @@ func loadTorrentsFromJSON(filename string) (Torrents, error) {
 	return torrents, nil
 }
 
-func newTorrentStorage(filename string) *TorrentStorage {
-	// Open the JSON file and read the data
+func newTorrentStorage(db storage.Store, jsonFile string) *TorrentStorage {
+	ts := &TorrentStorage{
+		torrents: make(Torrents),
+		db:       db,
+	}
+	_log := logger.Default()
+	if err := ts.migrateFromJSON(jsonFile); err != nil {
+		_log.Error().Err(err).Msgf("Failed to migrate %s", jsonFile)
+	}
+	if err := ts.load(); err != nil {
+		_log.Error().Err(err).Msg("Failed to load torrents")
+	}
+	return ts
+}
+
+// migrateFromJSON imports the legacy torrents.json into the database.
+// It only runs when the database has no torrents, the json file is kept as a .bak
+func (ts *TorrentStorage) migrateFromJSON(filename string) error {
+	if _, err := os.Stat(filename); err != nil {
+		return nil
+	}
+	if count, err := ts.db.Count(torrentsBucket); err != nil || count > 0 {
+		return err
+	}
 	torrents, err := loadTorrentsFromJSON(filename)
 	if err != nil {
-		torrents = make(Torrents)
+		return err
 	}
-	// Create a new Storage
-	return &TorrentStorage{
-		torrents: torrents,
-		filename: filename,
+	items := make(map[string][]byte, len(torrents))
+	for _, torrent := range torrents {
+		if torrent == nil {
+			continue
+		}
+		data, err := json.Marshal(torrent)
+		if err != nil {
+			return err
+		}
+		items[keyPair(torrent.Hash, torrent.Category)] = data
+	}
+	if err := ts.db.PutMany(torrentsBucket, items); err != nil {
+		return err
+	}
+	_log := logger.Default()
+	_log.Info().Msgf("Migrated %d torrents from %s", len(items), filename)
+	return os.Rename(filename, filename+".bak")
+}
+
+func (ts *TorrentStorage) load() error {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	return ts.db.ForEach(torrentsBucket, func(key string, value []byte) error {
+		var torrent Torrent
+		if err := json.Unmarshal(value, &torrent); err != nil {
+			// Skip the broken entry, don't fail the whole load
+			return nil
+		}
+		torrent.normalizePriorities()
+		ts.torrents[key] = &torrent
+		return nil
+	})
+}
+
+// save persists a single torrent. It's called with mu held, so writes to the database happen in the same
+// order as the changes to the map and are done by the time the change returns
+func (ts *TorrentStorage) save(torrent *Torrent) {
+	data, err := json.Marshal(torrent)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	if err := ts.db.Put(torrentsBucket, keyPair(torrent.Hash, torrent.Category), data); err != nil {
+		fmt.Println(err)
 	}
 }
 
@@ -50,24 +117,14 @@ func (ts *TorrentStorage) Add(torrent *Torrent) {
 	ts.mu.Lock()
 	defer ts.mu.Unlock()
 	ts.torrents[keyPair(torrent.Hash, torrent.Category)] = torrent
-	go func() {
-		err := ts.saveToFile()
-		if err != nil {
-			fmt.Println(err)
-		}
-	}()
+	ts.save(torrent)
 }
 
 func (ts *TorrentStorage) AddOrUpdate(torrent *Torrent) {
 	ts.mu.Lock()
 	defer ts.mu.Unlock()
 	ts.torrents[keyPair(torrent.Hash, torrent.Category)] = torrent
-	go func() {
-		err := ts.saveToFile()
-		if err != nil {
-			fmt.Println(err)
-		}
-	}()
+	ts.save(torrent)
 }
 
 func (ts *TorrentStorage) Get(hash, category string) *Torrent {
@@ -113,6 +170,18 @@ func (ts *TorrentStorage) GetAll(category string, filter string, hashes []string
 	return torrents
 }
 
+// GetTorrents is GetAllSorted without the NZBs, they're tracked by the SABnzbd API and not reported to torrent clients
+func (ts *TorrentStorage) GetTorrents(category string, filter string, hashes []string, sortBy string, ascending bool) []*Torrent {
+	torrents := ts.GetAllSorted(category, filter, hashes, sortBy, ascending)
+	filtered := make([]*Torrent, 0, len(torrents))
+	for _, t := range torrents {
+		if t.Source != string(ImportTypeSABnzbd) {
+			filtered = append(filtered, t)
+		}
+	}
+	return filtered
+}
+
 func (ts *TorrentStorage) GetAllSorted(category string, filter string, hashes []string, sortBy string, ascending bool) []*Torrent {
 	torrents := ts.GetAll(category, filter, hashes)
 	if sortBy != "" {
@@ -156,12 +225,7 @@ func (ts *TorrentStorage) Update(torrent *Torrent) {
 	ts.mu.Lock()
 	defer ts.mu.Unlock()
 	ts.torrents[keyPair(torrent.Hash, torrent.Category)] = torrent
-	go func() {
-		err := ts.saveToFile()
-		if err != nil {
-			fmt.Println(err)
-		}
-	}()
+	ts.save(torrent)
 }
 
 func (ts *TorrentStorage) Delete(hash, category string, removeFromDebrid bool) {
@@ -185,6 +249,9 @@ func (ts *TorrentStorage) Delete(hash, category string, removeFromDebrid bool) {
 				}
 			}
 			delete(ts.torrents, key)
+			if err := ts.db.Delete(torrentsBucket, key); err != nil {
+				fmt.Println(err)
+			}
 
 			// Delete the torrent folder
 			if torrent.ContentPath != "" {
@@ -196,12 +263,6 @@ func (ts *TorrentStorage) Delete(hash, category string, removeFromDebrid bool) {
 			break
 		}
 	}
-	go func() {
-		err := ts.saveToFile()
-		if err != nil {
-			fmt.Println(err)
-		}
-	}()
 }
 
 func (ts *TorrentStorage) DeleteMultiple(hashes []string, removeFromDebrid bool) {
@@ -225,6 +286,9 @@ func (ts *TorrentStorage) DeleteMultiple(hashes []string, removeFromDebrid bool)
 					toDelete[torrent.DebridID] = torrent.Debrid
 				}
 				delete(ts.torrents, key)
+				if err := ts.db.Delete(torrentsBucket, key); err != nil {
+					fmt.Println(err)
+				}
 				if torrent.ContentPath != "" {
 					err := os.RemoveAll(torrent.ContentPath)
 					if err != nil {
@@ -235,13 +299,6 @@ func (ts *TorrentStorage) DeleteMultiple(hashes []string, removeFromDebrid bool)
 			}
 		}
 	}
-	go func() {
-		err := ts.saveToFile()
-		if err != nil {
-			fmt.Println(err)
-		}
-	}()
-
 	clients := st.debrid.Clients()
 
 	go func() {
@@ -258,19 +315,20 @@ func (ts *TorrentStorage) DeleteMultiple(hashes []string, removeFromDebrid bool)
 	}()
 }
 
+// Save persists all the torrents in a single transaction
 func (ts *TorrentStorage) Save() error {
-	return ts.saveToFile()
-}
-
-// saveToFile is a helper function to write the current state to the JSON file
-func (ts *TorrentStorage) saveToFile() error {
-	ts.mu.RLock()
-	data, err := json.MarshalIndent(ts.torrents, "", "  ")
-	ts.mu.RUnlock()
-	if err != nil {
-		return err
+	// Held for the write too, a torrent deleted meanwhile would be written back
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	items := make(map[string][]byte, len(ts.torrents))
+	for key, torrent := range ts.torrents {
+		data, err := json.Marshal(torrent)
+		if err != nil {
+			return err
+		}
+		items[key] = data
 	}
-	return os.WriteFile(ts.filename, data, 0644)
+	return ts.db.PutMany(torrentsBucket, items)
 }
 
 func (ts *TorrentStorage) Reset() {
@@ -2,7 +2,9 @@ package wire
 
 import (
 	"fmt"
+	"maps"
 	"sync"
+	"time"
 )
 
 type File struct {
@@ -22,6 +24,8 @@ type Torrent struct {
 	Debrid      string  `json:"debrid"`
 	TorrentPath string  `json:"-"`
 	Files       []*File `json:"files,omitempty"`
+	// FileRenames maps the debrid path of the files renamed through the qBittorrent API to their new name
+	FileRenames map[string]string `json:"file_renames,omitempty"`
 
 	AddedOn           int64   `json:"added_on,omitempty"`
 	AmountLeft        int64   `json:"amount_left"`
@@ -68,6 +72,8 @@ type Torrent struct {
 	UploadedSession   int64   `json:"uploaded_session,omitempty"`
 	Upspeed           int64   `json:"upspeed,omitempty"`
 	Source            string  `json:"source,omitempty"`
+	DebridPolicy      string  `json:"debrid_policy,omitempty"`
+	DebridReason      string  `json:"debrid_reason,omitempty"`
 
 	sync.Mutex
 }
@@ -78,6 +84,7 @@ func (t *Torrent) Copy() *Torrent {
 		DebridID:          t.DebridID,
 		Debrid:            t.Debrid,
 		TorrentPath:       t.TorrentPath,
+		FileRenames:       maps.Clone(t.FileRenames),
 		AddedOn:           t.AddedOn,
 		AmountLeft:        t.AmountLeft,
 		AutoTmm:           t.AutoTmm,
@@ -123,6 +130,8 @@ func (t *Torrent) Copy() *Torrent {
 		UploadedSession:   t.UploadedSession,
 		Upspeed:           t.Upspeed,
 		Source:            t.Source,
+		DebridPolicy:      t.DebridPolicy,
+		DebridReason:      t.DebridReason,
 	}
 }
 
@@ -130,7 +139,14 @@ func (t *Torrent) IsReady() bool {
 	return (t.AmountLeft <= 0 || t.Progress == 1) && t.TorrentPath != ""
 }
 
-func (t *Torrent) discordContext() string {
+// markCompleted sets when the torrent completed, the first time it does
+func (t *Torrent) markCompleted() {
+	if t.CompletionOn == 0 {
+		t.CompletionOn = int(time.Now().Unix())
+	}
+}
+
+func (t *Torrent) notifyMessage() string {
 	format := `
 		**Name:** %s
 		**Arr:** %s
@@ -140,3 +156,17 @@ func (t *Torrent) discordContext() string {
 	`
 	return fmt.Sprintf(format, t.Name, t.Category, t.Hash, t.MagnetUri, t.Debrid)
 }
+
+// notifyData is exposed to notification templates as .Data
+func (t *Torrent) notifyData() map[string]any {
+	return map[string]any{
+		"name":   t.Name,
+		"arr":    t.Category,
+		"hash":   t.Hash,
+		"magnet": t.MagnetUri,
+		"debrid": t.Debrid,
+		"size":   t.Size,
+		"path":   t.ContentPath,
+		"state":  t.State,
+	}
+}